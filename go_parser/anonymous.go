@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+	"strings"
+)
+
+// Function to find the interface literals typing function and method
+// parameters and struct fields, such as c interface{ Close() error }, with
+// anonymous_interfaces set. They have no name, so each is named by its
+// location, e.g. "store.go:12:15", and Anonymous says what it types. Empty
+// interfaces are left out: interface{} is any, not an ad-hoc abstraction.
+func findAnonymousInterfaces(fset *token.FileSet, node *ast.File, config *Config) []*InterfaceDetails {
+	var found []*InterfaceDetails
+	add := func(fields *ast.FieldList, kind, owner string, exported bool) {
+		if fields == nil || (config.ExportedOnly && !exported) {
+			return
+		}
+		for _, field := range fields.List {
+			interfaceType, ok := field.Type.(*ast.InterfaceType)
+			if !ok || len(interfaceType.Methods.List) == 0 {
+				continue
+			}
+			var names []string
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+			site := kind
+			switch {
+			case len(names) > 1:
+				site += "s " + strings.Join(names, ", ")
+			case len(names) == 1:
+				site += " " + names[0]
+			}
+
+			pos := fset.Position(interfaceType.Pos())
+			name := fmt.Sprintf("%s:%d:%d", filepath.Base(pos.Filename), pos.Line, pos.Column)
+			details := newInterfaceDetails(fset, node, interfaceType, name, nil, pos, config)
+			details.Anonymous = site + " of " + owner
+			if field.Doc != nil {
+				details.Doc = strings.TrimSpace(field.Doc.Text())
+			}
+			found = append(found, details)
+		}
+	}
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			owner := "func " + n.Name.Name
+			if n.Recv != nil && len(n.Recv.List) > 0 {
+				receiver, _ := receiverTypeName(n.Recv.List[0].Type)
+				owner = "method " + receiver + "." + n.Name.Name
+			}
+			add(n.Type.Params, "parameter", owner, n.Name.IsExported())
+		case *ast.TypeSpec:
+			if structType, ok := n.Type.(*ast.StructType); ok {
+				add(structType.Fields, "field", "struct "+n.Name.Name, n.Name.IsExported())
+			}
+		}
+		return true
+	})
+	return found
+}
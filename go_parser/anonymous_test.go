@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnonymousInterfaces(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "pool.go")
+	writeFile(t, path, `package pool
+
+type Pool struct {
+	// Closes connections on shutdown
+	Closer interface{ Close() error }
+	Any    interface{}
+}
+
+func Open(c interface{ Close() error }, a, b interface {
+	Name() string
+}) {
+}
+
+func (p *Pool) Put(w interface{ Write(p []byte) (int, error) }) {}
+
+func drain(c interface{ Close() error }) {}
+
+type File struct{}
+
+func (File) Close() error { return nil }
+`)
+
+	interfaces, _ := findInterfaces(path, &Config{})
+	if len(interfaces) != 0 {
+		t.Fatalf("interfaces = %v, want none unless anonymous_interfaces is set", sortedInterfaceNames(interfaces))
+	}
+
+	config := &Config{AnonymousInterfaces: true}
+	interfaces, _ = findInterfaces(path, config)
+	want := map[string]string{
+		"pool.go:5:9":   "field Closer of struct Pool",
+		"pool.go:9:13":  "parameter c of func Open",
+		"pool.go:9:46":  "parameters a, b of func Open",
+		"pool.go:14:22": "parameter w of method Pool.Put",
+		"pool.go:16:14": "parameter c of func drain",
+	}
+	if len(interfaces) != len(want) {
+		t.Errorf("interfaces = %v, want %d", sortedInterfaceNames(interfaces), len(want))
+	}
+	for name, site := range want {
+		iface := interfaceNamed(interfaces, name)
+		if iface == nil {
+			t.Errorf("%s not found", name)
+			continue
+		}
+		if iface.Anonymous != site || iface.Package != "pool" {
+			t.Errorf("%s types %q in %s, want %q", name, iface.Anonymous, iface.Package, site)
+		}
+	}
+	if doc := interfaceNamed(interfaces, "pool.go:5:9").Doc; doc != "Closes connections on shutdown" {
+		t.Errorf("field doc = %q", doc)
+	}
+
+	// They are matched like any interface
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+	for _, result := range results {
+		if result.InterfaceName == "pool.go:9:13" {
+			if len(result.Implementations) != 1 || result.Implementations[0].TypeName != "File" {
+				t.Errorf("implementations = %+v, want File", result.Implementations)
+			}
+			if md := renderMarkdown([]InterfaceDetails{result}, nil); !strings.Contains(md, "## pool.go:9:13\n\nPackage `pool`.\n\nAnonymous interface, the type of parameter c of func Open.") {
+				t.Errorf("markdown:\n%s", md)
+			}
+		}
+	}
+
+	config.ExportedOnly = true
+	interfaces, _ = findInterfaces(path, config)
+	if interfaceNamed(interfaces, "pool.go:16:14") != nil || len(interfaces) != 4 {
+		t.Errorf("interfaces = %v, want those of unexported funcs left out", sortedInterfaceNames(interfaces))
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	anthropicBaseURL      = "https://api.anthropic.com/v1"
+	anthropicVersion      = "2023-06-01"
+	anthropicDefaultModel = "claude-3-5-sonnet-latest"
+	// Default max_tokens when max_completion_tokens isn't configured
+	anthropicMaxTokens = 4096
+)
+
+// anthropicClient talks to the Anthropic Messages API
+type anthropicClient struct {
+	api     *httpAPI
+	baseURL string
+	apiKey  string
+	model   string
+	config  *Config
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// Function to validate the API key by listing the models
+func (c *anthropicClient) checkAuth(ctx context.Context) error {
+	resp, err := c.api.get(ctx, c.baseURL+"/models", map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *anthropicClient) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	// The Messages API takes system prompts as a separate field
+	var system []string
+	var messages []PromptMessage
+	for _, message := range buildMessages(c.config, prompt) {
+		if message.Role == RoleSystem {
+			system = append(system, message.Content)
+		} else {
+			messages = append(messages, message)
+		}
+	}
+	// A final assistant turn is a prefill the reply continues from, and it
+	// may not end in whitespace
+	seed := ""
+	if last := len(messages) - 1; last >= 0 && messages[last].Role == RoleAssistant {
+		messages[last].Content = strings.TrimRight(messages[last].Content, " \t\r\n")
+		seed = messages[last].Content
+	}
+
+	payload := map[string]interface{}{
+		"model":      c.model,
+		"max_tokens": c.maxTokens(),
+		"messages":   messages,
+	}
+	if len(system) > 0 {
+		payload["system"] = strings.Join(system, "\n\n")
+	}
+
+	if c.config.Temperature != nil {
+		payload["temperature"] = *c.config.Temperature
+	}
+
+	var response anthropicResponse
+	headers := map[string]string{
+		"x-api-key":         c.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+	if err := c.api.postJSON(ctx, c.baseURL+"/messages", headers, payload, &response); err != nil {
+		return nil, err
+	}
+
+	// The reply is a list of content blocks; join the text ones
+	var text []string
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			text = append(text, block.Text)
+		}
+	}
+	if len(text) == 0 {
+		return nil, fmt.Errorf("response contained no text content")
+	}
+	return &Completion{
+		Content: seed + strings.Join(text, ""),
+		Usage: TokenUsage{
+			PromptTokens:     response.Usage.InputTokens,
+			CompletionTokens: response.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// Function to get max_tokens for a request; the Messages API requires it
+func (c *anthropicClient) maxTokens() int {
+	if c.config.MaxCompletionTokens > 0 {
+		return c.config.MaxCompletionTokens
+	}
+	return anthropicMaxTokens
+}
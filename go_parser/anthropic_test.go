@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// The reply is split over two text blocks, with a block of another type
+// between them that must be skipped
+const cannedAnthropicMessage = `{
+	"content": [
+		{"type": "text", "text": "Store persists "},
+		{"type": "tool_use", "id": "toolu_1", "name": "lookup", "input": {}},
+		{"type": "text", "text": "items."}
+	],
+	"usage": {"input_tokens": 80, "output_tokens": 20}
+}`
+
+// Function to start a fake Messages endpoint that checks the headers and
+// hands each decoded request to inspect, then answers with status and body
+func fakeAnthropic(t *testing.T, status int, body string, inspect func(payload map[string]interface{})) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/messages" {
+			t.Errorf("path = %s, want /messages", r.URL.Path)
+		}
+		if got := r.Header.Get("x-api-key"); got != "test-key" {
+			t.Errorf("x-api-key = %q", got)
+		}
+		if got := r.Header.Get("anthropic-version"); got != anthropicVersion {
+			t.Errorf("anthropic-version = %q, want %s", got, anthropicVersion)
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none", got)
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		if inspect != nil {
+			inspect(payload)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestAnthropicComplete(t *testing.T) {
+	var payload map[string]interface{}
+	server, calls := fakeAnthropic(t, http.StatusOK, cannedAnthropicMessage, func(p map[string]interface{}) { payload = p })
+	config := &Config{
+		Provider: "anthropic", APIKey: "test-key", BaseURL: server.URL,
+		Messages: []PromptMessage{
+			{Role: RoleSystem, Content: "You document Go code."},
+			{Role: RoleSystem, Content: "Be brief."},
+			{Role: RoleUser, Content: "Document:\n" + resultsPlaceholder},
+		},
+	}
+	client, err := newLLMClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	completion, err := client.Complete(context.Background(), "type Store interface{}")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if completion.Content != "Store persists items." {
+		t.Errorf("content = %q, want the text blocks joined", completion.Content)
+	}
+	if completion.Usage.PromptTokens != 80 || completion.Usage.CompletionTokens != 20 {
+		t.Errorf("usage = %+v", completion.Usage)
+	}
+	if *calls != 1 {
+		t.Errorf("requests = %d, want 1", *calls)
+	}
+
+	// System prompts go in the top-level system field, not in messages
+	if system := payload["system"]; system != "You document Go code.\n\nBe brief." {
+		t.Errorf("system = %#v", system)
+	}
+	messages, _ := payload["messages"].([]interface{})
+	if len(messages) != 1 {
+		t.Fatalf("messages = %#v, want only the user turn", payload["messages"])
+	}
+	if message := messages[0].(map[string]interface{}); message["role"] != RoleUser || message["content"] != "Document:\ntype Store interface{}" {
+		t.Errorf("message = %#v", message)
+	}
+	if payload["model"] != anthropicDefaultModel || payload["max_tokens"] != float64(anthropicMaxTokens) {
+		t.Errorf("model = %v, max_tokens = %v", payload["model"], payload["max_tokens"])
+	}
+}
+
+func TestAnthropicErrorBody(t *testing.T) {
+	body := `{"type": "error", "error": {"type": "invalid_request_error", "message": "max_tokens: too large"}}`
+	server, calls := fakeAnthropic(t, http.StatusBadRequest, body, nil)
+	client, err := newLLMClient(&Config{Provider: "anthropic", APIKey: "test-key", BaseURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Complete(context.Background(), "prompt")
+	var provider *ProviderError
+	if !errors.As(err, &provider) {
+		t.Fatalf("Complete error = %v, want a ProviderError", err)
+	}
+	if provider.StatusCode != http.StatusBadRequest || !strings.Contains(provider.Body, "max_tokens: too large") {
+		t.Errorf("provider error = %d %q, want the 400 with its body", provider.StatusCode, provider.Body)
+	}
+	if code := exitCode(err); code != exitProvider {
+		t.Errorf("exit code = %d, want %d", code, exitProvider)
+	}
+	if *calls != 1 {
+		t.Errorf("requests = %d, want no retry of a 400", *calls)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Analyze runs discovery and matching for cfg and returns the report: the
+// interfaces with their implementations and usages, the exported constants
+// and variables, the undocumented methods and the summary. Nothing is
+// printed and no LLM is called, so the analysis can be used from code and
+// tests; see Report.Markdown and Report.SendTo for the outputs.
+func Analyze(cfg Config) (*Report, error) {
+	return AnalyzeContext(context.Background(), cfg)
+}
+
+// AnalyzeContext is Analyze stopping the scan once ctx is done, in which case
+// the report is marked partial
+func AnalyzeContext(ctx context.Context, cfg Config) (*Report, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return analyzeReport(ctx, &cfg)
+}
+
+// Function to run discovery, filtering and matching for a config. When ctx
+// is cancelled during the scan, the results gathered so far are returned.
+func analyzeReport(ctx context.Context, config *Config) (*Report, error) {
+	start := time.Now()
+
+	// Parse the interface sources to find all interfaces and their methods
+	sources := config.interfaceSources()
+	interfaces, conflicts, err := findAllInterfaces(ctx, sources, config)
+	if err != nil {
+		return nil, fmt.Errorf("reading interfaces: %w", err)
+	}
+
+	// Drop interfaces excluded by the include/exclude lists before matching
+	filtered, err := filterInterfaces(interfaces, config)
+	if err != nil {
+		return nil, fmt.Errorf("filtering interfaces: %w", err)
+	}
+
+	// Walk the roots to find implementations of these interfaces
+	roots := config.scanRoots()
+	results, types, values, err := scanImplementations(ctx, roots, interfaces, config)
+	if err != nil {
+		return nil, fmt.Errorf("scanning for implementations: %w", err)
+	}
+	attachExamples(results)
+	markDeprecated(results)
+
+	// Let the user pick the interfaces worth documenting
+	if config.Interactive && len(results) > 0 {
+		selected := selectInterfaces(os.Stdin, os.Stderr, results)
+		filtered += len(results) - len(selected)
+		results = selected
+		if config.SaveSelection != "" {
+			if err := saveIncludeInterfaces(config.SaveSelection, results); err != nil {
+				return nil, withExitCode(exitOutput, fmt.Errorf("saving the selection to %s: %w", config.SaveSelection, err))
+			}
+			fmt.Fprintf(os.Stderr, "Saved %d interfaces to include_interfaces in %s\n", len(results), config.SaveSelection)
+		}
+	}
+
+	// In incremental mode only interfaces whose declaration or
+	// implementations changed are documented
+	if config.Since != "" {
+		changed, err := changedFilesSince(filepath.Dir(config.GoFilePath), config.Since)
+		if err != nil {
+			return nil, fmt.Errorf("listing files changed since %s: %w", config.Since, err)
+		}
+		var unchanged int
+		results, unchanged = keepChanged(results, changed)
+		filtered += unchanged
+	}
+
+	// Stubs for a partial scan could duplicate implementations it didn't reach
+	if config.GenerateStubs != "" && ctx.Err() == nil {
+		if err := generateStubs(config.GenerateStubs, results); err != nil {
+			return nil, withExitCode(exitOutput, fmt.Errorf("generating stubs: %w", err))
+		}
+	}
+
+	config.Metrics.scanDone(time.Since(start), len(results))
+
+	summary := summarize(results, filtered)
+	summary.Roots = summarizeRoots(results, sources, roots)
+	return &Report{
+		SchemaVersion: schemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		ToolVersion:   toolVersion(),
+		Paths:         ReportPaths{InterfaceSources: sources, Roots: roots},
+		ReportResults: ReportResults{
+			Interfaces:   results,
+			Summary:      summary,
+			Partial:      ctx.Err() != nil,
+			Conflicts:    conflicts,
+			Undocumented: undocumentedMethods(results),
+			ParseErrors:  config.skippedFiles,
+			SkippedFiles: config.limitedFiles,
+			Values:       values,
+			Graph:        buildPackageGraph(results),
+		},
+		types:  types,
+		config: config,
+	}, nil
+}
+
+// Markdown renders the report as the render command does: the interfaces,
+// with test doubles only counted unless show_test_doubles is set, then the
+// package summaries and the constants and variables
+func (r *Report) Markdown() string {
+	config := r.config
+	if config == nil {
+		config = &Config{ShowTestDoubles: true}
+	}
+	results := collapseTestDoubles(r.Interfaces, config)
+	frontMatter, err := renderFrontMatter(config.MarkdownFrontmatter, pageFrontMatter(results))
+	if err != nil {
+		log.Printf("Warning: leaving out the front-matter: %v", err)
+	}
+	msgs := docMessages(config)
+	return frontMatter + partialMarker(r.Partial, msgs) + renderMarkdown(results, msgs) + renderPackageSummaries(r.Packages, msgs) + renderValues(r.Values, msgs)
+}
+
+// SendTo asks an LLM provider ("openai", "anthropic", "ollama" or "mock"; empty
+// keeps the configured one) to document the report's interfaces and returns
+// the documentation. The API key comes from the config or, when it has
+// none, from the environment or api_key_file; ollama and mock need none.
+func (r *Report) SendTo(ctx context.Context, provider string) (string, error) {
+	var config Config
+	if r.config != nil {
+		config = *r.config
+	}
+	if provider != "" {
+		config.Provider = provider
+	}
+	if config.APIKey == "" && needsAPIKey(config.Provider) {
+		key, _, err := resolveAPIKey(&config)
+		if err != nil {
+			return "", err
+		}
+		config.APIKey = key
+	}
+	return sendData(ctx, &config, collapseTestDoubles(r.Interfaces, &config), newUsageTracker(&config))
+}
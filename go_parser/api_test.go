@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "store.go")
+	writeFile(t, ifacePath, `package app
+
+// Store persists items.
+type Store interface {
+	Get(id string) (string, error)
+}
+
+// DefaultLimit caps the items listed.
+const DefaultLimit = 100
+`)
+	writeFile(t, filepath.Join(root, "mem.go"), `package app
+
+type MemStore struct{}
+
+func (m *MemStore) Get(id string) (string, error) { return "", nil }
+
+type MockStore struct{}
+
+func (MockStore) Get(id string) (string, error) { return "", nil }
+`)
+
+	server, calls := fakeOpenAI(t)
+	report, err := Analyze(Config{GoFilePath: ifacePath, GoDirectory: root, APIKey: "test-key", BaseURL: server.URL, NoStream: true})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if report.Summary.TotalInterfaces != 1 || len(report.Interfaces[0].Implementations) != 2 || len(report.Values) != 1 || report.Partial {
+		t.Errorf("report = %+v", report)
+	}
+
+	markdown := report.Markdown()
+	for _, want := range []string{"## Store", "`app.MemStore`", "- _and 1 test doubles (mocks, fakes, stubs)_", "- `app.DefaultLimit = 100`"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+
+	docs, err := report.SendTo(context.Background(), "openai")
+	if err != nil || docs != "Store persists items." || *calls != 1 {
+		t.Errorf("SendTo = %q, %v after %d requests", docs, err, *calls)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	if _, err := Analyze(Config{GoFilePath: filepath.Join(t.TempDir(), "missing.go")}); err == nil || !strings.Contains(err.Error(), "reading interfaces") {
+		t.Errorf("missing file: err = %v", err)
+	}
+	if _, err := Analyze(Config{Format: "xml"}); err == nil || !strings.Contains(err.Error(), "invalid format") {
+		t.Errorf("invalid config: err = %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const checkAuthTimeout = 30 * time.Second
+
+// Function to list the environment variables an API key is read from, most
+// preferred first: the provider's own variable, then the legacy API_KEY
+func apiKeyEnvVars(provider string) []string {
+	if provider == "anthropic" {
+		return []string{"ANTHROPIC_API_KEY", "API_KEY"}
+	}
+	return []string{"OPENAI_API_KEY", "API_KEY"}
+}
+
+// Function to tell whether a provider needs an API key; a local Ollama
+// server and the mock provider don't
+func needsAPIKey(provider string) bool {
+	return provider != "ollama" && provider != "mock"
+}
+
+// Function to find the API key in the environment or, failing that, in
+// api_key_file (e.g. a docker secret). Returns where the key came from,
+// for messages that must never show the key itself.
+func resolveAPIKey(config *Config) (string, string, error) {
+	for _, name := range apiKeyEnvVars(config.Provider) {
+		if key := strings.TrimSpace(os.Getenv(name)); key != "" {
+			return key, name, nil
+		}
+	}
+	if config.APIKeyFile != "" {
+		data, err := os.ReadFile(config.APIKeyFile)
+		if err != nil {
+			return "", "", fmt.Errorf("reading api_key_file: %w", err)
+		}
+		key := strings.TrimSpace(string(data))
+		if key == "" {
+			return "", "", fmt.Errorf("api_key_file %s is empty", config.APIKeyFile)
+		}
+		return key, config.APIKeyFile, nil
+	}
+	return "", "", fmt.Errorf("no API key: set %s or api_key_file", strings.Join(apiKeyEnvVars(config.Provider), " or "))
+}
+
+// Function to load the API key into the config for commands that call the
+// LLM, exiting when there is none. Returns where the key came from, or ""
+// for a provider that needs no key.
+func requireAPIKey(config *Config) string {
+	if !needsAPIKey(config.Provider) {
+		return ""
+	}
+	key, source, err := resolveAPIKey(config)
+	if err != nil {
+		fatalf(exitUsage, "Error: %v", err)
+	}
+	config.APIKey = key
+	return source
+}
+
+// Function to hide a secret in text that is about to be logged
+func maskSecret(text, secret string) string {
+	if secret == "" {
+		return text
+	}
+	return strings.ReplaceAll(text, secret, "[REDACTED]")
+}
+
+// Shown in place of a secret when a config is printed
+const maskedSecret = "***"
+
+// configFields has the fields of Config without its methods, so String
+// can print them without calling itself
+type configFields Config
+
+// Function to print the config with its secrets (the API key and the Slack
+// webhook URL) masked, so a config that ends up in a log or a %v doesn't
+// leak them
+func (c Config) String() string {
+	if c.APIKey != "" {
+		c.APIKey = maskedSecret
+	}
+	if c.Notifications.SlackWebhookURL != "" {
+		c.Notifications.SlackWebhookURL = maskedSecret
+	}
+	return fmt.Sprintf("%+v", configFields(c))
+}
+
+// Function to mask the secrets for %#v too
+func (c Config) GoString() string {
+	return "Config" + c.String()
+}
+
+// authChecker is implemented by clients that can validate their API key
+// with a cheap request
+type authChecker interface {
+	checkAuth(ctx context.Context) error
+}
+
+// Function to check the API key through a client wrapped by another one,
+// such as the rate limiter
+func checkAuthOf(ctx context.Context, client LLMClient, provider string) error {
+	checker, ok := client.(authChecker)
+	if !ok {
+		return fmt.Errorf("the %s provider can't check its API key", provider)
+	}
+	return checker.checkAuth(ctx)
+}
+
+// Function to validate the API key by listing the provider's models, or for
+// ollama to check the model is pulled, printing the outcome to stderr.
+// Returns the exit code.
+func runCheckAuth(config *Config) int {
+	source := requireAPIKey(config)
+	client, err := newLLMClient(config)
+	if err != nil {
+		log.Printf("Error in config: %v", err)
+		return exitUsage
+	}
+	checker, ok := client.(authChecker)
+	if !ok {
+		log.Printf("Error: the %s provider can't check its API key", config.Provider)
+		return exitUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkAuthTimeout)
+	defer cancel()
+	err = checker.checkAuth(ctx)
+	switch {
+	case source == "" && err != nil:
+		fmt.Fprintf(os.Stderr, "Check of %s failed: %v\n", config.Provider, err)
+		return exitProvider
+	case source == "":
+		fmt.Fprintf(os.Stderr, "%s is reachable and has model %s\n", config.Provider, modelName(config))
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "API key from %s was rejected: %v\n", source, err)
+		return exitProvider
+	default:
+		fmt.Fprintf(os.Stderr, "API key from %s is valid\n", source)
+	}
+	return exitOK
+}
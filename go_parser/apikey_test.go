@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveAPIKeyPrecedence(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "api_key")
+	writeFile(t, keyFile, "file-key\n")
+	config := &Config{APIKeyFile: keyFile}
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("API_KEY", "")
+	if key, source, err := resolveAPIKey(config); err != nil || key != "file-key" || source != keyFile {
+		t.Errorf("from file: %q, %q, %v", key, source, err)
+	}
+
+	t.Setenv("API_KEY", "legacy-key")
+	if key, source, _ := resolveAPIKey(config); key != "legacy-key" || source != "API_KEY" {
+		t.Errorf("legacy: %q from %q", key, source)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+	if key, source, _ := resolveAPIKey(config); key != "openai-key" || source != "OPENAI_API_KEY" {
+		t.Errorf("preferred: %q from %q", key, source)
+	}
+
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("API_KEY", "")
+	if _, _, err := resolveAPIKey(&Config{}); err == nil || !strings.Contains(err.Error(), "OPENAI_API_KEY or API_KEY") {
+		t.Errorf("no key: err = %v", err)
+	}
+}
+
+func TestCheckAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/models" {
+			t.Errorf("request = %s %s, want GET /models", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer good-key" {
+			// Providers may echo the key back
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": "Incorrect API key provided: ` + strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") + `"}`))
+			return
+		}
+		w.Write([]byte(`{"data": []}`))
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		key string
+		ok  bool
+	}{{"good-key", true}, {"secret-bad-key", false}} {
+		client, err := newLLMClient(&Config{APIKey: tc.key, BaseURL: server.URL})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = client.(authChecker).checkAuth(context.Background())
+		if (err == nil) != tc.ok {
+			t.Errorf("checkAuth with %s = %v", tc.key, err)
+		}
+		if err != nil && strings.Contains(err.Error(), tc.key) {
+			t.Errorf("error shows the key: %v", err)
+		}
+	}
+}
+
+func TestConfigStringMasksSecrets(t *testing.T) {
+	config := Config{APIKey: "sk-secret", Model: "gpt-4o", Notifications: NotificationConfig{SlackWebhookURL: "https://hooks.slack.com/services/T0/B0/secret"}}
+	for _, s := range []string{config.String(), fmt.Sprint(config), fmt.Sprintf("%v", &config), fmt.Sprintf("%+v", config), fmt.Sprintf("%#v", config)} {
+		if strings.Contains(s, "secret") {
+			t.Errorf("secret printed: %s", s)
+		}
+		if !strings.Contains(s, "APIKey:***") || !strings.Contains(s, "Model:gpt-4o") {
+			t.Errorf("config printed as %s", s)
+		}
+	}
+	if config.APIKey != "sk-secret" {
+		t.Error("String changed the config")
+	}
+}
+
+func TestRequestErrorMasksAPIKey(t *testing.T) {
+	// A key in the URL ends up in the error of a failed request
+	api, err := newHTTPAPI(&Config{APIKey: "sk-secret", MaxRetries: new(int)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = api.get(context.Background(), "http://127.0.0.1:1/v1/models?key=sk-secret", nil)
+	if err == nil || strings.Contains(err.Error(), "sk-secret") || !strings.Contains(err.Error(), "[REDACTED]") {
+		t.Errorf("err = %v", err)
+	}
+}
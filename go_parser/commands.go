@@ -0,0 +1,567 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// A subcommand with its own flag set
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+var commands = []command{
+	{"analyze", "find interfaces and implementations and print them as JSON", runAnalyze},
+	{"render", "render the analysis as markdown without calling the API", runRender},
+	{"send", "send the analysis to the LLM API (default)", runSend},
+	{"explain", "analyze one interface by name and print a focused report", runExplain},
+	{"openapi", "write an OpenAPI skeleton for the routes annotated on interface methods", runOpenAPI},
+	{"package-docs", "write a doc summarizing each scanned package into its directory", runPackageDocs},
+	{"diff", "compare two analysis JSON files, or a baseline with a fresh scan", runDiff},
+	{"schema", "print the JSON Schema of the analyze output", runSchema},
+}
+
+// Function to dispatch to a subcommand. Running without one behaves like
+// "send" so existing invocations keep working.
+func dispatch(args []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		runSend(args)
+		return
+	}
+
+	if args[0] == "help" {
+		usage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			cmd.run(args[1:])
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage()
+	os.Exit(exitUsage)
+}
+
+// Function to print the list of subcommands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: go_parser <command> [flags]\n\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nRun 'go_parser <command> -h' for the flags of a command.")
+}
+
+// Flags shared by every subcommand
+type commonFlags struct {
+	configPaths   []string
+	profile       string
+	progress      bool
+	generateStubs string
+	since         string
+	metricsAddr   string
+	interactive   bool
+	saveSelection bool
+	strict        bool
+	writePkgDocs  bool
+	maxUndoc      int
+	failOnDepr    bool
+	packages      []string
+	exclude       []string
+	testDoubles   bool
+	debug         bool
+}
+
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.Func("config", "path to the config file (.yaml, .yml, .json or .toml), config.yaml by default; repeat it to merge several, later files overriding earlier ones", func(path string) error {
+		c.configPaths = append(c.configPaths, path)
+		return nil
+	})
+	fs.StringVar(&c.profile, "profile", "", "profile of the config file to merge over its top-level keys; defaults to the profile named default, if any")
+	fs.BoolVar(&c.progress, "progress", false, "periodically print the number of files processed")
+	fs.StringVar(&c.generateStubs, "generate-stubs", "", "write skeleton implementations of unimplemented interfaces to this directory")
+	fs.StringVar(&c.since, "since", "", "only process interfaces declared or implemented in files changed since this git ref")
+	fs.StringVar(&c.metricsAddr, "metrics-addr", "", "serve Prometheus metrics at /metrics on this address, e.g. :9090")
+	fs.BoolVar(&c.interactive, "interactive", false, "pick the interfaces to keep from a list after the scan")
+	fs.Func("package", "package pattern to search for implementations instead of go_directory, e.g. ./... (repeatable)", func(pattern string) error {
+		c.packages = append(c.packages, pattern)
+		return nil
+	})
+	fs.Func("exclude-interface", "leave out the interfaces matching this name, glob or regular expression, like exclude_interfaces (repeatable)", func(pattern string) error {
+		c.exclude = append(c.exclude, pattern)
+		return nil
+	})
+	fs.BoolVar(&c.saveSelection, "save-selection", false, "with -interactive, save the picked interfaces as include_interfaces in the config file")
+	fs.BoolVar(&c.testDoubles, "show-test-doubles", false, "list mocks, fakes and stubs in the markdown and the LLM prompt instead of only counting them")
+	fs.BoolVar(&c.strict, "strict", false, "exit with code 2 when scanned Go files were skipped as they don't parse")
+	fs.BoolVar(&c.writePkgDocs, "write-package-docs", false, "with package_summaries, write each summary as the package comment of a new doc.go in packages that have none")
+	fs.BoolVar(&c.failOnDepr, "fail-on-deprecated-usage", false, "exit with code 5 when a deprecated interface still has implementations or usages (or set fail_on_deprecated_usage)")
+	fs.IntVar(&c.maxUndoc, "max-undocumented", -1, "exit with code 5 when more exported methods than this are undocumented (overrides max_undocumented)")
+	fs.BoolVar(&c.debug, "debug", false, "log debug details, such as the pacing decisions of requests_per_minute and tokens_per_minute")
+}
+
+// Function to read the config files and apply the common flags to them
+func (c *commonFlags) loadConfig() *Config {
+	paths := c.configPaths
+	if len(paths) == 0 {
+		paths = []string{"config.yaml"}
+	}
+	config, err := loadConfigFile(paths, c.profile)
+	if err != nil {
+		fatalf(exitUsage, "Error reading config file: %v", err)
+	}
+	config.Progress = c.progress
+	config.Since = c.since
+	config.ShowTestDoubles = c.testDoubles
+	config.Debug = c.debug
+	config.Strict = c.strict
+	config.WritePackageDocs = c.writePkgDocs
+	if c.failOnDepr {
+		config.FailOnDeprecatedUsage = true
+	}
+	if c.maxUndoc >= 0 {
+		config.MaxUndocumented = &c.maxUndoc
+	}
+	if len(c.packages) > 0 {
+		config.Packages = c.packages
+	}
+	if len(c.exclude) > 0 {
+		config.ExcludeInterfaces = append(config.ExcludeInterfaces, c.exclude...)
+		if _, err := newInterfaceFilter(config); err != nil {
+			fatalf(exitUsage, "Error in flags: %v", err)
+		}
+	}
+	if c.interactive {
+		if err := requireTerminal(os.Stdin); err != nil {
+			fatalf(exitUsage, "Error: %v", err)
+		}
+		config.Interactive = true
+		if c.saveSelection {
+			// The last file is the most specific, e.g. a developer's
+			// overrides of a shared base
+			config.SaveSelection = paths[len(paths)-1]
+		}
+	}
+	if c.metricsAddr != "" {
+		if config.Metrics, err = startMetricsServer(c.metricsAddr, config.MetricsNamespace); err != nil {
+			fatalf(exitUsage, "Error starting metrics server: %v", err)
+		}
+	}
+	if c.generateStubs != "" {
+		config.GenerateStubs = c.generateStubs
+	}
+	return config
+}
+
+// Function to run discovery, filtering and matching for a config, exiting
+// on errors. When ctx is cancelled during the scan, the results gathered so
+// far are returned.
+func analyze(ctx context.Context, config *Config) *Report {
+	report, err := analyzeReport(ctx, config)
+	if err != nil {
+		fatalf(exitCode(err), "Error: %v", err)
+	}
+	return report
+}
+
+// Function to open the output file, or stdout when no path is given
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(path)
+}
+
+type nopCloser struct{ io.Writer }
+
+func (nopCloser) Close() error { return nil }
+
+func runAnalyze(args []string) {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	output := fs.String("o", "", "write the JSON to this file instead of stdout")
+	format := fs.String("format", "", "output format: json, sarif, dot, package-dot, github or html (overrides the config)")
+	docs := fs.String("docs", "", "documentation written by send whose doc comments become SARIF fixes")
+	stdin := fs.Bool("stdin", false, "analyze one Go file read from stdin and print JSON; no config file is needed")
+	stdinFilename := fs.String("stdin-filename", "stdin.go", "file name reported in positions with -stdin")
+	dir := fs.String("dir", "", "with -stdin, match implementations in this directory")
+	list := fs.Bool("list", false, "print the interfaces and implementations as a table instead")
+	reportUndocumented := fs.Bool("report-undocumented", false, "print path:line of each exported method without a doc comment to stderr")
+	version := fs.Int("schema-version", schemaVersion, fmt.Sprintf("schema version of the JSON, %d for the legacy bare array of interfaces", legacySchemaVersion))
+	parseFlags(fs, args)
+	if err := checkSchemaVersion(*version); err != nil {
+		fatalf(exitUsage, "Error in flags: %v", err)
+	}
+
+	if *stdin {
+		ctx, stop := interruptContext()
+		err := analyzeStdin(ctx, os.Stdin, os.Stdout, *stdinFilename, *dir, *version)
+		exitIfInterrupted(ctx)
+		stop()
+		if err != nil {
+			// Editors read stderr, so the message has no log timestamp
+			fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		}
+		os.Exit(exitCode(err))
+	}
+
+	config := common.loadConfig()
+	if *format != "" {
+		config.Format = *format
+		if err := config.validate(); err != nil {
+			fatalf(exitUsage, "Error in flags: %v", err)
+		}
+	}
+	if config.PackageSummaries {
+		requireAPIKey(config)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	defer exitIfInterrupted(ctx)
+	report := analyze(ctx, config)
+	defer exitForRun(ctx, report, config)
+	if err := summarizePackages(ctx, config, report); err != nil {
+		fatalf(exitCode(err), "Error: %v", err)
+	}
+
+	out, err := openOutput(*output)
+	if err != nil {
+		fatalf(exitOutput, "Error creating output file: %v", err)
+	}
+	defer out.Close()
+
+	if *reportUndocumented {
+		if err := writeUndocumented(os.Stderr, report.Undocumented); err != nil {
+			fatalf(exitOutput, "Error writing undocumented methods: %v", err)
+		}
+	}
+
+	switch {
+	case *list:
+		if err := writeList(out, report.Interfaces); err != nil {
+			fatalf(exitOutput, "Error writing list: %v", err)
+		}
+		printSummary(os.Stderr, report.Summary)
+		return
+	case config.Format == FormatSarif:
+		if err := writeSarif(out, report, config, *docs); err != nil {
+			fatalf(exitOutput, "Error writing SARIF: %v", err)
+		}
+		printSummary(os.Stderr, report.Summary)
+		return
+	case config.Format == FormatDot:
+		if err := writeDot(out, report.Interfaces); err != nil {
+			fatalf(exitOutput, "Error writing DOT: %v", err)
+		}
+		printSummary(os.Stderr, report.Summary)
+		return
+	case config.Format == FormatPackageDot:
+		if err := writePackageDot(out, report.Graph); err != nil {
+			fatalf(exitOutput, "Error writing DOT: %v", err)
+		}
+		printSummary(os.Stderr, report.Summary)
+		return
+	case config.Format == FormatHTML:
+		if err := writeHTML(out, report, config); err != nil {
+			fatalf(exitOutput, "Error writing HTML: %v", err)
+		}
+		printSummary(os.Stderr, report.Summary)
+		return
+	case config.Format == FormatGitHub:
+		if err := writeGitHubAnnotations(out, report.Undocumented); err != nil {
+			fatalf(exitOutput, "Error writing annotations: %v", err)
+		}
+		printSummary(os.Stderr, report.Summary)
+		return
+	}
+
+	if err := writeReportJSON(out, report, *version); err != nil {
+		fatalf(exitOutput, "Error writing JSON: %v", err)
+	}
+
+	printSummary(os.Stderr, report.Summary)
+}
+
+func runRender(args []string) {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	output := fs.String("o", "", "write the markdown to this file instead of stdout")
+	outDir := fs.String("out-dir", "", "write one markdown file per interface plus index.md to this directory (output_layout: split)")
+	watchFiles := fs.Bool("watch", false, "render again whenever the scanned .go files change, until interrupted")
+	parseFlags(fs, args)
+
+	config := common.loadConfig()
+	if config.PackageSummaries {
+		requireAPIKey(config)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+
+	if *watchFiles {
+		watch(ctx, config, watchInterval, watchSettle, func() {
+			report, err := analyzeReport(ctx, config)
+			if err != nil {
+				log.Printf("Error: %v", err)
+				return
+			}
+			if err := summarizePackages(ctx, config, report); err != nil {
+				log.Printf("Error: %v", err)
+				return
+			}
+			if err := writeMarkdown(report, config, *output, *outDir); err != nil {
+				log.Printf("Error: %v", err)
+				return
+			}
+			printSummary(os.Stderr, report.Summary)
+		})
+		return
+	}
+
+	defer exitIfInterrupted(ctx)
+	report := analyze(ctx, config)
+	defer exitForRun(ctx, report, config)
+	if err := summarizePackages(ctx, config, report); err != nil {
+		fatalf(exitCode(err), "Error: %v", err)
+	}
+
+	if err := writeMarkdown(report, config, *output, *outDir); err != nil {
+		fatalf(exitOutput, "Error: %v", err)
+	}
+	printSummary(os.Stderr, report.Summary)
+	notifyRun(config, "render", report, nil)
+}
+
+// Function to write the markdown of render: one file per interface under
+// outDir with the split layout, else a single document to output or stdout
+func writeMarkdown(report *Report, config *Config, output, outDir string) error {
+	if outDir != "" || config.OutputLayout == LayoutSplit {
+		dir := outDir
+		if dir == "" {
+			dir = config.OutputDir
+		}
+		if dir == "" {
+			dir = defaultOutputDir
+		}
+		if err := renderMarkdownFiles(dir, collapseTestDoubles(report.Interfaces, config), report.Values, report.Packages, report.Partial, config); err != nil {
+			return fmt.Errorf("writing markdown files: %w", err)
+		}
+		return nil
+	}
+
+	out, err := openOutput(output)
+	if err != nil {
+		return fmt.Errorf("creating output file: %w", err)
+	}
+	defer out.Close()
+	if _, err := io.WriteString(out, report.Markdown()); err != nil {
+		return fmt.Errorf("writing markdown: %w", err)
+	}
+	return nil
+}
+
+func runSend(args []string) {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	noStream := fs.Bool("no-stream", false, "wait for the whole reply instead of streaming it to stderr")
+	blockOnSecrets := fs.Bool("block-on-secrets", false, "abort the send if anything had to be redacted from the prompt")
+	output := fs.String("o", "", "write the generated documentation to this file instead of stdout")
+	list := fs.Bool("list", false, "only print the interfaces and implementations as a table, without calling the API")
+	checkAuth := fs.Bool("check-auth", false, "only check that the API key is accepted, with a cheap request listing the models")
+	parseFlags(fs, args)
+
+	config := common.loadConfig()
+	if *checkAuth {
+		os.Exit(runCheckAuth(config))
+	}
+	config.NoStream = *noStream
+	config.BlockOnSecrets = config.BlockOnSecrets || *blockOnSecrets
+
+	if *list {
+		ctx, stop := interruptContext()
+		defer stop()
+		defer exitIfInterrupted(ctx)
+		report := analyze(ctx, config)
+		if err := writeList(os.Stdout, report.Interfaces); err != nil {
+			fatalf(exitOutput, "Error writing list: %v", err)
+		}
+		printSummary(os.Stderr, report.Summary)
+		return
+	}
+
+	// Only LLM sinks need an API key
+	if config.usesLLM() {
+		requireAPIKey(config)
+	}
+
+	tracker := newUsageTracker(config)
+	sink, err := newSinks(config, tracker, *output)
+	if err != nil {
+		fatalf(exitUsage, "Error in config: %v", err)
+	}
+
+	// Send the results to the configured sinks
+	ctx, stop := interruptContext()
+	defer stop()
+	report, err := sendResults(ctx, config, sink)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send data: %v\n", err)
+	}
+	report.Partial = report.Partial || ctx.Err() != nil
+	notifyRun(config, "send", report, err)
+
+	printSummary(os.Stderr, report.Summary)
+	tracker.printSummary(os.Stderr)
+	if err := tracker.appendLog(); err != nil {
+		log.Printf("Error writing usage log: %v", err)
+	}
+	exitIfInterrupted(ctx)
+	if err != nil {
+		os.Exit(exitCode(err))
+	}
+	exitForRun(ctx, report, config)
+}
+
+// Function to write generated documentation to a file or stdout
+func writeContent(path, content string) error {
+	out, err := openOutput(path)
+	if err != nil {
+		return withExitCode(exitOutput, fmt.Errorf("creating output file: %w", err))
+	}
+	defer out.Close()
+
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if _, err := io.WriteString(out, content); err != nil {
+		return withExitCode(exitOutput, fmt.Errorf("writing output: %w", err))
+	}
+	return nil
+}
+
+func runOpenAPI(args []string) {
+	fs := flag.NewFlagSet("openapi", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	output := fs.String("o", "openapi.yaml", "write the OpenAPI document to this file; - for stdout")
+	docs := fs.String("docs", "", "documentation written by send to describe methods without a doc comment")
+	parseFlags(fs, args)
+
+	config := common.loadConfig()
+	ctx, stop := interruptContext()
+	defer stop()
+	defer exitIfInterrupted(ctx)
+	report := analyze(ctx, config)
+
+	out, err := openOutput(*output)
+	if err != nil {
+		fatalf(exitOutput, "Error creating output file: %v", err)
+	}
+	defer out.Close()
+
+	routes, err := writeOpenAPI(out, report.Interfaces, config, *docs)
+	if err != nil {
+		fatalf(exitOutput, "Error writing OpenAPI document: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d routes to %s\n", routes, *output)
+}
+
+func runPackageDocs(args []string) {
+	fs := flag.NewFlagSet("package-docs", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	llm := fs.Bool("llm", false, "add an overview of each package written by the LLM (needs an API key), as package_summaries does")
+	parseFlags(fs, args)
+
+	config := common.loadConfig()
+	if *llm {
+		config.PackageSummaries = true
+	}
+	if config.PackageSummaries {
+		requireAPIKey(config)
+	}
+	ctx, stop := interruptContext()
+	defer stop()
+	defer exitIfInterrupted(ctx)
+	report := analyze(ctx, config)
+	defer exitForRun(ctx, report, config)
+	// An interrupted run still writes the docs, without the overviews it
+	// didn't get
+	if err := summarizePackages(ctx, config, report); err != nil {
+		fatalf(exitCode(err), "Error: %v", err)
+	}
+
+	written, err := writePackageDocs(groupPackages(report), config.PackageDocs)
+	for _, path := range written {
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	}
+	if err != nil {
+		fatalf(exitOutput, "Error writing package docs: %v", err)
+	}
+	printSummary(os.Stderr, report.Summary)
+}
+
+func runDiff(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	var common commonFlags
+	common.register(fs)
+	baseline := fs.String("baseline", "", "baseline JSON to compare a fresh scan against")
+	output := fs.String("o", "", "write the markdown changelog to this file instead of stdout")
+	jsonOutput := fs.String("json", "", "also write the diff as JSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go_parser diff [flags] old.json new.json\n       go_parser diff [flags] -baseline old.json")
+		fs.PrintDefaults()
+	}
+	parseFlags(fs, args)
+
+	var old, current *Report
+	var err error
+	switch {
+	case *baseline != "" && fs.NArg() == 0:
+		if old, err = loadReport(*baseline); err != nil {
+			fatalf(exitUsage, "Error reading baseline: %v", err)
+		}
+		ctx, stop := interruptContext()
+		defer stop()
+		defer exitIfInterrupted(ctx)
+		current = analyze(ctx, common.loadConfig())
+	case *baseline == "" && fs.NArg() == 2:
+		if old, err = loadReport(fs.Arg(0)); err != nil {
+			fatalf(exitUsage, "Error reading report: %v", err)
+		}
+		if current, err = loadReport(fs.Arg(1)); err != nil {
+			fatalf(exitUsage, "Error reading report: %v", err)
+		}
+	default:
+		fs.Usage()
+		os.Exit(exitUsage)
+	}
+
+	diff := diffReports(old, current)
+
+	if *jsonOutput != "" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fatalf(exitOutput, "Error encoding diff: %v", err)
+		}
+		if err := os.WriteFile(*jsonOutput, append(data, '\n'), 0o644); err != nil {
+			fatalf(exitOutput, "Error writing diff JSON: %v", err)
+		}
+	}
+	if err := writeContent(*output, renderDiffMarkdown(diff)); err != nil {
+		fatalf(exitOutput, "Error writing changelog: %v", err)
+	}
+}
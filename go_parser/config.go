@@ -0,0 +1,666 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+type Config struct {
+	GoFilePath string `yaml:"go_file_path" json:"go_file_path" toml:"go_file_path"`
+	// More files or package directories declaring interfaces, see
+	// interfaceSources
+	InterfaceSources []string `yaml:"interface_sources" json:"interface_sources" toml:"interface_sources"`
+	GoDirectory      string   `yaml:"go_directory" json:"go_directory" toml:"go_directory"`
+	// More directories to scan along with go_directory, see scanRoots
+	GoDirectories []string `yaml:"go_directories" json:"go_directories" toml:"go_directories"`
+	APIKey        string   `yaml:"-" json:"-" toml:"-"` // This will hold the API key, see resolveAPIKey
+
+	// File holding the API key, e.g. a docker secret; OPENAI_API_KEY (or
+	// ANTHROPIC_API_KEY) and API_KEY take precedence over it
+	APIKeyFile string `yaml:"api_key_file" json:"api_key_file" toml:"api_key_file"`
+
+	// Package patterns such as "./..." or an import path to search for
+	// implementations instead of walking go_directory. They are resolved by
+	// the go tool in go_directory (or the working directory when unset).
+	Packages []string `yaml:"packages" json:"packages" toml:"packages"`
+
+	// When true, interfaces and types whose names are unexported are skipped
+	ExportedOnly bool `yaml:"exported_only" json:"exported_only" toml:"exported_only"`
+	// When true, unexported methods of types don't count toward implementing
+	// an interface. Go counts them, so this is a reporting convenience for
+	// public APIs rather than the compiler's matching.
+	IgnoreUnexportedMethods bool `yaml:"ignore_unexported_methods" json:"ignore_unexported_methods" toml:"ignore_unexported_methods"`
+
+	// When true, interface literals typing function parameters and struct
+	// fields are reported too, named by their location
+	AnonymousInterfaces bool `yaml:"anonymous_interfaces" json:"anonymous_interfaces" toml:"anonymous_interfaces"`
+
+	// Interface names or regular expressions to keep or drop; exclude wins
+	IncludeInterfaces []string `yaml:"include_interfaces" json:"include_interfaces" toml:"include_interfaces"`
+	ExcludeInterfaces []string `yaml:"exclude_interfaces" json:"exclude_interfaces" toml:"exclude_interfaces"`
+
+	// Drop interfaces without methods (interface{}, marker interfaces) from
+	// the output instead of listing them with no implementations
+	SkipEmptyInterfaces bool `yaml:"skip_empty_interfaces" json:"skip_empty_interfaces" toml:"skip_empty_interfaces"`
+
+	// Interfaces checked in addition to the built-in stdlib catalog
+	ExtraKnownInterfaces []KnownInterface `yaml:"extra_known_interfaces" json:"extra_known_interfaces" toml:"extra_known_interfaces"`
+
+	// Build tags used to evaluate //go:build constraints when selecting files
+	BuildTags []string `yaml:"build_tags" json:"build_tags" toml:"build_tags"`
+
+	// When true, _test.go files are scanned for implementations too
+	IncludeTests bool `yaml:"include_tests" json:"include_tests" toml:"include_tests"`
+
+	// Regular expressions matching the names of mocks, fakes and stubs;
+	// empty uses defaultTestDoublePatterns
+	TestDoublePatterns []string `yaml:"test_double_patterns" json:"test_double_patterns" toml:"test_double_patterns"`
+	// List test doubles in the markdown and the LLM prompt instead of only
+	// counting them; set by -show-test-doubles
+	ShowTestDoubles bool `yaml:"-" json:"-" toml:"-"`
+
+	// Paths skipped while walking, as gitignore patterns relative to the
+	// scan root; .godocignore files can re-include them with "!"
+	ExcludeDirs []string `yaml:"exclude_dirs" json:"exclude_dirs" toml:"exclude_dirs"`
+	// Walk symlinked directories too, each directory once whatever links
+	// lead to it; off by default
+	FollowSymlinks bool `yaml:"follow_symlinks" json:"follow_symlinks" toml:"follow_symlinks"`
+	// Go files larger than this are skipped while walking; 0 uses
+	// defaultMaxFileSizeBytes
+	MaxFileSizeBytes int64 `yaml:"max_file_size_bytes" json:"max_file_size_bytes" toml:"max_file_size_bytes"`
+	// Directories more than this many levels below a scan root aren't
+	// walked, 1 being its subdirectories; 0 walks every level
+	MaxDepth int `yaml:"max_depth" json:"max_depth" toml:"max_depth"`
+
+	// LLM provider ("openai", "anthropic", "ollama" or "mock", which answers
+	// offline with canned docs) and model; empty uses the defaults
+	Provider string `yaml:"provider" json:"provider" toml:"provider"`
+	Model    string `yaml:"model" json:"model" toml:"model"`
+
+	// Generation parameters; only sent when set. Lower temperatures give more
+	// deterministic documentation.
+	Temperature         *float64 `yaml:"temperature" json:"temperature" toml:"temperature"`
+	MaxCompletionTokens int      `yaml:"max_completion_tokens" json:"max_completion_tokens" toml:"max_completion_tokens"`
+	// Ask for the documentation as JSON following a schema, validated and
+	// rendered as markdown, rather than free text; providers without a JSON
+	// mode fall back to free text with a warning
+	StructuredOutput bool `yaml:"structured_output" json:"structured_output" toml:"structured_output"`
+	// Language of the documentation, e.g. "ja" or "es": the model is asked
+	// to write in it, through {{language}} in the messages if they have it,
+	// and the static text of the markdown comes from its catalog in
+	// locales/; defaults to en. JSON output keeps its English field names.
+	DocLanguage string `yaml:"doc_language" json:"doc_language" toml:"doc_language"`
+
+	// Base URL of the provider API, e.g. "https://api.openai.com/v1", for
+	// compatible gateways or a local test server
+	BaseURL string `yaml:"base_url" json:"base_url" toml:"base_url"`
+	// Retries for 429 and 5xx responses; defaults to 3 when unset
+	MaxRetries *int `yaml:"max_retries" json:"max_retries" toml:"max_retries"`
+	// Largest response body read from the provider, in bytes, and longest
+	// a request may take, reading the response included, in seconds;
+	// default to 8 MB and 10 minutes, see newHTTPAPI
+	MaxResponseBytes      int `yaml:"max_response_bytes" json:"max_response_bytes" toml:"max_response_bytes"`
+	RequestTimeoutSeconds int `yaml:"request_timeout_seconds" json:"request_timeout_seconds" toml:"request_timeout_seconds"`
+	// Client-side budgets requests queue for instead of hitting 429s; a
+	// request counts its estimated prompt tokens plus max_completion_tokens
+	// (the Anthropic default when unset there). Unset means no pacing.
+	RequestsPerMinute int `yaml:"requests_per_minute" json:"requests_per_minute" toml:"requests_per_minute"`
+	TokensPerMinute   int `yaml:"tokens_per_minute" json:"tokens_per_minute" toml:"tokens_per_minute"`
+
+	// Proxy for API calls; overrides HTTP_PROXY/HTTPS_PROXY when set
+	ProxyURL string `yaml:"proxy_url" json:"proxy_url" toml:"proxy_url"`
+
+	// Extra regular expressions masked in the prompt, on top of the built-in
+	// secret patterns; findings are written to redact_report when set
+	RedactPatterns []string `yaml:"redact_patterns" json:"redact_patterns" toml:"redact_patterns"`
+	RedactReport   string   `yaml:"redact_report" json:"redact_report" toml:"redact_report"`
+	// Directory a JSON transcript of every LLM request is written to, for
+	// auditing; see Transcript
+	TranscriptDir string `yaml:"transcript_dir" json:"transcript_dir" toml:"transcript_dir"`
+	// Abort the send entirely when anything had to be redacted
+	BlockOnSecrets bool `yaml:"block_on_secrets" json:"block_on_secrets" toml:"block_on_secrets"`
+
+	// Per-model prices in USD per million tokens, merged over the built-in
+	// table, and an optional JSONL file each run's token usage is appended to
+	Pricing  map[string]ModelPrice `yaml:"pricing" json:"pricing" toml:"pricing"`
+	UsageLog string                `yaml:"usage_log" json:"usage_log" toml:"usage_log"`
+
+	// How much source to send per interface: signatures, bodies or file.
+	// Source is cut to context_max_bytes per interface, and prompts are split
+	// into several requests of at most max_prompt_tokens (estimated).
+	ContextLevel    string `yaml:"context_level" json:"context_level" toml:"context_level"`
+	ContextMaxBytes int    `yaml:"context_max_bytes" json:"context_max_bytes" toml:"context_max_bytes"`
+	MaxPromptTokens int    `yaml:"max_prompt_tokens" json:"max_prompt_tokens" toml:"max_prompt_tokens"`
+	// Requests of a split prompt sent at once; defaults to 2 to stay clear of
+	// rate limits. Replies are joined in order whatever order they arrive in.
+	Concurrency int `yaml:"concurrency" json:"concurrency" toml:"concurrency"`
+
+	// Directory to write skeleton implementations of unimplemented interfaces to
+	GenerateStubs string `yaml:"generate_stubs" json:"generate_stubs" toml:"generate_stubs"`
+
+	// Output format of analyze: json (default), sarif, which reports
+	// undocumented exported symbols, dot, a Graphviz graph of which types
+	// implement which interfaces, or package-dot, one of which packages
+	// implement or consume the interfaces of which. sarif_severities sets
+	// the level (none, note, warning or error) per rule ID such as GODOC001.
+	Format          string            `yaml:"format" json:"format" toml:"format"`
+	SarifSeverities map[string]string `yaml:"sarif_severities" json:"sarif_severities" toml:"sarif_severities"`
+	// Undocumented exported methods a run may report before it fails with
+	// exit code 5, as a CI gate; unset means no limit
+	MaxUndocumented *int `yaml:"max_undocumented" json:"max_undocumented" toml:"max_undocumented"`
+	// Fail a run with exit code 5 when a deprecated interface still has
+	// implementations or usages, as a sign a migration isn't done
+	FailOnDeprecatedUsage bool `yaml:"fail_on_deprecated_usage" json:"fail_on_deprecated_usage" toml:"fail_on_deprecated_usage"`
+
+	// Prefix of the Prometheus metrics served with -metrics-addr; defaults to
+	// go_documentator
+	MetricsNamespace string `yaml:"metrics_namespace" json:"metrics_namespace" toml:"metrics_namespace"`
+
+	// Conversation sent with each request, for prompt engineering: role
+	// (system, user or assistant) and content pairs, where {{results}} is
+	// replaced with the analysis. Defaults to one user message holding the
+	// analysis. assistant_seed adds an assistant turn after them that the
+	// model continues from.
+	Messages      []PromptMessage `yaml:"messages" json:"messages" toml:"messages"`
+	AssistantSeed string          `yaml:"assistant_seed" json:"assistant_seed" toml:"assistant_seed"`
+
+	// Where send delivers the results: llm (the configured provider), openai,
+	// anthropic, file or nop. Defaults to llm; file writes to sink_file, as
+	// JSON when it ends in .json and markdown otherwise.
+	Sinks    []string `yaml:"sinks" json:"sinks" toml:"sinks"`
+	SinkFile string   `yaml:"sink_file" json:"sink_file" toml:"sink_file"`
+
+	// Markdown written by send is wrapped in doc_header and doc_footer, then
+	// piped through post_process_command (program and arguments, e.g.
+	// ["prettier", "--parser", "markdown"]) when set
+	DocHeader          string   `yaml:"doc_header" json:"doc_header" toml:"doc_header"`
+	DocFooter          string   `yaml:"doc_footer" json:"doc_footer" toml:"doc_footer"`
+	PostProcessCommand []string `yaml:"post_process_command" json:"post_process_command" toml:"post_process_command"`
+
+	// How render writes the markdown: single (one document, the default) or
+	// split, a file per interface under <output_dir>/interfaces/<package
+	// path>/ plus <output_dir>/index.md; output_dir defaults to docs
+	OutputLayout string `yaml:"output_layout" json:"output_layout" toml:"output_layout"`
+	OutputDir    string `yaml:"output_dir" json:"output_dir" toml:"output_dir"`
+	// YAML front-matter put at the top of every markdown file render writes,
+	// for static site generators: string values are text/template templates
+	// of the interface, e.g. title: "{{ .InterfaceName }}"
+	MarkdownFrontmatter map[string]interface{} `yaml:"markdown_frontmatter" json:"markdown_frontmatter" toml:"markdown_frontmatter"`
+
+	// Doc comment annotation mapping interface methods to HTTP routes for the
+	// openapi command; defaults to @route, as in "@route GET /users/{id}"
+	RouteAnnotation string `yaml:"route_annotation" json:"route_annotation" toml:"route_annotation"`
+
+	// Where the package-docs command writes, see PackageDocsConfig
+	PackageDocs PackageDocsConfig `yaml:"package_docs" json:"package_docs" toml:"package_docs"`
+	// Ask the LLM for a 2 to 4 sentence overview of each scanned package,
+	// from its package comment, exported symbols and interfaces, for
+	// analyze, render and package-docs; see summarizePackages
+	PackageSummaries bool `yaml:"package_summaries" json:"package_summaries" toml:"package_summaries"`
+
+	// Slack summary of each run, see NotificationConfig
+	Notifications NotificationConfig `yaml:"notifications" json:"notifications" toml:"notifications"`
+
+	Progress bool   `yaml:"-" json:"-" toml:"-"` // Set from the -progress flag
+	Since    string `yaml:"-" json:"-" toml:"-"` // Set from the -since flag
+
+	Interactive   bool   `yaml:"-" json:"-" toml:"-"` // Set from the -interactive flag
+	SaveSelection string `yaml:"-" json:"-" toml:"-"` // Config file to save the selection to, from -save-selection
+
+	Metrics  *metricsRecorder `yaml:"-" json:"-" toml:"-"` // Set up from the -metrics-addr flag
+	NoStream bool             `yaml:"-" json:"-" toml:"-"` // Set from the -no-stream flag
+	// JSON Schema the reply must follow, set by send with structured_output
+	ResponseSchema map[string]interface{} `yaml:"-" json:"-" toml:"-"`
+	Debug          bool                   `yaml:"-" json:"-" toml:"-"` // Set from the -debug flag
+	Strict         bool                   `yaml:"-" json:"-" toml:"-"` // Set from the -strict flag
+	// Set from the -write-package-docs flag
+	WritePackageDocs bool `yaml:"-" json:"-" toml:"-"`
+
+	// Scanned files skipped as they couldn't be read or parsed, see
+	// skipFile
+	skippedFiles []FileError
+	// Files and directories left out of the walk by max_file_size_bytes or
+	// max_depth, see skipLimited, and those already warned about
+	limitedFiles []FileError
+	limitWarned  map[string]bool
+}
+
+// Function to read the config file, choosing the format from its extension,
+// with the named profile, or the default one, merged over it; see
+// applyProfile
+func readConfig(path, profile string) (*Config, error) {
+	return readConfigs([]string{path}, profile)
+}
+
+// Function to read config files in order, each decoded over the config the
+// files before it made, as if it included them: sections such as
+// notifications merge field by field and maps such as pricing key by key,
+// while a list, a single value or a map entry set in a later file replaces
+// the earlier one whole. A later file's false or 0 wins too, as the keys it
+// sets are decoded rather than compared with zero values. Files of
+// different formats can be mixed. The profile is applied in every YAML file
+// that has profiles, and must be in at least one of them.
+func readConfigs(paths []string, profile string) (*Config, error) {
+	var config Config
+	applied := false
+	for _, path := range paths {
+		ok, err := decodeConfigFile(path, profile, &config)
+		var pathErr *fs.PathError
+		if err != nil && len(paths) > 1 && !errors.As(err, &pathErr) {
+			err = fmt.Errorf("%s: %w", path, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		applied = applied || ok
+	}
+	if profile != "" && !applied {
+		return nil, fmt.Errorf("unknown profile %q: the config has no profiles, which are only read from YAML configs", profile)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Function to decode one config file over config, choosing the format from
+// its extension. Returns whether a profile of the file was applied.
+func decodeConfigFile(path, profile string, config *Config) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	bytes, err := io.ReadAll(file)
+	if err != nil {
+		return false, err
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		if err := decodeYAMLConfig(path, bytes, config, nil); err != nil {
+			return false, err
+		}
+		return applyProfile(bytes, config, profile)
+	case ".json":
+		return false, json.Unmarshal(bytes, config)
+	case ".toml":
+		return false, toml.Unmarshal(bytes, config)
+	default:
+		return false, fmt.Errorf("unsupported config format %q (use .yaml, .yml, .json or .toml)", ext)
+	}
+}
+
+// includeDirective is the part of a YAML config naming other YAML files to
+// merge in, e.g. a shared exclude list or model settings. It is read on its
+// own, so it never ends up in Config.
+type includeDirective struct {
+	Include stringList `yaml:"include"`
+}
+
+// stringList is a YAML list of strings that may also be written as a
+// single string
+type stringList []string
+
+// Function to accept both include: shared.yaml and include: [a.yaml, b.yaml]
+func (l *stringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var one string
+	if err := unmarshal(&one); err == nil {
+		*l = stringList{one}
+		return nil
+	}
+	var many []string
+	if err := unmarshal(&many); err != nil {
+		return err
+	}
+	*l = many
+	return nil
+}
+
+// Function to decode a YAML config over config after the files it includes,
+// in order, so a key set in the including file takes precedence. Keys of
+// nested sections and maps are merged one by one, lists are replaced whole.
+// Included paths are relative to the including file, and may include files
+// of their own; chain holds the files being read, to catch cycles.
+func decodeYAMLConfig(path string, data []byte, config *Config, chain []string) error {
+	// Errors in an included file name it
+	wrap := func(err error) error {
+		if err != nil && len(chain) > 0 {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		return err
+	}
+	var directive includeDirective
+	if err := yaml.Unmarshal(data, &directive); err != nil {
+		return wrap(err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, include := range directive.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		switch ext := strings.ToLower(filepath.Ext(include)); ext {
+		case ".yaml", ".yml":
+		default:
+			return fmt.Errorf("%s: can't include %s: only .yaml and .yml files can be included", path, include)
+		}
+		includeAbs, err := filepath.Abs(include)
+		if err != nil {
+			return err
+		}
+		if includeAbs == abs || containsString(chain, includeAbs) {
+			return fmt.Errorf("%s: include cycle through %s", path, include)
+		}
+		included, err := os.ReadFile(include)
+		if err != nil {
+			// Not wrapped: a missing include is not a missing config file
+			return fmt.Errorf("%s: include: %v", path, err)
+		}
+		if err := decodeYAMLConfig(include, included, config, append(chain, abs)); err != nil {
+			return err
+		}
+	}
+	return wrap(yaml.Unmarshal(data, config))
+}
+
+// Profile used when none is asked for, if the config has it
+const defaultProfile = "default"
+
+// profilesDirective is the part of a YAML config holding named profiles,
+// each a config block for one project. Like includeDirective it is read on
+// its own, so it never ends up in Config.
+type profilesDirective struct {
+	Profiles map[string]yaml.MapSlice `yaml:"profiles"`
+}
+
+// Function to decode a profile of a YAML config over the config its
+// top-level keys and includes made, so those act as shared defaults. The
+// profile is deep-merged into the top-level keys: maps, including sections
+// such as notifications, are merged key by key at every depth, e.g. a
+// profile setting pricing.gpt-4o.prompt keeps the completion price and the
+// other models, while lists and scalars replace the shared value whole, so
+// a profile's exclude_dirs is the whole list. Without a name the default
+// profile is used, when there is one. Profiles are only read from the
+// config file itself, not from the files it includes, and can't include
+// files or have profiles of their own. Returns whether a profile was
+// applied; a name the file's profiles don't have is an error, and one in a
+// file without profiles is left to the caller.
+func applyProfile(data []byte, config *Config, name string) (bool, error) {
+	var directive profilesDirective
+	if err := yaml.Unmarshal(data, &directive); err != nil {
+		return false, err
+	}
+	var document, shared yaml.MapSlice
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return false, err
+	}
+	for _, item := range document {
+		if key, _ := item.Key.(string); key != "include" && key != "profiles" {
+			shared = append(shared, item)
+		}
+	}
+	selected := name
+	if selected == "" {
+		selected = defaultProfile
+	}
+	block, ok := directive.Profiles[selected]
+	switch {
+	case !ok && (name == "" || len(directive.Profiles) == 0):
+		return false, nil
+	case !ok:
+		return false, fmt.Errorf("unknown profile %q (use %s)", name, strings.Join(sortedKeys(directive.Profiles), ", "))
+	}
+	for _, item := range block {
+		if key, _ := item.Key.(string); key == "include" || key == "profiles" {
+			return false, fmt.Errorf("profile %s: %s can only be set at the top level", selected, key)
+		}
+	}
+	merged, err := yaml.Marshal(mergeYAML(shared, block))
+	if err != nil {
+		return false, err
+	}
+	if err := yaml.Unmarshal(merged, config); err != nil {
+		return false, fmt.Errorf("profile %s: %w", selected, err)
+	}
+	return true, nil
+}
+
+// Function to deep-merge the YAML mapping over into base: a key both have
+// as a mapping is merged recursively, any other key of over replaces
+// base's
+func mergeYAML(base, over yaml.MapSlice) yaml.MapSlice {
+	merged := append(yaml.MapSlice(nil), base...)
+	for _, item := range over {
+		replaced := false
+		for i := range merged {
+			if merged[i].Key != item.Key {
+				continue
+			}
+			baseMap, baseOK := merged[i].Value.(yaml.MapSlice)
+			overMap, overOK := item.Value.(yaml.MapSlice)
+			if baseOK && overOK {
+				merged[i].Value = mergeYAML(baseMap, overMap)
+			} else {
+				merged[i].Value = item.Value
+			}
+			replaced = true
+			break
+		}
+		if !replaced {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// Function to reject config values the tool can't act on
+func (c *Config) validate() error {
+	switch c.ContextLevel {
+	case "", ContextSignatures, ContextBodies, ContextFile:
+	default:
+		return configError("context_level", "%q (use signatures, bodies or file)", c.ContextLevel)
+	}
+	if c.Concurrency < 0 {
+		return configError("concurrency", "%d, it can't be negative", c.Concurrency)
+	}
+	if c.MaxFileSizeBytes < 0 {
+		return configError("max_file_size_bytes", "%d, it can't be negative", c.MaxFileSizeBytes)
+	}
+	if c.MaxDepth < 0 {
+		return configError("max_depth", "%d, it can't be negative", c.MaxDepth)
+	}
+	if c.MaxResponseBytes < 0 {
+		return configError("max_response_bytes", "%d, it can't be negative", c.MaxResponseBytes)
+	}
+	if c.RequestsPerMinute < 0 || c.TokensPerMinute < 0 {
+		return configError("requests_per_minute or tokens_per_minute", "they can't be negative")
+	}
+	if c.MaxUndocumented != nil && *c.MaxUndocumented < 0 {
+		return configError("max_undocumented", "%d, it can't be negative", *c.MaxUndocumented)
+	}
+	if c.RequestTimeoutSeconds < 0 {
+		return configError("request_timeout_seconds", "%d, it can't be negative", c.RequestTimeoutSeconds)
+	}
+	switch c.Format {
+	case "", FormatJSON, FormatSarif, FormatDot, FormatPackageDot, FormatGitHub, FormatHTML:
+	default:
+		return configError("format", "%q (use json, sarif, dot, package-dot, github or html)", c.Format)
+	}
+	switch c.OutputLayout {
+	case "", LayoutSingle, LayoutSplit:
+	default:
+		return configError("output_layout", "%q (use single or split)", c.OutputLayout)
+	}
+	if _, err := loadMessages(c.DocLanguage); err != nil {
+		return err
+	}
+	// Templates fail on unknown fields when executed, so try them once here
+	if _, err := renderFrontMatter(c.MarkdownFrontmatter, frontMatterData{}); err != nil {
+		return configError("markdown_frontmatter", "%v", err)
+	}
+	for _, sink := range c.Sinks {
+		switch sink {
+		case SinkLLM, SinkOpenAI, SinkAnthropic, SinkNop:
+		case SinkFile:
+			if c.SinkFile == "" {
+				return configError("sink_file", "the file sink needs it to be set")
+			}
+		default:
+			return configError("sinks", "%q (use llm, openai, anthropic, file or nop)", sink)
+		}
+	}
+	if err := validateMessages(c.Messages); err != nil {
+		return err
+	}
+	if strings.ContainsAny(c.RouteAnnotation, " \t\n") {
+		return configError("route_annotation", "%q, it can't contain spaces", c.RouteAnnotation)
+	}
+	if err := c.Notifications.validate(); err != nil {
+		return err
+	}
+	for _, pattern := range append([]string{c.GoDirectory}, c.GoDirectories...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return configError("go_directories", "pattern %q: %v", pattern, err)
+		}
+	}
+	for _, pattern := range append([]string{c.GoFilePath}, c.InterfaceSources...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return configError("interface_sources", "pattern %q: %v", pattern, err)
+		}
+	}
+	if _, err := c.testDoublePatterns(); err != nil {
+		return configError("test_double_patterns", "%v", err)
+	}
+	if _, err := newIgnoreMatcher(c.ExcludeDirs); err != nil {
+		return err
+	}
+	return validateSarifSeverities(c.SarifSeverities)
+}
+
+// Function to read the config files, see readConfigs, with environment
+// variables applied over them, see applyEnv. A single missing file is fine
+// as long as some config variable is set.
+func loadConfigFile(paths []string, profile string) (*Config, error) {
+	config, err := readConfigs(paths, profile)
+	if errors.Is(err, fs.ErrNotExist) && len(paths) == 1 && len(envConfigVars()) > 0 {
+		config, err = &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := config.applyEnv(); err != nil {
+		return nil, err
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Function to list the environment variables that set config fields: the
+// upper-cased yaml key of each field, e.g. GO_FILE_PATH for go_file_path
+func envConfigVars() []string {
+	var names []string
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		name := envName(configType.Field(i))
+		if _, ok := os.LookupEnv(name); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Function to get the environment variable of a config field, or "" for
+// fields that can't be set from the environment
+func envName(field reflect.StructField) string {
+	key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+	if key == "" || key == "-" {
+		return ""
+	}
+	return strings.ToUpper(key)
+}
+
+// Function to override config fields with the environment variables named
+// after them. Strings, numbers and booleans are parsed from the value and
+// lists are comma-separated; maps and structured lists can only be set in
+// the file.
+func (c *Config) applyEnv() error {
+	value := reflect.ValueOf(c).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name := envName(value.Type().Field(i))
+		env, ok := os.LookupEnv(name)
+		if name == "" || !ok {
+			continue
+		}
+		if err := setFromEnv(value.Field(i), env); err != nil {
+			return fmt.Errorf("environment variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Function to parse an environment value into a config field
+func setFromEnv(field reflect.Value, env string) error {
+	if field.Kind() == reflect.Ptr {
+		target := reflect.New(field.Type().Elem())
+		if err := setFromEnv(target.Elem(), env); err != nil {
+			return err
+		}
+		field.Set(target)
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(env)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(env)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("can only be set in the config file")
+		}
+		var items []string
+		for _, item := range strings.Split(env, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("can only be set in the config file")
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+func sampleConfig() Config {
+	temperature := 0.2
+	maxRetries := 5
+	maxUndocumented := 12
+	return Config{
+		GoFilePath:              "services/access/access.go",
+		GoDirectory:             "services",
+		GoDirectories:           []string{"cmd/*", "pkg"},
+		InterfaceSources:        []string{"pkg/ports"},
+		APIKeyFile:              "/run/secrets/api_key",
+		Packages:                []string{"./...", "example.com/app/internal/service"},
+		ExportedOnly:            true,
+		IgnoreUnexportedMethods: true,
+		AnonymousInterfaces:     true,
+		IncludeInterfaces:       []string{"Store", ".*Handler"},
+		ExcludeInterfaces:       []string{"Internal.*"},
+		SkipEmptyInterfaces:     true,
+		ExtraKnownInterfaces: []KnownInterface{
+			{Name: "driver.Valuer", Methods: []string{"Value() (driver.Value, error)"}},
+		},
+		BuildTags:             []string{"enterprise"},
+		IncludeTests:          true,
+		TestDoublePatterns:    []string{"^Mock", "Stub$"},
+		TranscriptDir:         "transcripts",
+		FollowSymlinks:        true,
+		MaxFileSizeBytes:      4 << 20,
+		MaxDepth:              6,
+		ExcludeDirs:           []string{"vendor/"},
+		OutputLayout:          LayoutSplit,
+		OutputDir:             "docs/api",
+		MarkdownFrontmatter:   map[string]interface{}{"title": "{{ .InterfaceName }}"},
+		RequestsPerMinute:     60,
+		TokensPerMinute:       10000,
+		MaxResponseBytes:      1 << 20,
+		RequestTimeoutSeconds: 120,
+		Provider:              "anthropic",
+		Model:                 "claude-3-5-sonnet-latest",
+		Temperature:           &temperature,
+		MaxCompletionTokens:   1024,
+		StructuredOutput:      true,
+		DocLanguage:           "ja",
+		BaseURL:               "http://localhost:8080/v1",
+		MaxRetries:            &maxRetries,
+		MaxUndocumented:       &maxUndocumented,
+		ProxyURL:              "http://proxy:3128",
+		RedactPatterns:        []string{`internal-[0-9]+`},
+		RedactReport:          "redact.json",
+		BlockOnSecrets:        true,
+		Pricing:               map[string]ModelPrice{"local-model": {Prompt: 0.5, Completion: 1.5}},
+		UsageLog:              "usage.jsonl",
+		ContextLevel:          ContextBodies,
+		ContextMaxBytes:       4096,
+		MaxPromptTokens:       3000,
+		Concurrency:           4,
+		GenerateStubs:         "stubs",
+		Format:                FormatSarif,
+		SarifSeverities:       map[string]string{RuleUndocumentedMethod: "warning"},
+		MetricsNamespace:      "docs",
+		Sinks:                 []string{SinkFile, SinkOpenAI},
+		SinkFile:              "docs.md",
+		DocHeader:             "<!-- generated -->",
+		DocFooter:             "_Generated by Go Documentator._",
+		PostProcessCommand:    []string{"prettier", "--parser", "markdown"},
+		RouteAnnotation:       "@http",
+		PackageDocs:           PackageDocsConfig{FileName: "README.md", MirrorDir: "docs"},
+		PackageSummaries:      true,
+		FailOnDeprecatedUsage: true,
+		Notifications: NotificationConfig{
+			SlackWebhookURL: "https://hooks.slack.com/services/T0/B0/x",
+			NotifyOn:        []string{NotifyChanges, NotifyFailures},
+			ArtifactURL:     "https://ci.example.com/artifacts/docs",
+			StateFile:       "docs-state.json",
+		},
+		Messages: []PromptMessage{
+			{Role: RoleSystem, Content: "You write Go documentation."},
+			{Role: RoleUser, Content: "Document these:\n{{results}}"},
+		},
+		AssistantSeed: "# Interfaces",
+	}
+}
+
+func TestReadConfigRoundTrip(t *testing.T) {
+	want := sampleConfig()
+
+	encoders := map[string]func(*Config) ([]byte, error){
+		"config.yaml": func(c *Config) ([]byte, error) { return yaml.Marshal(c) },
+		"config.yml":  func(c *Config) ([]byte, error) { return yaml.Marshal(c) },
+		"config.json": func(c *Config) ([]byte, error) { return json.Marshal(c) },
+		"config.toml": func(c *Config) ([]byte, error) {
+			var buf bytes.Buffer
+			err := toml.NewEncoder(&buf).Encode(c)
+			return buf.Bytes(), err
+		},
+	}
+
+	for name, encode := range encoders {
+		t.Run(name, func(t *testing.T) {
+			data, err := encode(&want)
+			if err != nil {
+				t.Fatalf("encoding: %v", err)
+			}
+			path := filepath.Join(t.TempDir(), name)
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readConfig(path, "")
+			if err != nil {
+				t.Fatalf("readConfig: %v", err)
+			}
+			if !reflect.DeepEqual(*got, want) {
+				t.Errorf("decoded config differs\ngot:  %+v\nwant: %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestReadConfigUnsupportedExtension(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(path, []byte("go_file_path = x.go\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := readConfig(path, "")
+	if err == nil || !strings.Contains(err.Error(), `unsupported config format ".ini"`) {
+		t.Fatalf("readConfig error = %v, want unsupported config format", err)
+	}
+}
+
+func TestReadConfigInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shared", "base.yaml"), "include: models.yaml\nexclude_dirs: [vendor/, testdata/]\nmodel: gpt-4o\n"+
+		"notifications:\n  state_file: shared.json\n  notify_on: [failures]\n")
+	writeFile(t, filepath.Join(dir, "shared", "models.yaml"), "model: gpt-3.5-turbo\ntemperature: 0.2\npricing:\n  local: {prompt: 1}\n")
+	path := filepath.Join(dir, "service", "config.yaml")
+	writeFile(t, path, "include: [../shared/base.yaml]\ngo_file_path: api.go\nexclude_dirs: [gen/]\n"+
+		"notifications:\n  state_file: service.json\npricing:\n  other: {prompt: 2}\n")
+
+	config, err := readConfig(path, "")
+	if err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	// The including file wins key by key; lists are replaced, not appended
+	if config.GoFilePath != "api.go" || config.Model != "gpt-4o" || config.Temperature == nil || *config.Temperature != 0.2 {
+		t.Errorf("go_file_path = %q, model = %q, temperature = %v", config.GoFilePath, config.Model, config.Temperature)
+	}
+	if !reflect.DeepEqual(config.ExcludeDirs, []string{"gen/"}) {
+		t.Errorf("exclude_dirs = %q, want the including file's", config.ExcludeDirs)
+	}
+	if config.Notifications.StateFile != "service.json" || !reflect.DeepEqual(config.Notifications.NotifyOn, []string{"failures"}) {
+		t.Errorf("notifications = %+v, want the sections merged", config.Notifications)
+	}
+	if len(config.Pricing) != 2 {
+		t.Errorf("pricing = %v, want both models", config.Pricing)
+	}
+}
+
+func TestReadConfigIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.yaml"), "include: b.yaml\n")
+	writeFile(t, filepath.Join(dir, "b.yaml"), "include: a.yaml\n")
+	writeFile(t, filepath.Join(dir, "bad.yaml"), "model: [\n")
+	writeFile(t, filepath.Join(dir, "shared.json"), "{}")
+
+	tests := map[string]string{
+		"include: a.yaml\n":       "include cycle through",
+		"include: missing.yaml\n": "include: open",
+		"include: bad.yaml\n":     "bad.yaml: yaml:",
+		"include: shared.json\n":  "only .yaml and .yml files can be included",
+	}
+	for content, want := range tests {
+		path := filepath.Join(dir, "config.yaml")
+		writeFile(t, path, content)
+		_, err := readConfig(path, "")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: error = %v, want %q", content, err, want)
+		}
+		if errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%q: a missing include must not look like a missing config file", content)
+		}
+	}
+}
+
+func TestReadConfigProfiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shared.yaml"), "concurrency: 4\npricing:\n  local: {prompt: 1}\n")
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, `include: shared.yaml
+model: gpt-4o
+exclude_dirs: [vendor/]
+pricing:
+  gpt-4o: {prompt: 2.5, completion: 10}
+notifications:
+  state_file: shared.json
+  notify_on: [failures]
+profiles:
+  default:
+    go_directory: .
+  serviceA:
+    go_directory: ../serviceA
+    exclude_dirs: [gen/]
+    pricing:
+      gpt-4o: {prompt: 2}
+    notifications:
+      state_file: serviceA.json
+`)
+
+	// The selected profile is merged over the shared top-level keys: lists
+	// are replaced, maps merged at every depth
+	config, err := readConfig(path, "serviceA")
+	if err != nil {
+		t.Fatalf("readConfig: %v", err)
+	}
+	if config.GoDirectory != "../serviceA" || config.Model != "gpt-4o" || config.Concurrency != 4 {
+		t.Errorf("go_directory = %q, model = %q, concurrency = %d", config.GoDirectory, config.Model, config.Concurrency)
+	}
+	if !reflect.DeepEqual(config.ExcludeDirs, []string{"gen/"}) {
+		t.Errorf("exclude_dirs = %q, want the profile's", config.ExcludeDirs)
+	}
+	wantPricing := map[string]ModelPrice{"local": {Prompt: 1}, "gpt-4o": {Prompt: 2, Completion: 10}}
+	if !reflect.DeepEqual(config.Pricing, wantPricing) {
+		t.Errorf("pricing = %v, want %v", config.Pricing, wantPricing)
+	}
+	if config.Notifications.StateFile != "serviceA.json" || !reflect.DeepEqual(config.Notifications.NotifyOn, []string{"failures"}) {
+		t.Errorf("notifications = %+v, want the sections merged", config.Notifications)
+	}
+
+	// Without a profile the default one is used
+	if config, err := readConfig(path, ""); err != nil || config.GoDirectory != "." || !reflect.DeepEqual(config.ExcludeDirs, []string{"vendor/"}) {
+		t.Errorf("default profile: config = %+v, err = %v", config, err)
+	}
+
+	if _, err := readConfig(path, "serviceB"); err == nil || !strings.Contains(err.Error(), `unknown profile "serviceB" (use default, serviceA)`) {
+		t.Errorf("err = %v", err)
+	}
+	writeFile(t, path, "model: gpt-4o\nprofiles:\n  nested:\n    include: shared.yaml\n")
+	if _, err := readConfig(path, "nested"); err == nil || !strings.Contains(err.Error(), "profile nested: include can only be set at the top level") {
+		t.Errorf("err = %v", err)
+	}
+	jsonPath := filepath.Join(dir, "config.json")
+	writeFile(t, jsonPath, "{}")
+	if _, err := readConfig(jsonPath, "serviceA"); err == nil || !strings.Contains(err.Error(), "only read from YAML configs") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestReadConfigs(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yaml")
+	writeFile(t, base, `go_file_path: api.go
+model: gpt-4o
+exported_only: true
+exclude_dirs: [vendor/, testdata/]
+pricing:
+  local: {prompt: 1}
+  gpt-4o: {prompt: 2.5, completion: 10}
+notifications:
+  state_file: base.json
+  notify_on: [failures]
+profiles:
+  serviceA:
+    go_directory: serviceA
+`)
+	team := filepath.Join(dir, "team.json")
+	writeFile(t, team, `{"exported_only": false, "exclude_dirs": ["gen/"], "pricing": {"gpt-4o": {"prompt": 2}}, "notifications": {"notify_on": ["changes"]}}`)
+	local := filepath.Join(dir, "local.toml")
+	writeFile(t, local, "model = \"gpt-4o-mini\"\n")
+
+	config, err := readConfigs([]string{base, team, local}, "serviceA")
+	if err != nil {
+		t.Fatalf("readConfigs: %v", err)
+	}
+	// Later files win key by key, even with false; lists and map entries
+	// are replaced whole, sections and maps merged
+	if config.GoFilePath != "api.go" || config.GoDirectory != "serviceA" || config.Model != "gpt-4o-mini" || config.ExportedOnly {
+		t.Errorf("go_file_path = %q, go_directory = %q, model = %q, exported_only = %v", config.GoFilePath, config.GoDirectory, config.Model, config.ExportedOnly)
+	}
+	if !reflect.DeepEqual(config.ExcludeDirs, []string{"gen/"}) {
+		t.Errorf("exclude_dirs = %q, want the later file's", config.ExcludeDirs)
+	}
+	wantPricing := map[string]ModelPrice{"local": {Prompt: 1}, "gpt-4o": {Prompt: 2}}
+	if !reflect.DeepEqual(config.Pricing, wantPricing) {
+		t.Errorf("pricing = %v, want %v", config.Pricing, wantPricing)
+	}
+	if config.Notifications.StateFile != "base.json" || !reflect.DeepEqual(config.Notifications.NotifyOn, []string{"changes"}) {
+		t.Errorf("notifications = %+v, want the sections merged", config.Notifications)
+	}
+
+	// Errors name the file when there are several
+	bad := filepath.Join(dir, "bad.yaml")
+	writeFile(t, bad, "model: [\n")
+	if _, err := readConfigs([]string{base, bad}, ""); err == nil || !strings.HasPrefix(err.Error(), bad+": ") {
+		t.Errorf("err = %v, want it to name %s", err, bad)
+	}
+	if _, err := readConfigs([]string{team, local}, "serviceA"); err == nil || !strings.Contains(err.Error(), `unknown profile "serviceA"`) {
+		t.Errorf("err = %v, want the profile missing from every file", err)
+	}
+}
+
+func TestLoadConfigFileFromEnvironment(t *testing.T) {
+	t.Setenv("GO_FILE_PATH", "api/api.go")
+	t.Setenv("GO_DIRECTORY", "services")
+	t.Setenv("MODEL", "gpt-4o")
+	t.Setenv("TEMPERATURE", "0.5")
+	t.Setenv("EXPORTED_ONLY", "true")
+	t.Setenv("INCLUDE_INTERFACES", "Store, .*Handler")
+
+	// No config file: the environment alone is enough
+	config, err := loadConfigFile([]string{filepath.Join(t.TempDir(), "config.yaml")}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GoFilePath != "api/api.go" || config.GoDirectory != "services" || config.Model != "gpt-4o" || !config.ExportedOnly {
+		t.Errorf("config = %+v", config)
+	}
+	if config.Temperature == nil || *config.Temperature != 0.5 {
+		t.Errorf("temperature = %v, want 0.5", config.Temperature)
+	}
+	if !reflect.DeepEqual(config.IncludeInterfaces, []string{"Store", ".*Handler"}) {
+		t.Errorf("include_interfaces = %q", config.IncludeInterfaces)
+	}
+}
+
+func TestLoadConfigFileEnvironmentOverridesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("go_file_path: file.go\nmodel: from-file\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MODEL", "from-env")
+
+	config, err := loadConfigFile([]string{path}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GoFilePath != "file.go" || config.Model != "from-env" {
+		t.Errorf("go_file_path = %q, model = %q, want the file's path and the environment's model", config.GoFilePath, config.Model)
+	}
+
+	t.Setenv("MAX_RETRIES", "many")
+	if _, err := loadConfigFile([]string{path}, ""); err == nil || !strings.Contains(err.Error(), "MAX_RETRIES") {
+		t.Errorf("err = %v, want an error naming MAX_RETRIES", err)
+	}
+}
+
+func TestLoadConfigFileMissingWithoutEnvironment(t *testing.T) {
+	if _, err := loadConfigFile([]string{filepath.Join(t.TempDir(), "config.yaml")}, ""); err == nil {
+		t.Error("expected an error for a missing config file without environment config")
+	}
+}
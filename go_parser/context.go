@@ -0,0 +1,114 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+)
+
+// Values of context_level, controlling how much source goes into the prompt
+const (
+	ContextSignatures = "signatures" // method signatures only (default)
+	ContextBodies     = "bodies"     // method bodies of one representative implementation
+	ContextFile       = "file"       // the whole file declaring the interface
+)
+
+const (
+	defaultContextMaxBytes = 8 * 1024
+	defaultMaxPromptTokens = 6000
+	defaultConcurrency     = 2
+	truncatedMarker        = "\n…truncated"
+)
+
+// Function to get the per-interface source limit, applying the default
+func (c *Config) contextMaxBytes() int {
+	if c.ContextMaxBytes > 0 {
+		return c.ContextMaxBytes
+	}
+	return defaultContextMaxBytes
+}
+
+// Function to get the prompt token budget per request, applying the default
+func (c *Config) maxPromptTokens() int {
+	if c.MaxPromptTokens > 0 {
+		return c.MaxPromptTokens
+	}
+	return defaultMaxPromptTokens
+}
+
+// Function to get the number of requests sent at once, applying the default
+func (c *Config) concurrency() int {
+	if c.Concurrency > 0 {
+		return c.Concurrency
+	}
+	return defaultConcurrency
+}
+
+// Function to extract the source of the methods of a type that implement
+// the given interface methods
+func methodSources(fset *token.FileSet, src []byte, methods []typeMethod, ifaceMethods []MethodDetails) string {
+	byName := make(map[string]typeMethod)
+	for _, method := range methods {
+		byName[method.Name] = method
+	}
+
+	// Follow the interface's order so the bodies line up with its methods
+	var parts []string
+	for _, ifaceMethod := range methodsInOrder(ifaceMethods) {
+		method, ok := byName[ifaceMethod.Name]
+		if !ok || method.decl == nil {
+			continue
+		}
+		start := fset.Position(method.decl.Pos()).Offset
+		end := fset.Position(method.decl.End()).Offset
+		if start >= 0 && end <= len(src) && start < end {
+			parts = append(parts, string(src[start:end]))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Function to cut source down to limit bytes, marking where it was cut
+func truncateContext(source string, limit int) string {
+	if len(source) <= limit {
+		return source
+	}
+	cut := limit
+	// Don't split a multi-byte character
+	for cut > 0 && !isRuneStart(source[cut]) {
+		cut--
+	}
+	return source[:cut] + truncatedMarker
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// Function to estimate the token count of a prompt; roughly four bytes per
+// token for English text and code
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Function to split the results into groups whose prompts stay within
+// maxTokens. An interface too large on its own still gets its own group.
+func chunkResults(results []InterfaceDetails, maxTokens int) [][]InterfaceDetails {
+	if maxTokens <= 0 {
+		return [][]InterfaceDetails{results}
+	}
+
+	var chunks [][]InterfaceDetails
+	var current []InterfaceDetails
+	for _, result := range results {
+		candidate := append(current[:len(current):len(current)], result)
+		if len(current) > 0 && estimateTokens(formatResultsForMessage(candidate)) > maxTokens {
+			chunks = append(chunks, current)
+			candidate = []InterfaceDetails{result}
+		}
+		current = candidate
+	}
+	if len(current) > 0 || len(chunks) == 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatResultsSendsFileContextOnce(t *testing.T) {
+	source := "package svc\n\ntype Store interface{ Get() }\n\ntype Cache interface{ Put() }\n"
+	results := []InterfaceDetails{
+		{InterfaceName: "Cache", sourceContext: source, sourceFile: "svc/svc.go"},
+		{InterfaceName: "Store", sourceContext: source, sourceFile: "svc/svc.go"},
+		{InterfaceName: "Closer", sourceContext: "func (m *MemStore) Close() error { return nil }"},
+	}
+
+	message := formatResultsForMessage(results)
+	if n := strings.Count(message, source); n != 1 {
+		t.Errorf("file source appears %d times, want once:\n%s", n, message)
+	}
+	if !strings.Contains(message, "Source of svc.go:") {
+		t.Errorf("file source is not labelled with its file name:\n%s", message)
+	}
+	if !strings.Contains(message, "Source:\n```go\nfunc (m *MemStore) Close()") {
+		t.Errorf("method bodies are no longer attached per interface:\n%s", message)
+	}
+}
+
+func TestChunkResultsSplitsOversizedPrompts(t *testing.T) {
+	var results []InterfaceDetails
+	for _, name := range []string{"A", "B", "C", "D"} {
+		results = append(results, InterfaceDetails{InterfaceName: name, sourceContext: strings.Repeat("x", 400)})
+	}
+
+	chunks := chunkResults(results, 250)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want the results split", len(chunks))
+	}
+	total := 0
+	for _, chunk := range chunks {
+		total += len(chunk)
+	}
+	if total != len(results) {
+		t.Errorf("chunks hold %d interfaces, want %d", total, len(results))
+	}
+}
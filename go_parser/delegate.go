@@ -0,0 +1,128 @@
+package main
+
+import (
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Function to add the methods a struct gets through the interfaces embedded
+// in it, as with Store in type LoggingStore struct{ Store }, when resolve
+// finds them among the analyzed interfaces. A method the struct declares
+// itself wins and is marked as overriding the field's. A method two fields
+// provide and the struct doesn't declare is ambiguous, so neither provides
+// it, as in Go. Interfaces reached through embedded structs are not
+// followed.
+//
+// A struct declared in the same file as the struct also lends its methods,
+// as Base does in struct{ Base } and struct{ *Base }. Through *Base all of
+// them belong to the value, while through Base its pointer receiver methods
+// stay with the pointer, as in Go's method sets. Only the methods Base
+// declares itself are promoted.
+func withDelegatedMethods(fset *token.FileSet, file *ast.File, structType *ast.StructType, methods []typeMethod, resolve func(ast.Expr) *InterfaceDetails, config *Config) []typeMethod {
+	declared := make(map[string]int)
+	for i, method := range methods {
+		declared[method.Name] = i
+	}
+
+	var delegated []typeMethod
+	providers := make(map[string]int)
+	for _, field := range structType.Fields.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		iface := resolve(field.Type)
+		if iface == nil {
+			for _, method := range promotedMethods(fset, file, field.Type, config) {
+				if _, ok := declared[method.Name]; !ok {
+					providers[method.Name]++
+					delegated = append(delegated, method)
+				}
+			}
+			continue
+		}
+		name, _ := receiverTypeName(field.Type)
+		if selector, ok := field.Type.(*ast.SelectorExpr); ok {
+			name = selector.Sel.Name
+		}
+		for _, method := range iface.Methods {
+			if i, ok := declared[method.Name]; ok {
+				if methods[i].overrides == "" {
+					methods[i].overrides = name
+				}
+				continue
+			}
+			providers[method.Name]++
+			details := method
+			details.Doc = ""
+			details.embeddedFrom = ""
+			details.pos = fset.Position(field.Pos())
+			delegated = append(delegated, typeMethod{MethodDetails: details, delegatedTo: name})
+		}
+	}
+
+	for _, method := range delegated {
+		if providers[method.Name] == 1 {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// Function to get the methods a struct declared in file lends through an
+// embedded field of type Base or *Base. Methods of types declared elsewhere
+// are unknown here, so none are returned for them.
+func promotedMethods(fset *token.FileSet, file *ast.File, expr ast.Expr, config *Config) []typeMethod {
+	name, pointer := receiverTypeName(expr)
+	if name == "" || !localStruct(file, name) {
+		return nil
+	}
+	methods := getMethodsForType(fset, file, name, config)
+	for i := range methods {
+		methods[i].PointerReceiver = methods[i].PointerReceiver && !pointer
+	}
+	return methods
+}
+
+// Function to tell whether file declares a struct type called name
+func localStruct(file *ast.File, name string) bool {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == name {
+				_, isStruct := typeSpec.Type.(*ast.StructType)
+				return isStruct
+			}
+		}
+	}
+	return false
+}
+
+// Function to list the methods of an implementation delegated to embedded
+// fields, e.g. "Get, Put"
+func delegatedMethodNames(methods []ImplementedMethod) string {
+	var names []string
+	for _, method := range methods {
+		if method.DelegatedTo != "" {
+			names = append(names, method.Name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Function to list the embedded fields the methods of an implementation
+// are delegated to, in interface order without repeats
+func delegateFields(methods []ImplementedMethod) []string {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, method := range methods {
+		if method.DelegatedTo != "" && !seen[method.DelegatedTo] {
+			seen[method.DelegatedTo] = true
+			fields = append(fields, method.DelegatedTo)
+		}
+	}
+	return fields
+}
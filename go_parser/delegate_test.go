@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDelegatedImplementations(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	ifacePath := filepath.Join(root, "api", "store.go")
+	writeFile(t, ifacePath, `package api
+
+type Store interface {
+	Get(id string) (string, error)
+	Put(id, value string) error
+}
+
+type Getter interface {
+	Get(id string) (string, error)
+}
+
+// LoggingStore delegates every method to the embedded Store.
+type LoggingStore struct {
+	Store
+}
+
+// CachingStore overrides Get.
+type CachingStore struct {
+	Store
+}
+
+// Get reads through the cache.
+func (c *CachingStore) Get(id string) (string, error) { return "", nil }
+
+// Ambiguous gets Get from both fields, so it has no Get at all
+type Ambiguous struct {
+	Store
+	Getter
+}
+`)
+	writeFile(t, filepath.Join(root, "wrap", "wrap.go"), `package wrap
+
+import "example.com/app/api"
+
+type Traced struct {
+	api.Store
+	name string
+}
+`)
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+
+	impls := make(map[string]Implementation)
+	for _, result := range results {
+		if result.InterfaceName == "Store" {
+			for _, impl := range result.Implementations {
+				impls[impl.TypeName] = impl
+			}
+		}
+	}
+	if _, ok := impls["Ambiguous"]; ok || len(impls) != 3 {
+		t.Fatalf("Store implementations = %v, want LoggingStore, CachingStore and Traced", reflect.ValueOf(impls).MapKeys())
+	}
+	for _, name := range []string{"LoggingStore", "Traced"} {
+		impl := impls[name]
+		if !impl.Delegated || !reflect.DeepEqual(impl.DelegateFields, []string{"Store"}) || impl.ReceiverSatisfaction != SatisfiedByBoth {
+			t.Errorf("%s = %+v, want both methods delegated to Store", name, impl)
+		}
+		for _, method := range impl.Methods {
+			if method.DelegatedTo != "Store" || method.Overrides != "" {
+				t.Errorf("%s.%s = %+v", name, method.Name, method)
+			}
+		}
+	}
+	caching := impls["CachingStore"]
+	if get, put := caching.Methods[0], caching.Methods[1]; get.Overrides != "Store" || get.DelegatedTo != "" || put.DelegatedTo != "Store" || caching.ReceiverSatisfaction != SatisfiedByPointer {
+		t.Errorf("CachingStore = %+v, want Get overridden and Put delegated", caching)
+	}
+
+	markdown := renderMarkdown(results, nil)
+	for _, want := range []string{"- `api.LoggingStore` (satisfied by both), delegates to `Store`", "via `Store`", "✔ overrides `Store`"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+	for _, finding := range undocumentedMethods(results) {
+		if strings.HasPrefix(finding.Symbol, "LoggingStore.") || strings.HasPrefix(finding.Symbol, "Traced.") {
+			t.Errorf("delegated method reported as undocumented: %s", finding)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Function to get the deprecation notice of a doc comment: the text of its
+// paragraph starting with "Deprecated:", as Go tools recognize it, joined
+// into one line; empty when there is none
+func deprecationNotice(doc string) string {
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if notice, ok := strings.CutPrefix(paragraph, "Deprecated:"); ok {
+			return strings.Join(strings.Fields(notice), " ")
+		}
+	}
+	return ""
+}
+
+// Function to set the deprecation notices of the interfaces, their methods
+// and their implementations from their doc comments
+func markDeprecated(results []InterfaceDetails) {
+	for i := range results {
+		result := &results[i]
+		result.Deprecated = deprecationNotice(result.Doc)
+		for j := range result.Methods {
+			result.Methods[j].Deprecated = deprecationNotice(result.Methods[j].Doc)
+		}
+		for j := range result.Implementations {
+			result.Implementations[j].Deprecated = deprecationNotice(result.Implementations[j].Doc)
+		}
+	}
+}
+
+// Function to list the deprecated interfaces that still have
+// implementations or usages, for -fail-on-deprecated-usage, e.g.
+// "api.Store (2 implementations, 1 usages)"
+func deprecatedInUse(results []InterfaceDetails) []string {
+	var inUse []string
+	for _, result := range results {
+		if result.Deprecated == "" || (len(result.Implementations) == 0 && len(result.Usages) == 0) {
+			continue
+		}
+		inUse = append(inUse, fmt.Sprintf("%s.%s (%d implementations, %d usages)", result.Package, result.InterfaceName, len(result.Implementations), len(result.Usages)))
+	}
+	return inUse
+}
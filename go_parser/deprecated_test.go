@@ -0,0 +1,115 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDeprecationNotice(t *testing.T) {
+	tests := []struct {
+		doc, want string
+	}{
+		{"", ""},
+		{"Store persists items.", ""},
+		{"Store persists items.\n\nDeprecated: use Repo instead,\nwhich batches writes.", "use Repo instead, which batches writes."},
+		{"Deprecated: use Repo.", "use Repo."},
+		{"Store persists items. Deprecated: only at the start of a paragraph.", ""},
+		{"Get gets.\n\nDeprecated: use Find.\n\nIt will be removed in v2.", "use Find."},
+	}
+	for _, test := range tests {
+		if got := deprecationNotice(test.doc); got != test.want {
+			t.Errorf("deprecationNotice(%q) = %q, want %q", test.doc, got, test.want)
+		}
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com\n\ngo 1.22\n")
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+// Store persists items.
+//
+// Deprecated: use Repo, which batches writes.
+type Store interface {
+	// Get looks an item up.
+	//
+	// Deprecated: use Find.
+	Get(id string) string
+	Find(id string) string
+}
+
+// Cache caches items.
+type Cache interface {
+	Find(id string) string
+}
+`)
+	writeFile(t, filepath.Join(root, "mem", "mem.go"), `package mem
+
+import "example.com/api"
+
+// MemStore keeps items in a map.
+//
+// Deprecated: use the sql package.
+type MemStore struct{}
+
+func (MemStore) Get(id string) string  { return id }
+func (MemStore) Find(id string) string { return id }
+
+// Deprecated: open a Repo.
+func Open(s api.Store) {}
+`)
+
+	config := Config{GoFilePath: ifacePath, GoDirectory: root}
+	report, err := Analyze(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var store, cache InterfaceDetails
+	for _, result := range report.Interfaces {
+		switch result.InterfaceName {
+		case "Store":
+			store = result
+		case "Cache":
+			cache = result
+		}
+	}
+	if store.Deprecated != "use Repo, which batches writes." || store.Methods[0].Deprecated != "use Find." || store.Methods[1].Deprecated != "" || cache.Deprecated != "" {
+		t.Errorf("store = %q, methods = %+v, cache = %q", store.Deprecated, store.Methods, cache.Deprecated)
+	}
+	if len(store.Implementations) != 1 || store.Implementations[0].Deprecated != "use the sql package." {
+		t.Errorf("implementations = %+v", store.Implementations)
+	}
+	if len(store.Usages) != 1 || store.Usages[0].Deprecated != "open a Repo." {
+		t.Errorf("usages = %+v", store.Usages)
+	}
+
+	markdown := renderMarkdown([]InterfaceDetails{store}, nil)
+	for _, want := range []string{
+		"## Store\n\nPackage `api`.\n\n> **Deprecated:** use Repo, which batches writes.\n",
+		"- `Get(id string) string` (**Deprecated:** use Find.)\n- `Find(id string) string`\n",
+		"- `mem.MemStore` (satisfied by both), **Deprecated:** use the sql package.\n",
+		"- param `Open` in package `mem`",
+		", **Deprecated:** open a Repo.\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+	message := formatResultsForMessage([]InterfaceDetails{store})
+	for _, want := range []string{"Deprecated: use Repo, which batches writes.\nNote: say it is deprecated", "Get(id string) string (deprecated: use Find.)", "deprecated: use the sql package."} {
+		if !strings.Contains(message, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, message)
+		}
+	}
+
+	if code, _ := runExitCode(report, &config); code != exitOK {
+		t.Errorf("exit code = %d without fail_on_deprecated_usage", code)
+	}
+	config.FailOnDeprecatedUsage = true
+	if code, reason := runExitCode(report, &config); code != exitPolicy || !strings.Contains(reason, "api.Store (1 implementations, 1 usages)") {
+		t.Errorf("exit code = %d (%s), want %d naming api.Store", code, reason, exitPolicy)
+	}
+}
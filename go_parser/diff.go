@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ReportDiff lists the interface changes between two analysis runs
+type ReportDiff struct {
+	AddedInterfaces   []string          `json:"added_interfaces"`
+	RemovedInterfaces []string          `json:"removed_interfaces"`
+	ChangedInterfaces []InterfaceChange `json:"changed_interfaces"`
+}
+
+// InterfaceChange lists what changed in an interface present in both runs
+type InterfaceChange struct {
+	InterfaceName         string         `json:"interface_name"`
+	AddedMethods          []string       `json:"added_methods,omitempty"`
+	RemovedMethods        []string       `json:"removed_methods,omitempty"`
+	ChangedMethods        []MethodChange `json:"changed_methods,omitempty"`
+	GainedImplementations []string       `json:"gained_implementations,omitempty"`
+	LostImplementations   []string       `json:"lost_implementations,omitempty"`
+}
+
+// MethodChange is a method whose parameter or result types changed; renamed
+// parameters alone are not a change. Renamed methods show up as a removed and
+// an added method instead.
+type MethodChange struct {
+	Name         string `json:"name"`
+	OldSignature string `json:"old_signature"`
+	NewSignature string `json:"new_signature"`
+}
+
+func (c InterfaceChange) empty() bool {
+	return len(c.AddedMethods) == 0 && len(c.RemovedMethods) == 0 && len(c.ChangedMethods) == 0 &&
+		len(c.GainedImplementations) == 0 && len(c.LostImplementations) == 0
+}
+
+// Function to load a report written by analyze. A bare array of interfaces
+// is accepted too.
+func loadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var report Report
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &report.Interfaces)
+	} else if err = json.Unmarshal(data, &report); err == nil && report.SchemaVersion < resultsSchemaVersion {
+		// Older reports have the results at the top level
+		err = json.Unmarshal(data, &report.ReportResults)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	if report.SchemaVersion > schemaVersion {
+		log.Printf("Warning: %s has schema version %d, newer than %d; fields this version doesn't know are ignored", path, report.SchemaVersion, schemaVersion)
+	}
+	return &report, nil
+}
+
+// Function to compare a baseline report with the current one
+func diffReports(old, new *Report) ReportDiff {
+	oldByName := interfacesByName(old.Interfaces)
+	newByName := interfacesByName(new.Interfaces)
+
+	diff := ReportDiff{
+		AddedInterfaces:   []string{},
+		RemovedInterfaces: []string{},
+		ChangedInterfaces: []InterfaceChange{},
+	}
+	for _, name := range sortedKeys(newByName) {
+		if _, ok := oldByName[name]; !ok {
+			diff.AddedInterfaces = append(diff.AddedInterfaces, name)
+		}
+	}
+	for _, name := range sortedKeys(oldByName) {
+		newIface, ok := newByName[name]
+		if !ok {
+			diff.RemovedInterfaces = append(diff.RemovedInterfaces, name)
+			continue
+		}
+		if change := diffInterface(oldByName[name], newIface); !change.empty() {
+			change.InterfaceName = name
+			diff.ChangedInterfaces = append(diff.ChangedInterfaces, change)
+		}
+	}
+	return diff
+}
+
+// Function to compare the methods and implementations of one interface
+func diffInterface(old, new InterfaceDetails) InterfaceChange {
+	change := InterfaceChange{InterfaceName: new.InterfaceName}
+
+	fset := token.NewFileSet()
+	oldMethods := make(map[string]MethodDetails)
+	for _, method := range old.Methods {
+		oldMethods[method.Name] = method
+	}
+	newMethods := make(map[string]bool)
+	for _, method := range new.Methods {
+		newMethods[method.Name] = true
+		oldMethod, ok := oldMethods[method.Name]
+		switch {
+		case !ok:
+			change.AddedMethods = append(change.AddedMethods, method.Name)
+		case signatureTypes(fset, oldMethod.Signature) != signatureTypes(fset, method.Signature):
+			change.ChangedMethods = append(change.ChangedMethods, MethodChange{
+				Name:         method.Name,
+				OldSignature: oldMethod.Signature,
+				NewSignature: method.Signature,
+			})
+		}
+	}
+	for _, method := range old.Methods {
+		if !newMethods[method.Name] {
+			change.RemovedMethods = append(change.RemovedMethods, method.Name)
+		}
+	}
+
+	oldImpls := implementationNames(old)
+	newImpls := implementationNames(new)
+	for _, name := range sortedKeys(newImpls) {
+		if !oldImpls[name] {
+			change.GainedImplementations = append(change.GainedImplementations, name)
+		}
+	}
+	for _, name := range sortedKeys(oldImpls) {
+		if !newImpls[name] {
+			change.LostImplementations = append(change.LostImplementations, name)
+		}
+	}
+	return change
+}
+
+// Function to reduce a method signature read from a report to its parameter
+// and result types, falling back to the signature itself if it won't parse
+func signatureTypes(fset *token.FileSet, signature string) string {
+	method, err := parseMethodSignature(fset, signature)
+	if err != nil {
+		return signature
+	}
+	return method.Types
+}
+
+// Function to index the interfaces of a report by name, qualified with the
+// package for a name declared in several packages
+func interfacesByName(interfaces []InterfaceDetails) map[string]InterfaceDetails {
+	count := make(map[string]int)
+	for _, iface := range interfaces {
+		count[iface.InterfaceName]++
+	}
+	byName := make(map[string]InterfaceDetails)
+	for _, iface := range interfaces {
+		name := iface.InterfaceName
+		if count[name] > 1 {
+			name = iface.Package + "." + name
+		}
+		byName[name] = iface
+	}
+	return byName
+}
+
+// Function to list the implementations of an interface, qualified with
+// their package so same-named types of different packages stay apart
+func implementationNames(iface InterfaceDetails) map[string]bool {
+	names := make(map[string]bool)
+	for _, impl := range iface.Implementations {
+		name := impl.TypeName
+		if impl.Package != "" {
+			name = impl.Package + "." + name
+		}
+		names[name] = true
+	}
+	return names
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Function to render the diff as a markdown changelog
+func renderDiffMarkdown(diff ReportDiff) string {
+	var b strings.Builder
+	b.WriteString("# Interface changes\n")
+
+	if len(diff.AddedInterfaces) == 0 && len(diff.RemovedInterfaces) == 0 && len(diff.ChangedInterfaces) == 0 {
+		b.WriteString("\nNo interface changes.\n")
+		return b.String()
+	}
+
+	writeList := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		for _, item := range items {
+			fmt.Fprintf(&b, "- `%s`\n", item)
+		}
+	}
+	writeList("Added interfaces", diff.AddedInterfaces)
+	writeList("Removed interfaces", diff.RemovedInterfaces)
+
+	if len(diff.ChangedInterfaces) > 0 {
+		b.WriteString("\n## Changed interfaces\n")
+	}
+	for _, change := range diff.ChangedInterfaces {
+		fmt.Fprintf(&b, "\n### %s\n\n", change.InterfaceName)
+		for _, name := range change.AddedMethods {
+			fmt.Fprintf(&b, "- Added method `%s`\n", name)
+		}
+		for _, name := range change.RemovedMethods {
+			fmt.Fprintf(&b, "- Removed method `%s`\n", name)
+		}
+		for _, method := range change.ChangedMethods {
+			fmt.Fprintf(&b, "- Changed method `%s` to `%s`\n", method.OldSignature, method.NewSignature)
+		}
+		for _, name := range change.GainedImplementations {
+			fmt.Fprintf(&b, "- New implementation `%s`\n", name)
+		}
+		for _, name := range change.LostImplementations {
+			fmt.Fprintf(&b, "- Lost implementation `%s`\n", name)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func iface(name string, methods []string, impls ...string) InterfaceDetails {
+	details := InterfaceDetails{InterfaceName: name}
+	for _, signature := range methods {
+		method, err := parseMethodSignature(token.NewFileSet(), signature)
+		if err != nil {
+			panic(err)
+		}
+		details.Methods = append(details.Methods, MethodDetails{Name: method.Name, Signature: signature})
+	}
+	for _, impl := range impls {
+		details.Implementations = append(details.Implementations, Implementation{TypeName: impl})
+	}
+	return details
+}
+
+func TestDiffReports(t *testing.T) {
+	old := &Report{ReportResults: ReportResults{Interfaces: []InterfaceDetails{
+		iface("Closer", []string{"Close() error"}),
+		iface("Store", []string{
+			"Get(id string) (string, error)",
+			"Put(id, v string) error",
+			"Delete(id string) error",
+			"Len() int",
+			"Find(q string) []string",
+		}, "MemStore", "DiskStore"),
+		iface("Unchanged", []string{"Ping() error"}, "Pinger"),
+	}}}
+	current := &Report{ReportResults: ReportResults{Interfaces: []InterfaceDetails{
+		iface("Fetcher", []string{"Fetch(url string) ([]byte, error)"}),
+		iface("Store", []string{
+			"Get(key string) (string, error)", // parameter renamed only
+			"Put(id, v string, ttl int) error",
+			"Len() int",
+			"Search(q string) []string", // Find renamed
+			"Keys() []string",
+		}, "MemStore", "RedisStore"),
+		iface("Unchanged", []string{"Ping() (err error)"}, "Pinger"),
+	}}}
+
+	want := ReportDiff{
+		AddedInterfaces:   []string{"Fetcher"},
+		RemovedInterfaces: []string{"Closer"},
+		ChangedInterfaces: []InterfaceChange{{
+			InterfaceName:  "Store",
+			AddedMethods:   []string{"Search", "Keys"},
+			RemovedMethods: []string{"Delete", "Find"},
+			ChangedMethods: []MethodChange{{
+				Name:         "Put",
+				OldSignature: "Put(id, v string) error",
+				NewSignature: "Put(id, v string, ttl int) error",
+			}},
+			GainedImplementations: []string{"RedisStore"},
+			LostImplementations:   []string{"DiskStore"},
+		}},
+	}
+
+	if got := diffReports(old, current); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffReports =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDiffReportsSameNameInTwoPackages(t *testing.T) {
+	store := func(pkg string, methods ...string) InterfaceDetails {
+		details := iface("Store", methods)
+		details.Package = pkg
+		return details
+	}
+	old := &Report{ReportResults: ReportResults{Interfaces: []InterfaceDetails{store("contracts", "Put()"), store("ports", "Get() string")}}}
+	current := &Report{ReportResults: ReportResults{Interfaces: []InterfaceDetails{store("contracts", "Put()"), store("ports", "Get() string", "Len() int")}}}
+
+	diff := diffReports(old, current)
+	if len(diff.AddedInterfaces) != 0 || len(diff.RemovedInterfaces) != 0 || len(diff.ChangedInterfaces) != 1 || diff.ChangedInterfaces[0].InterfaceName != "ports.Store" {
+		t.Errorf("diffReports = %+v, want ports.Store changed", diff)
+	}
+}
+
+func TestDiffReportsNoChanges(t *testing.T) {
+	report := &Report{ReportResults: ReportResults{Interfaces: []InterfaceDetails{iface("Store", []string{"Get(id string) string"}, "MemStore")}}}
+	diff := diffReports(report, report)
+	if len(diff.AddedInterfaces)+len(diff.RemovedInterfaces)+len(diff.ChangedInterfaces) != 0 {
+		t.Errorf("diff of identical reports = %+v", diff)
+	}
+	if got := renderDiffMarkdown(diff); got != "# Interface changes\n\nNo interface changes.\n" {
+		t.Errorf("markdown = %q", got)
+	}
+}
+
+// Reports of every shape load with their interfaces: the current one, with
+// the results nested, the flat one of schema version 12 and before, and the
+// legacy array
+func TestLoadReportShapes(t *testing.T) {
+	dir := t.TempDir()
+	shapes := map[string]string{
+		"nested": `{"schema_version": 13, "results": {"interfaces": [{"interface_name": "Store"}]}}`,
+		"flat":   `{"schema_version": 12, "interfaces": [{"interface_name": "Store"}]}`,
+		"legacy": `[{"interface_name": "Store"}]`,
+	}
+	for name, data := range shapes {
+		path := filepath.Join(dir, name+".json")
+		writeFile(t, path, data)
+		report, err := loadReport(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(report.Interfaces) != 1 || report.Interfaces[0].InterfaceName != "Store" {
+			t.Errorf("%s: interfaces = %+v, want Store", name, report.Interfaces)
+		}
+	}
+}
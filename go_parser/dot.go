@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Function to write the interface-implementation graph in Graphviz DOT.
+// Interfaces and implementing types are nodes, qualified with their package;
+// each implementation has an edge to the interface it implements. Edges of
+// types only *T satisfies are labelled "*T", partial matches are dashed.
+func writeDot(w io.Writer, results []InterfaceDetails) error {
+	var b strings.Builder
+	b.WriteString("digraph interfaces {\n\trankdir=LR;\n\tnode [fontname=\"Helvetica\"];\n\n")
+
+	types := make(map[string]bool)
+	for _, result := range results {
+		fmt.Fprintf(&b, "\t%s [shape=ellipse, style=filled, fillcolor=lightblue];\n", dotID(result.Package, result.InterfaceName))
+		for _, impl := range result.Implementations {
+			types[dotID(impl.Package, impl.TypeName)] = true
+		}
+	}
+	var ids []string
+	for id := range types {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(&b, "\t%s [shape=box];\n", id)
+	}
+
+	b.WriteString("\n")
+	for _, result := range results {
+		for _, impl := range result.Implementations {
+			var attrs []string
+			if impl.ReceiverSatisfaction == SatisfiedByPointer {
+				attrs = append(attrs, `label="*T"`)
+			}
+			if impl.Partial {
+				attrs = append(attrs, "style=dashed")
+			}
+			fmt.Fprintf(&b, "\t%s -> %s", dotID(impl.Package, impl.TypeName), dotID(result.Package, result.InterfaceName))
+			if len(attrs) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+			}
+			b.WriteString(";\n")
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Function to get the quoted node ID of a package-qualified name
+func dotID(pkg, name string) string {
+	if pkg != "" {
+		name = pkg + "." + name
+	}
+	return strconv.Quote(name)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteDot(t *testing.T) {
+	results := []InterfaceDetails{
+		{
+			InterfaceName: "Store",
+			Package:       "api",
+			Implementations: []Implementation{
+				{TypeName: "MemStore", Package: "mem", ReceiverSatisfaction: SatisfiedByPointer},
+				{TypeName: "Disk", Package: "disk", ReceiverSatisfaction: SatisfiedByBoth, Partial: true},
+			},
+		},
+		{
+			InterfaceName:   "Closer",
+			Package:         "api",
+			Implementations: []Implementation{{TypeName: "Disk", Package: "disk", ReceiverSatisfaction: SatisfiedByBoth}},
+		},
+	}
+
+	var b strings.Builder
+	if err := writeDot(&b, results); err != nil {
+		t.Fatal(err)
+	}
+	dot := b.String()
+
+	for _, want := range []string{
+		"digraph interfaces {",
+		`"api.Store" [shape=ellipse`,
+		`"disk.Disk" [shape=box];`,
+		`"mem.MemStore" -> "api.Store" [label="*T"];`,
+		`"disk.Disk" -> "api.Store" [style=dashed];`,
+		`"disk.Disk" -> "api.Closer";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT lacks %q:\n%s", want, dot)
+		}
+	}
+	if n := strings.Count(dot, `"disk.Disk" [shape=box]`); n != 1 {
+		t.Errorf("disk.Disk declared %d times, want once", n)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"log"
+	"path/filepath"
+)
+
+// A reference to an interface: the package declaring it, identified by
+// packageKey, and its name
+type interfaceRef struct {
+	pkg  string
+	name string
+}
+
+// An interface declared somewhere in the analyzed files, with its own methods
+// and the interfaces it embeds
+type declaredInterface struct {
+	methods []MethodDetails
+	embeds  []interfaceRef
+}
+
+// Function to identify the package in dir: its import path, or its
+// directory when it is not inside a module
+func packageKey(dir string) string {
+	if importPath := packageImportPath(dir); importPath != "" {
+		return importPath
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	return abs
+}
+
+// Function to resolve an embedded type such as Base, Base[T] or io.Reader
+// to an interface reference; ok is false for type elements like ~int
+func embeddedRef(expr ast.Expr, pkg string, imports map[string]string) (interfaceRef, bool) {
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		expr = index.X
+	case *ast.IndexListExpr:
+		expr = index.X
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return interfaceRef{pkg: pkg, name: t.Name}, true
+	case *ast.SelectorExpr:
+		if x, ok := t.X.(*ast.Ident); ok {
+			if importPath, ok := imports[x.Name]; ok {
+				return interfaceRef{pkg: importPath, name: t.Sel.Name}, true
+			}
+		}
+	}
+	return interfaceRef{}, false
+}
+
+// Function to list the references of the interfaces embedded in an interface
+func embeddedRefs(interfaceType *ast.InterfaceType, pkg string, imports map[string]string) []interfaceRef {
+	var refs []interfaceRef
+	for _, field := range interfaceType.Methods.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		if ref, ok := embeddedRef(field.Type, pkg, imports); ok {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// Function to index the interfaces declared in the packages of the interface
+// files and under the scan roots, so embedded interfaces resolve wherever
+// they are defined. It is built once per run and shared by every interface
+// file. When ctx is done, the interfaces of the files read so far are
+// returned.
+func declaredInterfaces(ctx context.Context, files []string, config *Config) map[interfaceRef]*declaredInterface {
+	var paths []string
+	seen := make(map[string]bool)
+	collect := func(path string) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			paths = append(paths, path)
+		}
+	}
+	dirs := make(map[string]bool)
+	for _, file := range files {
+		if dir := filepath.Dir(file); !dirs[dir] {
+			dirs[dir] = true
+			for _, path := range packageGoFiles(dir, config) {
+				collect(path)
+			}
+		}
+	}
+	for _, root := range config.scanRoots() {
+		if err := walkGoFiles(ctx, root, config, collect); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("Error walking directory: %v", err)
+		}
+	}
+	return collectDeclaredInterfaces(ctx, paths)
+}
+
+// Function to index every package-level interface declared in the given
+// files, stopping early when ctx is done
+func collectDeclaredInterfaces(ctx context.Context, files []string) map[interfaceRef]*declaredInterface {
+	declared := make(map[interfaceRef]*declaredInterface)
+	keys := make(map[string]string)
+	for _, path := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		fset := token.NewFileSet()
+		node, err := parser.ParseFile(fset, path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			log.Printf("Error parsing Go file %s: %v", path, err)
+			continue
+		}
+
+		dir := filepath.Dir(path)
+		if _, ok := keys[dir]; !ok {
+			keys[dir] = packageKey(dir)
+		}
+		indexDeclaredInterfaces(declared, fset, node, keys[dir])
+	}
+	return declared
+}
+
+// Function to add the package-level interfaces of a parsed file of package
+// pkg to the index
+func indexDeclaredInterfaces(declared map[interfaceRef]*declaredInterface, fset *token.FileSet, node *ast.File, pkg string) {
+	imports := fileImports(node)
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			interfaceType, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			iface := &declaredInterface{embeds: embeddedRefs(interfaceType, pkg, imports)}
+			for _, method := range interfaceType.Methods.List {
+				if funcType, ok := method.Type.(*ast.FuncType); ok && len(method.Names) > 0 {
+					iface.methods = append(iface.methods, interfaceMethod(fset, method, funcType, fieldNames(typeSpec.TypeParams)))
+				}
+			}
+			declared[interfaceRef{pkg: pkg, name: typeSpec.Name.Name}] = iface
+		}
+	}
+}
+
+// Function to collect the methods of embedded interfaces, following embeds
+// of embeds. Each interface is visited once, which also stops embedding
+// cycles. Methods are marked with the interface declaring them. Embeds that
+// are neither declared in the analyzed files nor known are returned as
+// unresolved.
+func embeddedMethods(refs []interfaceRef, declared map[interfaceRef]*declaredInterface, known map[string]knownInterface, visited map[interfaceRef]bool) ([]MethodDetails, []interfaceRef) {
+	var methods []MethodDetails
+	var unresolved []interfaceRef
+	for _, ref := range refs {
+		if visited[ref] {
+			continue
+		}
+		visited[ref] = true
+
+		iface, ok := declared[ref]
+		if !ok {
+			if knownIface, ok := known[knownName(ref)]; ok {
+				for _, method := range knownIface.methods {
+					method.embeddedFrom = knownIface.name
+					methods = append(methods, method)
+				}
+			} else {
+				unresolved = append(unresolved, ref)
+			}
+			continue
+		}
+		for _, method := range iface.methods {
+			method.embeddedFrom = ref.name
+			methods = append(methods, method)
+		}
+		nested, nestedUnresolved := embeddedMethods(iface.embeds, declared, known, visited)
+		methods = append(methods, nested...)
+		unresolved = append(unresolved, nestedUnresolved...)
+	}
+	return methods, unresolved
+}
+
+// Function to get the name an embedded interface has in the known
+// interface catalog, e.g. "io.Reader". An undeclared "error" is the
+// predeclared interface.
+func knownName(ref interfaceRef) string {
+	if ref.name == "error" {
+		return ref.name
+	}
+	return importName(&ast.ImportSpec{}, ref.pkg) + "." + ref.name
+}
+
+// Function to flatten the methods of embedded interfaces into each
+// interface. The interface's own methods win over embedded ones of the same
+// name, as do embeds listed first. Embeds whose methods can't be found are
+// recorded in UnresolvedEmbeds.
+func resolveEmbeddedInterfaces(interfaces map[interfaceRef]*InterfaceDetails, declared map[interfaceRef]*declaredInterface, known []knownInterface) {
+	knownByName := make(map[string]knownInterface)
+	for _, iface := range known {
+		knownByName[iface.name] = iface
+	}
+
+	for _, iface := range interfaces {
+		if len(iface.embedRefs) == 0 {
+			continue
+		}
+		self := iface.ref()
+		have := make(map[string]bool)
+		for _, method := range iface.Methods {
+			have[method.Name] = true
+		}
+		methods, unresolved := embeddedMethods(iface.embedRefs, declared, knownByName, map[interfaceRef]bool{self: true})
+		for _, method := range methods {
+			if !have[method.Name] {
+				have[method.Name] = true
+				iface.Methods = append(iface.Methods, method)
+			}
+		}
+		for _, ref := range unresolved {
+			name := ref.name
+			if ref.pkg != iface.packageKey {
+				name = knownName(ref)
+			}
+			iface.UnresolvedEmbeds = append(iface.UnresolvedEmbeds, name)
+		}
+	}
+}
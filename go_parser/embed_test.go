@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func methodNames(methods []MethodDetails) []string {
+	var names []string
+	for _, method := range methods {
+		names = append(names, method.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestEmbeddedInterfacesAcrossFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+import "example.com/app/base"
+
+type Store interface {
+	Reader
+	base.Closer
+	Put(id, v string) error
+}
+
+type Loop interface {
+	Cycle
+	Ping()
+}
+`)
+	// Reader lives in another file of the same package and embeds further
+	writeFile(t, filepath.Join(root, "api", "reader.go"), `package api
+
+type Reader interface {
+	Lister[string]
+	Get(id string) (string, error)
+}
+
+type Lister[T any] interface {
+	List() []T
+}
+
+type Cycle interface {
+	Loop
+	Pong()
+}
+`)
+	writeFile(t, filepath.Join(root, "base", "base.go"), `package base
+
+type Closer interface {
+	Close() error
+}
+`)
+	writeFile(t, filepath.Join(root, "impl", "mem.go"), `package impl
+
+type MemStore struct{}
+
+func (m *MemStore) Get(id string) (string, error) { return "", nil }
+func (m *MemStore) List() []string                { return nil }
+func (m *MemStore) Put(id, v string) error        { return nil }
+func (m *MemStore) Close() error                  { return nil }
+
+type Partial struct{}
+
+func (Partial) Put(id, v string) error { return nil }
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+
+	if got, want := methodNames(interfaceNamed(interfaces, "Store").Methods), []string{"Close", "Get", "List", "Put"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Store methods = %v, want %v", got, want)
+	}
+	// Embedding cycles end instead of recursing forever
+	if got, want := methodNames(interfaceNamed(interfaces, "Loop").Methods), []string{"Ping", "Pong"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Loop methods = %v, want %v", got, want)
+	}
+
+	results, _, _ := findImplementations(context.Background(), []string{filepath.Join(root, "impl")}, interfaces, config)
+	for _, result := range results {
+		if result.InterfaceName != "Store" {
+			continue
+		}
+		if len(result.Implementations) != 1 || result.Implementations[0].TypeName != "MemStore" {
+			t.Errorf("Store implementations = %+v, want only MemStore", result.Implementations)
+		}
+	}
+}
+
+func TestExternalEmbeds(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+import (
+	"io"
+
+	"github.com/acme/blob"
+)
+
+type Source interface {
+	io.Reader
+	Name() string
+}
+
+type Blob interface {
+	blob.Bucket
+	Name() string
+}
+`)
+	writeFile(t, filepath.Join(root, "impl", "file.go"), `package impl
+
+type File struct{}
+
+func (f *File) Read(p []byte) (int, error) { return 0, nil }
+func (f *File) Name() string               { return "" }
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+
+	// io.Reader is a known interface, so its method is inlined
+	source := interfaceNamed(interfaces, "Source")
+	if got, want := methodNames(source.Methods), []string{"Name", "Read"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Source methods = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(source.Embeds, []string{"io.Reader"}) || len(source.UnresolvedEmbeds) != 0 {
+		t.Errorf("Source embeds = %v, unresolved = %v", source.Embeds, source.UnresolvedEmbeds)
+	}
+
+	// blob.Bucket is outside the scanned tree, so its methods are unknown
+	blobIface := interfaceNamed(interfaces, "Blob")
+	if !reflect.DeepEqual(blobIface.UnresolvedEmbeds, []string{"blob.Bucket"}) {
+		t.Errorf("Blob unresolved embeds = %v, want [blob.Bucket]", blobIface.UnresolvedEmbeds)
+	}
+
+	results, _, _ := findImplementations(context.Background(), []string{filepath.Join(root, "impl")}, interfaces, config)
+	for _, result := range results {
+		if len(result.Implementations) != 1 {
+			t.Errorf("%s implementations = %+v, want File", result.InterfaceName, result.Implementations)
+			continue
+		}
+		if partial := result.Implementations[0].Partial; partial != (result.InterfaceName == "Blob") {
+			t.Errorf("%s match partial = %v", result.InterfaceName, partial)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseError is Go source that doesn't parse: an interface source, or the
+// file of analyze -stdin
+type ParseError struct {
+	File string
+	// The parser's error, a go/scanner.ErrorList with the position of each
+	// syntax error
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	msg := e.Err.Error()
+	if strings.HasPrefix(msg, e.File) {
+		return msg
+	}
+	return e.File + ": " + msg
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ConfigError is a config value the tool can't act on
+type ConfigError struct {
+	// Key of the value, as in the config file, e.g. "context_level"
+	Field  string
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+// Function to make a ConfigError for a key, with the reason formatted as
+// fmt.Sprintf does
+func configError(field, format string, args ...interface{}) error {
+	return &ConfigError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+// ProviderError is a response of an LLM provider other than 200 OK, once
+// retries, if any, are used up, so a caller can retry a 5xx later
+type ProviderError struct {
+	StatusCode int
+	// Response body, with the API key masked should the provider echo it
+	Body string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("status code %d: %s", e.StatusCode, e.Body)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTypedErrors(t *testing.T) {
+	root := t.TempDir()
+	broken := filepath.Join(root, "api.go")
+	writeFile(t, broken, "package api\n\ntype Store interface{")
+	_, err := Analyze(Config{GoFilePath: broken, GoDirectory: root})
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.File != broken || !strings.Contains(err.Error(), broken+":3:") {
+		t.Errorf("Analyze error = %v, want a ParseError of %s", err, broken)
+	}
+	if exitCode(err) != exitParse {
+		t.Errorf("exit code = %d, want %d", exitCode(err), exitParse)
+	}
+
+	err = (&Config{Concurrency: -1}).validate()
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "concurrency" || err.Error() != "invalid concurrency: -1, it can't be negative" {
+		t.Errorf("validate error = %v, want a ConfigError of concurrency", err)
+	}
+
+	server, _ := fakeOpenAI(t, http.StatusBadGateway)
+	maxRetries := 0
+	config := &Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true, MaxRetries: &maxRetries}
+	_, err = sendData(context.Background(), config, testResults(), newUsageTracker(config))
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.StatusCode != http.StatusBadGateway || !strings.Contains(providerErr.Body, "status 502") {
+		t.Errorf("sendData error = %v, want a ProviderError with status 502", err)
+	}
+	if exitCode(err) != exitProvider {
+		t.Errorf("exit code = %d, want %d", exitCode(err), exitProvider)
+	}
+}
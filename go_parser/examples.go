@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/doc"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Example is an Example function of a _test.go file, linked by its name to
+// what it documents as godoc does: ExampleStore to the type Store,
+// ExampleStore_Get to its method Get, with an optional lower-case suffix as
+// in ExampleStore_Get_cached
+type Example struct {
+	// Name of the function, e.g. "ExampleStore_Get"
+	Name string `json:"name"`
+	Doc  string `json:"doc,omitempty"`
+	// Body of the function, without the braces and the output comment
+	Code string `json:"code"`
+	// Expected output from the // Output: comment, if any
+	Output string `json:"output,omitempty"`
+	// Set for an // Unordered output: comment
+	Unordered bool   `json:"unordered,omitempty"`
+	Position  string `json:"position"`
+}
+
+// Output comment closing an example, as go test recognizes it
+var exampleOutput = regexp.MustCompile(`(?mi)^[ \t]*//\s*(unordered )?output:`)
+
+// Function to attach the Example functions of the _test.go files next to
+// each interface and implementation: examples of an interface go to it or,
+// when named after one of its methods, to the method, and those of an
+// implementation's type and methods to the implementation. Test files are
+// read for this whether or not include_tests is set.
+func attachExamples(results []InterfaceDetails) {
+	byDir := make(map[string]map[string][]Example)
+	examplesIn := func(filename string) map[string][]Example {
+		if filename == "" {
+			return nil
+		}
+		dir := filepath.Dir(filename)
+		if _, ok := byDir[dir]; !ok {
+			byDir[dir] = readExamples(dir)
+		}
+		return byDir[dir]
+	}
+
+	for i := range results {
+		result := &results[i]
+		if result.Anonymous != "" {
+			continue
+		}
+		if examples := examplesIn(result.pos.Filename); examples != nil {
+			result.Examples = examples[result.InterfaceName]
+			for j := range result.Methods {
+				method := &result.Methods[j]
+				if method.embeddedFrom == "" {
+					method.Examples = examples[result.InterfaceName+"_"+method.Name]
+				}
+			}
+		}
+		for j := range result.Implementations {
+			impl := &result.Implementations[j]
+			examples := examplesIn(impl.pos.Filename)
+			impl.Examples = examples[impl.TypeName]
+			for _, method := range impl.Methods {
+				impl.Examples = append(impl.Examples, examples[impl.TypeName+"_"+method.Name]...)
+			}
+		}
+	}
+}
+
+// Function to read the Example functions of the _test.go files of a
+// directory, by what they document, e.g. "Store" or "Store_Get". Files that
+// don't parse are left out.
+func readExamples(dir string) map[string][]Example {
+	paths, err := filepath.Glob(filepath.Join(dir, "*_test.go"))
+	if err != nil || len(paths) == 0 {
+		return nil
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, path := range paths {
+		if file, err := parser.ParseFile(fset, path, nil, parser.ParseComments); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	examples := make(map[string][]Example)
+	for _, example := range doc.Examples(files...) {
+		target := exampleTarget(example.Name)
+		examples[target] = append(examples[target], Example{
+			Name:      "Example" + example.Name,
+			Doc:       strings.TrimSpace(example.Doc),
+			Code:      exampleCode(fset, example),
+			Output:    strings.TrimSpace(example.Output),
+			Unordered: example.Unordered,
+			Position:  positionString(fset, example.Code.Pos()),
+		})
+	}
+	return examples
+}
+
+// Function to get what an example documents from its name without the
+// Example prefix, e.g. "Store_Get" from "Store_Get_cached": a last part
+// starting with a lower-case letter is a suffix telling examples apart
+func exampleTarget(name string) string {
+	if i := strings.LastIndex(name, "_"); i >= 0 && i+1 < len(name) && unicode.IsLower(rune(name[i+1])) {
+		return name[:i]
+	}
+	return name
+}
+
+// Function to print the code of an example as godoc shows it: the body of
+// the function unindented, with its comments but without the output one
+func exampleCode(fset *token.FileSet, example *doc.Example) string {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, &printer.CommentedNode{Node: example.Code, Comments: example.Comments}); err != nil {
+		return ""
+	}
+	code := buf.String()
+	if _, ok := example.Code.(*ast.BlockStmt); !ok {
+		return code
+	}
+
+	code = strings.TrimSuffix(strings.TrimPrefix(code, "{"), "}")
+	if loc := exampleOutput.FindStringIndex(code); loc != nil {
+		code = code[:loc[0]]
+	}
+	var lines []string
+	for _, line := range strings.Split(strings.Trim(code, "\n"), "\n") {
+		lines = append(lines, strings.TrimPrefix(line, "\t"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")) + "\n"
+}
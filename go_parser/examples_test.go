@@ -0,0 +1,124 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExamples(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface {\n\tGet(id string) string\n\tPut(id string)\n}\n")
+	writeFile(t, filepath.Join(root, "api", "api_test.go"), `package api_test
+
+import "fmt"
+
+// A Store is usually opened once.
+func ExampleStore() {
+	fmt.Println("open")
+}
+
+func ExampleStore_Get() {
+	// Look the id up
+	fmt.Println("item")
+	// Output: item
+}
+
+func ExampleNotAType() {}
+`)
+	writeFile(t, filepath.Join(root, "mem", "mem.go"), `package mem
+
+type MemStore struct{}
+
+func (MemStore) Get(id string) string { return id }
+
+func (MemStore) Put(id string) {}
+`)
+	writeFile(t, filepath.Join(root, "mem", "mem_test.go"), `package mem
+
+import "fmt"
+
+func ExampleMemStore_Put_twice() {
+	fmt.Println("a")
+	fmt.Println("b")
+	// Unordered output:
+	// b
+	// a
+}
+`)
+
+	report, err := Analyze(Config{GoFilePath: ifacePath, GoDirectory: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := report.Interfaces[0]
+	if len(store.Examples) != 1 || store.Examples[0].Name != "ExampleStore" || store.Examples[0].Doc != "A Store is usually opened once." {
+		t.Errorf("interface examples = %+v", store.Examples)
+	}
+	get := store.Methods[0].Examples
+	if len(get) != 1 || get[0].Code != "// Look the id up\nfmt.Println(\"item\")\n" || get[0].Output != "item" {
+		t.Errorf("Get examples = %+v", get)
+	}
+	if len(store.Implementations) != 1 {
+		t.Fatalf("implementations = %+v, want MemStore only, as test files aren't scanned", store.Implementations)
+	}
+	impl := store.Implementations[0].Examples
+	if len(impl) != 1 || impl[0].Name != "ExampleMemStore_Put_twice" || !impl[0].Unordered || impl[0].Output != "b\na" {
+		t.Errorf("MemStore examples = %+v", impl)
+	}
+
+	markdown := renderMarkdown(report.Interfaces, nil)
+	for _, want := range []string{
+		"### Examples\n\n#### ExampleStore\n\nA Store is usually opened once.\n\n```go\nfmt.Println(\"open\")\n```\n",
+		"#### ExampleStore_Get\n\n```go\n// Look the id up\nfmt.Println(\"item\")\n```\n\nOutput:\n\n```\nitem\n```\n",
+		"#### ExampleMemStore_Put_twice\n\n```go\nfmt.Println(\"a\")\nfmt.Println(\"b\")\n```\n\nOutput, in any order:\n\n```\nb\na\n```\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestExamplesInPromptAndPackageDocs(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface{ Get(id string) string }\n\n// Cache caches.\ntype Cache struct{}\n\nfunc NewCache() *Cache { return nil }\n\nfunc (*Cache) Get(id string) string { return id }\n")
+	writeFile(t, filepath.Join(root, "api", "api_test.go"), `package api
+
+import "fmt"
+
+func ExampleStore() {
+	fmt.Println("store")
+	// Output: store
+}
+
+func ExampleNewCache() {
+	fmt.Println("new")
+}
+
+func ExampleCache_Get() {
+	fmt.Println("get")
+}
+`)
+
+	config := Config{GoFilePath: ifacePath, GoDirectory: root}
+	report, err := Analyze(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	message := formatResultsForMessage(report.Interfaces)
+	if want := "Examples from its tests, to include in the documentation as Go code blocks:\nExampleStore:\n```go\nfmt.Println(\"store\")\n```\nOutput:\n```\nstore\n```\n"; !strings.Contains(message, want) {
+		t.Errorf("prompt lacks %q:\n%s", want, message)
+	}
+
+	doc := renderPackageDoc(groupPackages(report)[0])
+	for _, want := range []string{
+		"#### ExampleStore\n\n```go\nfmt.Println(\"store\")\n```\n",
+		"## Examples\n\n### ExampleCache_Get\n\n```go\nfmt.Println(\"get\")\n```\n\n### ExampleNewCache\n",
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("package doc lacks %q:\n%s", want, doc)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"go/scanner"
+	"log"
+	"os"
+	"strings"
+)
+
+// Exit codes of the commands, so CI can tell why a run failed. A run
+// stopped by a signal exits with exitInterrupted instead.
+const (
+	exitOK = 0
+	// Bad flags, arguments or config, and failures that fit no other code
+	exitUsage = 1
+	// Go source that doesn't parse: an interface source, an -stdin file, or,
+	// with -strict, a scanned file the scan skipped
+	exitParse = 2
+	// The output couldn't be written
+	exitOutput = 3
+	// The LLM provider failed or rejected the request
+	exitProvider = 4
+	// A policy gate failed: max_undocumented, fail_on_deprecated_usage or
+	// block_on_secrets
+	exitPolicy = 5
+)
+
+// exitError is an error marked with the exit code it ends a run with
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// Function to mark an error with the exit code it ends a run with; nil
+// stays nil
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// Function to get the exit code an error ends a run with: the code it was
+// marked with, the first one when several joined errors are, exitParse for
+// a ParseError or other Go syntax errors, exitProvider for a ProviderError
+// and exitUsage for anything else
+func exitCode(err error) int {
+	var marked *exitError
+	var parseErr *ParseError
+	var syntax scanner.ErrorList
+	var provider *ProviderError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &marked):
+		return marked.code
+	case errors.As(err, &parseErr), errors.As(err, &syntax):
+		return exitParse
+	case errors.As(err, &provider):
+		return exitProvider
+	default:
+		return exitUsage
+	}
+}
+
+// Function to log a failure and exit with the given code, as log.Fatalf
+// does with 1
+func fatalf(code int, format string, args ...interface{}) {
+	log.Printf(format, args...)
+	os.Exit(code)
+}
+
+// Function to parse the flags of a command, whose flag set must continue on
+// errors: -h exits with 0 and a bad flag with exitUsage, rather than the 2
+// of flag.ExitOnError
+func parseFlags(fs *flag.FlagSet, args []string) {
+	switch err := fs.Parse(args); {
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(exitOK)
+	case err != nil:
+		os.Exit(exitUsage)
+	}
+}
+
+// Function to get the exit code of a run whose output was written: exitParse
+// when -strict is set and scanned files were skipped as they didn't parse,
+// then exitPolicy when the undocumented methods exceed max_undocumented or,
+// with fail_on_deprecated_usage, a deprecated interface is still in use,
+// with the reason to print
+func runExitCode(report *Report, config *Config) (int, string) {
+	switch {
+	case config.Strict && len(report.ParseErrors) > 0:
+		return exitParse, fmt.Sprintf("%d Go files were skipped as they don't parse, and -strict is set", len(report.ParseErrors))
+	case config.MaxUndocumented != nil && len(report.Undocumented) > *config.MaxUndocumented:
+		return exitPolicy, fmt.Sprintf("%d exported methods are undocumented, more than max_undocumented (%d)", len(report.Undocumented), *config.MaxUndocumented)
+	}
+	if config.FailOnDeprecatedUsage {
+		if inUse := deprecatedInUse(report.Interfaces); len(inUse) > 0 {
+			return exitPolicy, fmt.Sprintf("deprecated interfaces are still in use, and fail_on_deprecated_usage is set: %s", strings.Join(inUse, ", "))
+		}
+	}
+	return exitOK, ""
+}
+
+// Function to exit with the code of a run whose output was written, see
+// runExitCode, when it isn't 0. An interrupted run exits with
+// exitInterrupted first. Commands defer it once the report is analyzed.
+func exitForRun(ctx context.Context, report *Report, config *Config) {
+	exitIfInterrupted(ctx)
+	if code, reason := runExitCode(report, config); code != exitOK {
+		fatalf(code, "Error: %s", reason)
+	}
+}
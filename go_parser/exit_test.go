@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	_, syntax := parser.ParseFile(token.NewFileSet(), "api.go", "package api\n\ntype Store interface{", 0)
+	provider := withExitCode(exitProvider, errors.New("status code 503"))
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"success", nil, exitOK},
+		{"config error", errors.New("invalid format"), exitUsage},
+		{"syntax error", fmt.Errorf("reading interfaces: %w", syntax), exitParse},
+		{"write error", withExitCode(exitOutput, errors.New("disk full")), exitOutput},
+		{"wrapped provider error", fmt.Errorf("request 2: %w", provider), exitProvider},
+		{"first of joined errors", errors.Join(provider, withExitCode(exitOutput, errors.New("disk full"))), exitProvider},
+		{"policy gate", withExitCode(exitPolicy, errors.New("block_on_secrets is set")), exitPolicy},
+	}
+	for _, test := range tests {
+		if got := exitCode(test.err); got != test.want {
+			t.Errorf("%s: exit code = %d, want %d", test.name, got, test.want)
+		}
+	}
+	if withExitCode(exitOutput, nil) != nil {
+		t.Error("a nil error was marked")
+	}
+}
+
+func TestRunExitCode(t *testing.T) {
+	zero, two := 0, 2
+	skipped := []FileError{{File: "broken.go", Error: "expected '}'"}}
+	undocumented := []UndocumentedMethod{{Symbol: "Store.Get"}, {Symbol: "Store.Put"}}
+	tests := []struct {
+		name   string
+		report Report
+		config Config
+		want   int
+	}{
+		{"clean", Report{}, Config{Strict: true, MaxUndocumented: &zero}, exitOK},
+		{"parse errors without -strict", Report{ReportResults: ReportResults{ParseErrors: skipped}}, Config{}, exitOK},
+		{"parse errors with -strict", Report{ReportResults: ReportResults{ParseErrors: skipped}}, Config{Strict: true}, exitParse},
+		{"no limit", Report{ReportResults: ReportResults{Undocumented: undocumented}}, Config{}, exitOK},
+		{"within the limit", Report{ReportResults: ReportResults{Undocumented: undocumented}}, Config{MaxUndocumented: &two}, exitOK},
+		{"over the limit", Report{ReportResults: ReportResults{Undocumented: undocumented}}, Config{MaxUndocumented: &zero}, exitPolicy},
+		{"parse errors first", Report{ReportResults: ReportResults{ParseErrors: skipped, Undocumented: undocumented}}, Config{Strict: true, MaxUndocumented: &zero}, exitParse},
+	}
+	for _, test := range tests {
+		if got, _ := runExitCode(&test.report, &test.config); got != test.want {
+			t.Errorf("%s: exit code = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCommandExitCodes(t *testing.T) {
+	if args := os.Getenv("GO_PARSER_DISPATCH_ARGS"); args != "" {
+		dispatch(strings.Split(args, "\n"))
+		os.Exit(0)
+	}
+
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api.go")
+	writeFile(t, ifacePath, "package api\n\n// Store stores.\ntype Store interface {\n\tGet(id string) string\n}\n")
+	writeFile(t, filepath.Join(root, "broken.go"), "package api\n\nfunc {\n")
+	configPath := filepath.Join(root, "config.yaml")
+	writeFile(t, configPath, fmt.Sprintf("go_file_path: %s\ngo_directory: %s\n", ifacePath, root))
+	badSource := filepath.Join(root, "bad.yaml")
+	writeFile(t, badSource, fmt.Sprintf("go_file_path: %s\ngo_directory: %s\n", filepath.Join(root, "broken.go"), root))
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"skipped file without -strict", []string{"analyze", "-config", configPath, "-o", filepath.Join(root, "out.json")}, exitOK},
+		{"unknown flag", []string{"analyze", "-no-such-flag"}, exitUsage},
+		{"unknown command", []string{"frobnicate"}, exitUsage},
+		{"missing config", []string{"analyze", "-config", filepath.Join(root, "missing.yaml")}, exitUsage},
+		{"interface source doesn't parse", []string{"analyze", "-config", badSource}, exitParse},
+		{"skipped file with -strict", []string{"analyze", "-config", configPath, "-strict", "-o", filepath.Join(root, "out.json")}, exitParse},
+		{"unwritable output", []string{"analyze", "-config", configPath, "-o", filepath.Join(root, "missing", "out.json")}, exitOutput},
+		{"undocumented over the limit", []string{"analyze", "-config", configPath, "-max-undocumented", "0", "-o", filepath.Join(root, "out.json")}, exitPolicy},
+	}
+	for _, test := range tests {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCommandExitCodes$")
+		cmd.Env = append(os.Environ(), "GO_PARSER_DISPATCH_ARGS="+strings.Join(test.args, "\n"))
+		err := cmd.Run()
+		got := exitOK
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			got = exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: exit code = %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestScanReportsSkippedFiles(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface {\n\tGet(id string) string\n}\n")
+	broken := filepath.Join(root, "broken.go")
+	writeFile(t, broken, "package api\n\nfunc {\n")
+
+	config := Config{GoFilePath: ifacePath, GoDirectory: root}
+	report, err := Analyze(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.ParseErrors) != 1 || report.ParseErrors[0].File != filepath.ToSlash(broken) || report.ParseErrors[0].Error == "" {
+		t.Errorf("parse errors = %+v, want broken.go", report.ParseErrors)
+	}
+	if report, _ := Analyze(config); len(report.ParseErrors) != 1 {
+		t.Errorf("parse errors = %+v, want those of the last scan only", report.ParseErrors)
+	}
+}
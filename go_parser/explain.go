@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Function to find the declarations of an interface by name in the
+// interface sources, every package's declaration being returned. The index
+// of declared interfaces is built once for all the files. When pkg is set
+// only those of that package are kept: a package name such as "api", or the
+// directory declaring it, e.g. "internal/api".
+func findNamedInterfaces(ctx context.Context, sources []string, config *Config, name, pkg string) ([]*InterfaceDetails, error) {
+	var candidates []*InterfaceDetails
+	files := interfaceFiles(sources, config)
+	declared := declaredInterfaces(ctx, files, config)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		found, _, err := findInterfacesInSource(file, src, config, declared)
+		if err != nil {
+			return nil, err
+		}
+		for _, iface := range found {
+			if iface.InterfaceName == name && (pkg == "" || inPackage(iface, pkg)) {
+				candidates = append(candidates, iface)
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// Function to tell whether an interface is declared in a package, given by
+// name or by directory
+func inPackage(iface *InterfaceDetails, pkg string) bool {
+	if iface.Package == pkg {
+		return true
+	}
+	dir := filepath.ToSlash(filepath.Dir(iface.pos.Filename))
+	pkg = filepath.ToSlash(filepath.Clean(pkg))
+	return dir == pkg || strings.HasSuffix(dir, "/"+pkg)
+}
+
+// Function to describe the declarations of an ambiguous name, one per line,
+// e.g. "  api (internal/api/repo.go:12)"
+func describeCandidates(candidates []*InterfaceDetails) string {
+	var lines []string
+	for _, candidate := range candidates {
+		lines = append(lines, fmt.Sprintf("  %s (%s)", candidate.Package, formatPosition(candidate.pos)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Function to analyze one interface: its implementations and usages under
+// the scan roots. Only its method set is matched, and the include and
+// exclude lists, -since and stub generation, which are about the full run,
+// don't apply.
+func explainInterface(ctx context.Context, config *Config, iface *InterfaceDetails) (*Report, error) {
+	start := time.Now()
+	roots := config.scanRoots()
+	interfaces := map[interfaceRef]*InterfaceDetails{iface.ref(): iface}
+	results, types, _, err := scanImplementations(ctx, roots, interfaces, config)
+	if err != nil {
+		return nil, fmt.Errorf("scanning for implementations: %w", err)
+	}
+	attachExamples(results)
+	markDeprecated(results)
+	config.Metrics.scanDone(time.Since(start), len(results))
+
+	return &Report{
+		SchemaVersion: schemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		ToolVersion:   toolVersion(),
+		Paths:         ReportPaths{InterfaceSources: []string{iface.pos.Filename}, Roots: roots},
+		ReportResults: ReportResults{
+			Interfaces:   results,
+			Summary:      summarize(results, 0),
+			Partial:      ctx.Err() != nil,
+			Undocumented: undocumentedMethods(results),
+			ParseErrors:  config.skippedFiles,
+			SkippedFiles: config.limitedFiles,
+		},
+		types:  types,
+		config: config,
+	}, nil
+}
+
+func runExplain(args []string) {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	var common commonFlags
+	// -package names the package of the interface here, and the flags about
+	// picking interfaces for a full run don't apply, so those common flags
+	// are left out
+	shared := flag.NewFlagSet("explain", flag.ContinueOnError)
+	common.register(shared)
+	skipped := map[string]bool{"package": true, "interactive": true, "save-selection": true, "since": true, "generate-stubs": true, "exclude-interface": true}
+	shared.VisitAll(func(f *flag.Flag) {
+		if !skipped[f.Name] {
+			fs.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	name := fs.String("interface", "", "name of the interface to explain, e.g. UserRepository")
+	pkg := fs.String("package", "", "package of the interface, by name or directory, when the name is declared in more than one")
+	ask := fs.Bool("ask", false, "also ask the LLM to document the interface")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON instead of markdown")
+	parseFlags(fs, args)
+	if *name == "" {
+		fatalf(exitUsage, "Error in flags: -interface is required")
+	}
+
+	config := common.loadConfig()
+	ctx, stop := interruptContext()
+	defer stop()
+	defer exitIfInterrupted(ctx)
+	candidates, err := findNamedInterfaces(ctx, config.interfaceSources(), config, *name, *pkg)
+	if err != nil {
+		fatalf(exitCode(err), "Error reading interfaces: %v", err)
+	}
+	switch {
+	case len(candidates) == 0 && *pkg != "":
+		fatalf(exitUsage, "Error: no interface %s in package %s", *name, *pkg)
+	case len(candidates) == 0:
+		fatalf(exitUsage, "Error: no interface %s in the interface sources", *name)
+	case len(candidates) > 1:
+		fmt.Fprintf(os.Stderr, "Interface %s is declared in %d places; pick one with -package:\n%s\n", *name, len(candidates), describeCandidates(candidates))
+		os.Exit(exitUsage)
+	}
+
+	report, err := explainInterface(ctx, config, candidates[0])
+	if err != nil {
+		fatalf(exitCode(err), "Error: %v", err)
+	}
+	defer exitForRun(ctx, report, config)
+
+	if *jsonOutput {
+		if err := writeReportJSON(os.Stdout, report, schemaVersion); err != nil {
+			fatalf(exitOutput, "Error writing JSON: %v", err)
+		}
+	} else if _, err := io.WriteString(os.Stdout, report.Markdown()); err != nil {
+		fatalf(exitOutput, "Error writing markdown: %v", err)
+	}
+	printSummary(os.Stderr, report.Summary)
+
+	if *ask {
+		requireAPIKey(config)
+		tracker := newUsageTracker(config)
+		content, err := sendData(ctx, config, collapseTestDoubles(report.Interfaces, config), tracker)
+		if content != "" {
+			if werr := writeContent("", content); werr != nil {
+				log.Printf("Error: %v", werr)
+			}
+		}
+		tracker.printSummary(os.Stderr)
+		if err != nil {
+			fatalf(exitCode(err), "Error asking the LLM: %v", err)
+		}
+	}
+}
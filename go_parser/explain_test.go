@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExplainInterface(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "repo.go"), `package api
+
+type UserRepository interface {
+	Find(id string) (string, error)
+}
+
+type Store interface {
+	Get(id string) (string, error)
+}
+`)
+	writeFile(t, filepath.Join(root, "legacy", "repo.go"), `package legacy
+
+type UserRepository interface {
+	Load(id int) string
+}
+`)
+	writeFile(t, filepath.Join(root, "db", "users.go"), `package db
+
+type Users struct{}
+
+func (u *Users) Find(id string) (string, error) { return "", nil }
+
+func (u *Users) Get(id string) (string, error) { return "", nil }
+`)
+	config := &Config{GoFilePath: filepath.Join(root, "api"), InterfaceSources: []string{filepath.Join(root, "legacy")}, GoDirectory: root}
+	sources := config.interfaceSources()
+
+	// The same name in two packages needs -package
+	candidates, err := findNamedInterfaces(context.Background(), sources, config, "UserRepository", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 || !strings.Contains(describeCandidates(candidates), "  legacy (") {
+		t.Fatalf("candidates:\n%s", describeCandidates(candidates))
+	}
+	for _, pkg := range []string{"api", filepath.Join(root, "api"), "api/"} {
+		if candidates, _ := findNamedInterfaces(context.Background(), sources, config, "UserRepository", pkg); len(candidates) != 1 || candidates[0].Package != "api" {
+			t.Errorf("-package %s: %d candidates", pkg, len(candidates))
+		}
+	}
+
+	// Only the named interface is matched
+	report, err := explainInterface(context.Background(), config, candidates[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Interfaces) != 1 || len(report.Interfaces[0].Implementations) != 1 || report.Interfaces[0].Implementations[0].TypeName != "Users" {
+		t.Errorf("report = %+v", report.Interfaces)
+	}
+	if markdown := report.Markdown(); !strings.Contains(markdown, "## UserRepository\n") || strings.Contains(markdown, "Store") {
+		t.Errorf("markdown:\n%s", markdown)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/build"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Default of max_file_size_bytes. Larger Go files are generated, e.g. embedded
+// assets, and slow to parse.
+const defaultMaxFileSizeBytes = 1 << 20
+
+// goFileSelector picks the Go files the go tool would build with the
+// configured tags. Test files mostly hold mocks, so they are only included
+// on request.
+type goFileSelector struct {
+	buildContext build.Context
+	includeTests bool
+}
+
+func newGoFileSelector(config *Config) *goFileSelector {
+	buildContext := build.Default
+	buildContext.BuildTags = config.BuildTags
+	return &goFileSelector{buildContext: buildContext, includeTests: config.IncludeTests}
+}
+
+// Function to check whether a file is a Go file selected for analysis. The
+// extension is matched case-insensitively, as a case-insensitive filesystem
+// may report main.GO.
+func (s *goFileSelector) selects(path string) bool {
+	name := filepath.Base(path)
+	ext := filepath.Ext(name)
+	if !strings.EqualFold(ext, ".go") {
+		return false
+	}
+	if strings.HasSuffix(strings.ToLower(name), "_test.go") && !s.includeTests {
+		return false
+	}
+
+	// MatchFile only accepts a lower-case extension, so check the file under
+	// that name while reading it by its real one
+	buildContext := s.buildContext
+	if ext != ".go" {
+		buildContext.OpenFile = func(string) (io.ReadCloser, error) { return os.Open(path) }
+		name = strings.TrimSuffix(name, ext) + ".go"
+	}
+
+	// Skip files excluded by GOOS/GOARCH suffixes or build constraints
+	match, err := buildContext.MatchFile(filepath.Dir(path), name)
+	if err != nil {
+		log.Printf("Error checking build constraints of %s: %v", path, err)
+		return false
+	}
+	return match
+}
+
+// Function to call visit for every selected Go file under dir, skipping
+// paths matched by exclude_dirs or a .godocignore file. The walk stops with
+// ctx's error once ctx is done. With follow_symlinks, symlinked directories
+// are walked too, under the path of the link; see symlinkWalker.
+func walkGoFiles(ctx context.Context, dir string, config *Config, visit func(path string)) error {
+	selector := newGoFileSelector(config)
+	ignore, err := newIgnoreMatcher(config.ExcludeDirs)
+	if err != nil {
+		return err
+	}
+	visited := 0
+	var links *symlinkWalker
+	if config.FollowSymlinks {
+		if links, err = newSymlinkWalker(dir); err != nil {
+			return err
+		}
+	}
+
+	// Walks root, whose files are reported under logical, the path it is
+	// reached by from dir
+	var walk func(root, logical string) error
+	walk = func(root, logical string) error {
+		return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			sub, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			if root != logical {
+				path = filepath.Join(logical, sub)
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			isDir := entry.IsDir()
+			var target string
+			if links != nil && entry.Type()&fs.ModeSymlink != 0 {
+				target, isDir = links.resolve(path)
+			}
+			if rel != "." && ignore.ignored(filepath.ToSlash(rel), isDir) {
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if isDir && config.MaxDepth > 0 && pathDepth(rel) > config.MaxDepth {
+				config.skipLimited(path, fmt.Sprintf("deeper than max_depth %d", config.MaxDepth))
+				if entry.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if target != "" {
+				return walk(target, path)
+			}
+			if entry.IsDir() {
+				if links != nil && !links.enter(root, sub) {
+					return filepath.SkipDir
+				}
+				return ignore.load(path, rel)
+			}
+			if !isDir && selector.selects(path) {
+				if !config.withinSizeLimit(path, entry) {
+					return nil
+				}
+				visited++
+				visit(path)
+			}
+			return nil
+		})
+	}
+	err = walk(dir, dir)
+	if err == nil && visited == 0 {
+		if rules := ignore.usedRules(); len(rules) > 0 {
+			log.Printf("Warning: every Go file under %s is ignored (by %s)", dir, strings.Join(rules, ", "))
+		}
+	}
+	return err
+}
+
+// Function to count the directories of a path relative to a scan root, 1
+// for its subdirectories
+func pathDepth(rel string) int {
+	if rel == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// Function to check a selected Go file against max_file_size_bytes, noting
+// it as skipped when it is larger
+func (c *Config) withinSizeLimit(path string, entry fs.DirEntry) bool {
+	info, err := entry.Info()
+	if err == nil && entry.Type()&fs.ModeSymlink != 0 {
+		info, err = os.Stat(path)
+	}
+	if err != nil {
+		// Reading the file reports the error
+		return true
+	}
+	limit := c.maxFileSizeBytes()
+	if info.Size() <= limit {
+		return true
+	}
+	c.skipLimited(path, fmt.Sprintf("%d bytes, over max_file_size_bytes %d", info.Size(), limit))
+	return false
+}
+
+// Function to get the largest Go file walked, applying the default
+func (c *Config) maxFileSizeBytes() int64 {
+	if c.MaxFileSizeBytes > 0 {
+		return c.MaxFileSizeBytes
+	}
+	return defaultMaxFileSizeBytes
+}
+
+// Function to note a file or directory left out of the walk by a limit, for
+// the report. Every path is warned about once, however many walks reach it.
+func (c *Config) skipLimited(path, reason string) {
+	file := filepath.ToSlash(path)
+	for _, skipped := range c.limitedFiles {
+		if skipped.File == file {
+			return
+		}
+	}
+	c.limitedFiles = append(c.limitedFiles, FileError{File: file, Error: reason})
+	if c.limitWarned == nil {
+		c.limitWarned = make(map[string]bool)
+	}
+	if !c.limitWarned[file] {
+		c.limitWarned[file] = true
+		log.Printf("Warning: skipping %s: %s", file, reason)
+	}
+}
+
+// symlinkWalker follows directory symlinks for walkGoFiles. Every directory
+// is walked once, whichever path reaches it first, which also stops loops
+// such as a link to an ancestor. Directories are identified by their path
+// with every link resolved, which names the same directory (inode) whatever
+// link leads to it, and works on every platform.
+type symlinkWalker struct {
+	visited map[string]bool
+	// Real path of each root being walked, the scan root or a link target
+	real map[string]string
+}
+
+func newSymlinkWalker(dir string) (*symlinkWalker, error) {
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	if real, err = filepath.Abs(real); err != nil {
+		return nil, err
+	}
+	return &symlinkWalker{visited: make(map[string]bool), real: map[string]string{dir: real}}, nil
+}
+
+// Function to resolve a symlink met during the walk. Returns the real path
+// of its target when it is a directory not walked yet, and whether it is a
+// directory at all; broken links are skipped with a warning.
+func (w *symlinkWalker) resolve(path string) (string, bool) {
+	target, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		target, err = filepath.Abs(target)
+	}
+	if err != nil {
+		log.Printf("Warning: skipping symlink %s: %v", path, err)
+		return "", false
+	}
+	info, err := os.Stat(target)
+	if err != nil || !info.IsDir() {
+		return "", false
+	}
+	if w.visited[target] {
+		return "", true
+	}
+	w.real[target] = target
+	return target, true
+}
+
+// Function to record a directory being entered, given by the root it is
+// walked from and its path below that root. Returns false when it was
+// walked already.
+func (w *symlinkWalker) enter(root, sub string) bool {
+	real := filepath.Join(w.real[root], sub)
+	if w.visited[real] {
+		return false
+	}
+	w.visited[real] = true
+	return true
+}
+
+// Function to expand the globs among paths, keeping the other paths as they
+// are; with dirsOnly a glob only expands to directories. key names the
+// config key in the warning about a glob matching nothing.
+func expandPatterns(patterns []string, key string, dirsOnly bool) []string {
+	var paths []string
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, _ := filepath.Glob(pattern) // validate checked the pattern
+		found := false
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && (info.IsDir() || !dirsOnly) {
+				paths = append(paths, match)
+				found = true
+			}
+		}
+		if !found {
+			log.Printf("Warning: %s pattern %q matches nothing", key, pattern)
+		}
+	}
+	return paths
+}
+
+// Function to list the directories scanned for implementations: go_directory
+// followed by go_directories. Entries may be globs such as services/* or
+// */internal, which expand to the directories they match. A root inside
+// another one is dropped, as walking the outer one covers it.
+func (c *Config) scanRoots() []string {
+	candidates := expandPatterns(append([]string{c.GoDirectory}, c.GoDirectories...), "go_directories", true)
+
+	abs := make([]string, len(candidates))
+	for i, root := range candidates {
+		abs[i] = filepath.Clean(root)
+		if path, err := filepath.Abs(root); err == nil {
+			abs[i] = path
+		}
+	}
+	var roots []string
+	for i, root := range candidates {
+		covered := false
+		for j := range candidates {
+			if j == i {
+				continue
+			}
+			rel, err := filepath.Rel(abs[j], abs[i])
+			inside := err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+			// Of two equal roots, the first is kept
+			if inside || (rel == "." && j < i) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			roots = append(roots, root)
+		}
+	}
+	return roots
+}
+
+// Function to list the selected Go files directly inside dir, i.e. the
+// files of one package
+func packageGoFiles(dir string, config *Config) []string {
+	selector := newGoFileSelector(config)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("Error reading directory %s: %v", dir, err)
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && selector.selects(path) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
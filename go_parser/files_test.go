@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestWalkGoFilesFoldsExtensionCase(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"store.go", "MAIN.GO", "cache.Go", "store_test.go", "mock_TEST.GO", "notes.txt", "go"} {
+		writeFile(t, filepath.Join(root, "svc", name), "package svc\n")
+	}
+	writeFile(t, filepath.Join(root, "svc", "ignored.GO"), "//go:build never\n\npackage svc\n")
+
+	walk := func(config *Config) []string {
+		var files []string
+		if err := walkGoFiles(context.Background(), root, config, func(path string) {
+			rel, _ := filepath.Rel(root, path)
+			files = append(files, filepath.ToSlash(rel))
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	if got, want := walk(&Config{}), []string{"svc/MAIN.GO", "svc/cache.Go", "svc/store.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if got, want := walk(&Config{IncludeTests: true}), []string{"svc/MAIN.GO", "svc/cache.Go", "svc/mock_TEST.GO", "svc/store.go", "svc/store_test.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files with tests = %v, want %v", got, want)
+	}
+}
+
+func TestWalkLimits(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface{ Get() string }\n")
+	writeFile(t, filepath.Join(root, "mem", "mem.go"), "package mem\n\ntype MemStore struct{}\n\nfunc (MemStore) Get() string { return \"\" }\n")
+	writeFile(t, filepath.Join(root, "gen", "gen.go"), "package gen\n\ntype GenStore struct{}\n\nfunc (GenStore) Get() string { return \""+strings.Repeat("x", 300)+"\" }\n")
+	writeFile(t, filepath.Join(root, "a", "b", "c", "deep.go"), "package c\n\ntype DeepStore struct{}\n\nfunc (DeepStore) Get() string { return \"\" }\n")
+
+	report, err := Analyze(Config{GoFilePath: ifacePath, GoDirectory: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Interfaces[0].Implementations) != 3 || len(report.SkippedFiles) != 0 {
+		t.Errorf("implementations = %+v, skipped = %+v, want all three without limits", report.Interfaces[0].Implementations, report.SkippedFiles)
+	}
+
+	report, err = Analyze(Config{GoFilePath: ifacePath, GoDirectory: root, MaxFileSizeBytes: 200, MaxDepth: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if impls := report.Interfaces[0].Implementations; len(impls) != 1 || impls[0].TypeName != "MemStore" {
+		t.Errorf("implementations = %+v, want MemStore only", impls)
+	}
+	want := []FileError{
+		{File: filepath.ToSlash(filepath.Join(root, "a", "b", "c")), Error: "deeper than max_depth 2"},
+		{File: filepath.ToSlash(filepath.Join(root, "gen", "gen.go")), Error: "380 bytes, over max_file_size_bytes 200"},
+	}
+	if !reflect.DeepEqual(report.SkippedFiles, want) {
+		t.Errorf("skipped = %+v, want %+v", report.SkippedFiles, want)
+	}
+}
+func TestWalkGoFilesFollowsSymlinks(t *testing.T) {
+	base := t.TempDir()
+	root := filepath.Join(base, "repo")
+	writeFile(t, filepath.Join(root, "a", "store.go"), "package a\n")
+	writeFile(t, filepath.Join(root, "vendored", "lib.go"), "package lib\n")
+	writeFile(t, filepath.Join(base, "shared", "impl.go"), "package shared\n")
+	links := map[string]string{
+		"linked":         filepath.Join(base, "shared"), // a package outside the tree
+		"loop":           root,                          // the tree itself
+		"a2":             filepath.Join(root, "a"),      // a directory walked already
+		"broken":         filepath.Join(base, "missing"),
+		"vendor-link":    filepath.Join(root, "vendored"),
+		"linked-file.go": filepath.Join(base, "shared", "impl.go"),
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+
+	walk := func(config *Config) []string {
+		var files []string
+		// Through an unclean path, which the walk cleans
+		dir := root + string(filepath.Separator) + "."
+		if err := walkGoFiles(context.Background(), dir, config, func(path string) {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(files)
+		return files
+	}
+
+	// Symlinked files are read as before; directories are only followed on request
+	if got, want := walk(&Config{}), []string{"a/store.go", "linked-file.go", "vendored/lib.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	got := walk(&Config{FollowSymlinks: true, ExcludeDirs: []string{"vendored/"}})
+	if want := []string{"a/store.go", "linked-file.go", "linked/impl.go", "vendor-link/lib.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("files following symlinks = %v, want %v", got, want)
+	}
+}
+
+func TestPositionsUseForwardSlashes(t *testing.T) {
+	// filepath.Join gives backslashes on Windows and slashes elsewhere; the
+	// formatted position must be the same either way
+	position := token.Position{Filename: filepath.Join("services", "access", "access.go"), Line: 12}
+	if got := formatPosition(position); got != "services/access/access.go:12" {
+		t.Errorf("formatPosition = %q", got)
+	}
+
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "services", "access", "access.go")
+	writeFile(t, ifacePath, "package access\n\ntype Store interface{ Get() }\n\ntype Store interface{ Put() }\n")
+
+	_, conflicts := findInterfaces(ifacePath, &Config{})
+	if len(conflicts) != 1 || len(conflicts[0].Positions) != 2 {
+		t.Fatalf("conflicts = %+v, want one with two positions", conflicts)
+	}
+	for i, line := range []string{":3", ":5"} {
+		want := filepath.ToSlash(ifacePath) + line
+		if got := conflicts[0].Positions[i]; got != want || strings.Contains(got, `\`) {
+			t.Errorf("position %d = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestScanRoots(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "api", "api.go"), "package api\n\ntype Store interface{ Get() string }\n")
+	writeFile(t, filepath.Join(root, "cmd", "server", "mem.go"), "package main\n\ntype MemStore struct{}\n\nfunc (MemStore) Get() string { return \"\" }\n")
+	writeFile(t, filepath.Join(root, "cmd", "README.md"), "not a directory\n")
+	writeFile(t, filepath.Join(root, "internal", "db", "db.go"), "package db\n\ntype DBStore struct{}\n\nfunc (*DBStore) Get() string { return \"\" }\n")
+	writeFile(t, filepath.Join(root, "scripts", "gen.go"), "package main\n\ntype GenStore struct{}\n\nfunc (GenStore) Get() string { return \"\" }\n")
+
+	// internal/db is inside internal, and internal is listed twice
+	config := &Config{
+		GoDirectory:   filepath.Join(root, "internal"),
+		GoDirectories: []string{filepath.Join(root, "cmd", "*"), filepath.Join(root, "internal", "db"), filepath.Join(root, "internal")},
+	}
+	roots := config.scanRoots()
+	want := []string{filepath.Join(root, "internal"), filepath.Join(root, "cmd", "server")}
+	if !reflect.DeepEqual(roots, want) {
+		t.Fatalf("roots = %v, want %v", roots, want)
+	}
+
+	interfaces, _ := findInterfaces(filepath.Join(root, "api", "api.go"), config)
+	results, _, _ := findImplementations(context.Background(), roots, interfaces, config)
+	var impls []string
+	for _, impl := range results[0].Implementations {
+		impls = append(impls, impl.TypeName)
+	}
+	sort.Strings(impls)
+	if got := strings.Join(impls, " "); got != "DBStore MemStore" {
+		t.Errorf("implementations = %s, want DBStore MemStore", got)
+	}
+}
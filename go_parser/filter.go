@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// interfaceFilter decides which discovered interfaces are kept for matching.
+// Patterns are exact interface names, globs such as *Marker, or regular
+// expressions, and always have to match the whole name. An interface that
+// matches both lists is excluded: exclude always wins over include.
+type interfaceFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// Function to compile the include/exclude lists from the config
+func newInterfaceFilter(config *Config) (*interfaceFilter, error) {
+	include, err := compilePatterns(config.IncludeInterfaces)
+	if err != nil {
+		return nil, fmt.Errorf("include_interfaces: %w", err)
+	}
+	exclude, err := compilePatterns(config.ExcludeInterfaces)
+	if err != nil {
+		return nil, fmt.Errorf("exclude_interfaces: %w", err)
+	}
+	return &interfaceFilter{include: include, exclude: exclude}, nil
+}
+
+// A pattern of identifier characters with * or ?, such as *Marker, is a glob
+var globPattern = regexp.MustCompile(`^[\pL\pN_]*[*?][\pL\pN_*?]*$`)
+
+// Function to compile name patterns anchored to the whole name. Globs
+// become the regular expression they stand for: * any run of characters,
+// ? a single one.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		expr := pattern
+		if globPattern.MatchString(pattern) {
+			expr = strings.NewReplacer("*", ".*", "?", ".").Replace(pattern)
+		}
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Function to check whether an interface name passes the filter
+func (f *interfaceFilter) keep(name string) bool {
+	if matchesAny(f.exclude, name) {
+		return false
+	}
+	return len(f.include) == 0 || matchesAny(f.include, name)
+}
+
+func matchesAny(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to remove filtered interfaces, and empty ones when
+// skip_empty_interfaces is set, returning how many were dropped
+func filterInterfaces(interfaces map[interfaceRef]*InterfaceDetails, config *Config) (int, error) {
+	filter, err := newInterfaceFilter(config)
+	if err != nil {
+		return 0, err
+	}
+
+	filtered := 0
+	for ref, iface := range interfaces {
+		if !filter.keep(iface.InterfaceName) || (config.SkipEmptyInterfaces && iface.IsEmpty) {
+			delete(interfaces, ref)
+			filtered++
+		}
+	}
+	return filtered, nil
+}
+
+// Function to keep the interfaces declared or implemented in a changed file,
+// returning them and how many were dropped
+func keepChanged(results []InterfaceDetails, changed map[string]bool) ([]InterfaceDetails, int) {
+	isChanged := func(file string) bool {
+		path, err := filepath.Abs(file)
+		return err == nil && changed[path]
+	}
+
+	var kept []InterfaceDetails
+	for _, result := range results {
+		keep := isChanged(result.pos.Filename)
+		for _, impl := range result.Implementations {
+			keep = keep || isChanged(impl.pos.Filename)
+		}
+		if keep {
+			kept = append(kept, result)
+		}
+	}
+	return kept, len(results) - len(kept)
+}
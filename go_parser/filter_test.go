@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterfaceFilterKeep(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		iface            string
+		want             bool
+	}{
+		{"no lists keeps everything", nil, nil, "Store", true},
+		{"exact include", []string{"Store"}, nil, "Store", true},
+		{"exact include drops others", []string{"Store"}, nil, "Cache", false},
+		{"regex include", []string{".*Handler"}, nil, "HTTPHandler", true},
+		{"include anchored at the end", []string{"Store"}, nil, "StoreFactory", false},
+		{"include anchored at the start", []string{"Store"}, nil, "MemStore", false},
+		{"alternation stays anchored", []string{"Store|Cache"}, nil, "MemCache", false},
+		{"exact exclude", nil, []string{"Store"}, "Store", false},
+		{"regex exclude", nil, []string{"Internal.*"}, "InternalStore", false},
+		{"exclude anchored", nil, []string{"Store"}, "StoreFactory", true},
+		{"exclude wins over include", []string{"Store.*"}, []string{"StoreInternal"}, "StoreInternal", false},
+		{"include still applies next to exclude", []string{"Store.*"}, []string{"StoreInternal"}, "StoreFactory", true},
+		{"glob exclude", nil, []string{"*Marker"}, "ErrorMarker", false},
+		{"glob exclude anchored", nil, []string{"*Marker"}, "MarkerSet", true},
+		{"glob prefix", []string{"Mock*"}, nil, "MockStore", true},
+		{"glob single character", nil, []string{"Store?"}, "Store2", false},
+		{"glob single character needs one", nil, []string{"Store?"}, "Store", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newInterfaceFilter(&Config{IncludeInterfaces: tt.include, ExcludeInterfaces: tt.exclude})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := filter.keep(tt.iface); got != tt.want {
+				t.Errorf("keep(%q) = %v, want %v", tt.iface, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInterfaceFilterInvalidPattern(t *testing.T) {
+	if _, err := newInterfaceFilter(&Config{ExcludeInterfaces: []string{"("}}); err == nil {
+		t.Fatal("expected an error for an invalid pattern")
+	}
+}
+
+func TestFilterInterfaces(t *testing.T) {
+	interfaces := make(map[interfaceRef]*InterfaceDetails)
+	for _, name := range []string{"Store", "StoreInternal", "Cache"} {
+		iface := &InterfaceDetails{InterfaceName: name}
+		interfaces[iface.ref()] = iface
+	}
+	config := &Config{IncludeInterfaces: []string{"Store.*"}, ExcludeInterfaces: []string{".*Internal"}}
+
+	filtered, err := filterInterfaces(interfaces, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filtered != 2 {
+		t.Errorf("filtered = %d, want 2", filtered)
+	}
+	if interfaceNamed(interfaces, "Store") == nil || len(interfaces) != 1 {
+		t.Errorf("remaining interfaces = %v, want only Store", sortedInterfaceNames(interfaces))
+	}
+}
+
+func TestEmptyInterfaces(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api.go")
+	writeFile(t, ifacePath, `package api
+
+type Any interface{}
+
+type Marker interface{}
+
+type Store interface{ Get() string }
+
+type Number interface{ ~int | ~float64 }
+
+type Mem struct{}
+
+func (Mem) Get() string { return "" }
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+	for _, result := range results {
+		wantEmpty := result.InterfaceName == "Any" || result.InterfaceName == "Marker"
+		if result.IsEmpty != wantEmpty {
+			t.Errorf("%s IsEmpty = %v, want %v", result.InterfaceName, result.IsEmpty, wantEmpty)
+		}
+		if wantEmpty && len(result.Implementations) != 0 {
+			t.Errorf("%s lists implementations %+v, want none", result.InterfaceName, result.Implementations)
+		}
+	}
+
+	config.SkipEmptyInterfaces = true
+	interfaces, _ = findInterfaces(ifacePath, config)
+	filtered, err := filterInterfaces(interfaces, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sortedInterfaceNames(interfaces); filtered != 2 || len(got) != 2 || got[0] != "Number" || got[1] != "Store" {
+		t.Errorf("kept %v (filtered %d), want Number and Store", got, filtered)
+	}
+}
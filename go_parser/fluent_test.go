@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Builder-style interfaces return themselves from their methods
+func TestFluentInterfaces(t *testing.T) {
+	fixture := filepath.Join("testdata", "fluent", "fluent.go")
+	config := &Config{GoFilePath: fixture}
+	interfaces, _ := findInterfaces(fixture, config)
+
+	var signatures []string
+	for _, method := range methodsInOrder(interfaceNamed(interfaces, "Builder").Methods) {
+		signatures = append(signatures, method.Signature)
+	}
+	want := []string{
+		"WithLimit(n int) Builder",
+		"Where(cond string, args ...any) Builder",
+		"Clone() (Builder, error)",
+		"Children() []Builder",
+		"Each(fn func(Builder) Builder) Builder",
+		"Build() string",
+		"Parent() Builder",
+	}
+	if !reflect.DeepEqual(signatures, want) {
+		t.Errorf("Builder signatures = %q, want %q", signatures, want)
+	}
+	if got := interfaceNamed(interfaces, "Chain").Methods[0].Signature; got != "Then(fn func(T) T) Chain[T]" {
+		t.Errorf("Chain.Then = %q", got)
+	}
+
+	results, _, _ := findImplementations(context.Background(), []string{filepath.Dir(fixture)}, interfaces, config)
+	implemented := make(map[string]string)
+	for _, result := range results {
+		for _, impl := range result.Implementations {
+			implemented[result.InterfaceName] += impl.TypeName
+		}
+	}
+	if want := map[string]string{"Builder": "Query", "Node": "Query", "Chain": "Box"}; !reflect.DeepEqual(implemented, want) {
+		t.Errorf("implementations = %v, want %v", implemented, want)
+	}
+
+	// An interface doesn't link to itself, while Node links to Builder
+	out := t.TempDir()
+	if err := renderMarkdownFiles(out, results, nil, nil, false, &Config{}); err != nil {
+		t.Fatal(err)
+	}
+	builder, _ := os.ReadFile(filepath.Join(out, "interfaces", "testdata", "fluent", "Builder.md"))
+	node, _ := os.ReadFile(filepath.Join(out, "interfaces", "testdata", "fluent", "Node.md"))
+	if strings.Contains(string(builder), "(Builder.md)") || !strings.Contains(string(node), "- `Parent() Builder` (see [`Builder`](Builder.md))") {
+		t.Errorf("Builder.md:\n%s\nNode.md:\n%s", builder, node)
+	}
+}
+
+// Stubs of fluent interfaces return the qualified interface, and build
+func TestFluentStubsCompile(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+	src, err := os.ReadFile(filepath.Join("testdata", "fluent", "fluent.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	ifacePath := filepath.Join(root, "fluent", "fluent.go")
+	writeFile(t, ifacePath, string(src))
+
+	interfaces, _ := findInterfaces(ifacePath, &Config{})
+	var results []InterfaceDetails
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		results = append(results, *interfaces[ref])
+	}
+	if err := generateStubs(filepath.Join(root, "out"), results); err != nil {
+		t.Fatal(err)
+	}
+	stub, _ := os.ReadFile(filepath.Join(root, "out", "builder_stub.go"))
+	for _, want := range []string{"WithLimit(n int) fluent.Builder", "Each(fn func(fluent.Builder) fluent.Builder) fluent.Builder", "fluent.Node"} {
+		if !strings.Contains(string(stub), want) {
+			t.Errorf("builder stub lacks %q:\n%s", want, stub)
+		}
+	}
+
+	writeFile(t, filepath.Join(root, "check", "check.go"), `package check
+
+import (
+	"example.com/app/fluent"
+	"example.com/app/out"
+)
+
+var (
+	_ fluent.Builder    = (*out.BuilderStub)(nil)
+	_ fluent.Chain[int] = (*out.ChainStub[int])(nil)
+)
+`)
+	cmd := exec.Command(goTool, "build", "./...")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+}
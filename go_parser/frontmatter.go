@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// frontMatterData is what the markdown_frontmatter templates are executed
+// with. For a page about several interfaces, the index or the single file,
+// InterfaceName and Package are empty and the counts are totals.
+type frontMatterData struct {
+	InterfaceName   string
+	Package         string
+	Implementations int
+	Methods         int
+	// Modification time of the source file declaring the interface (the
+	// newest of them for several), RFC 3339 in UTC; empty when unknown
+	LastModified string
+}
+
+// Function to gather the front-matter data of one interface's page
+func interfaceFrontMatter(result InterfaceDetails) frontMatterData {
+	return frontMatterData{
+		InterfaceName:   result.InterfaceName,
+		Package:         result.Package,
+		Implementations: implementationCount(result),
+		Methods:         len(result.Methods),
+		LastModified:    lastModified([]InterfaceDetails{result}),
+	}
+}
+
+// Function to gather the front-matter data of a page listing all of results
+func pageFrontMatter(results []InterfaceDetails) frontMatterData {
+	data := frontMatterData{LastModified: lastModified(results)}
+	for _, result := range results {
+		data.Implementations += implementationCount(result)
+		data.Methods += len(result.Methods)
+	}
+	return data
+}
+
+// Function to find the newest modification time of the files declaring
+// results, formatted for front-matter
+func lastModified(results []InterfaceDetails) string {
+	var newest time.Time
+	for _, result := range results {
+		if result.pos.Filename == "" {
+			continue
+		}
+		if info, err := os.Stat(result.pos.Filename); err == nil && info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	if newest.IsZero() {
+		return ""
+	}
+	return newest.UTC().Format(time.RFC3339)
+}
+
+// Function to render the markdown_frontmatter values as a YAML front-matter
+// block for a page, or "" when none are configured. Strings are templates,
+// also inside lists and maps; a template producing a whole number is
+// written as a number, so weight: "{{ .Implementations }}" sorts. Keys are
+// written in sorted order, and yaml quotes whatever needs it, such as a
+// title with a colon.
+func renderFrontMatter(values map[string]interface{}, data frontMatterData) (string, error) {
+	if len(values) == 0 {
+		return "", nil
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var block yaml.MapSlice
+	for _, key := range keys {
+		value, err := expandFrontMatter(key, values[key], data)
+		if err != nil {
+			return "", fmt.Errorf("markdown_frontmatter %s: %w", key, err)
+		}
+		block = append(block, yaml.MapItem{Key: key, Value: value})
+	}
+	out, err := yaml.Marshal(block)
+	if err != nil {
+		return "", err
+	}
+	return "---\n" + string(out) + "---\n\n", nil
+}
+
+// Function to execute the templates in a front-matter value
+func expandFrontMatter(name string, value interface{}, data frontMatterData) (interface{}, error) {
+	switch value := value.(type) {
+	case string:
+		tmpl, err := template.New(name).Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		if err := tmpl.Execute(&b, data); err != nil {
+			return nil, err
+		}
+		if n, err := strconv.Atoi(b.String()); err == nil && strconv.Itoa(n) == b.String() && value != b.String() {
+			return n, nil
+		}
+		return b.String(), nil
+	case []interface{}:
+		items := make([]interface{}, len(value))
+		for i, item := range value {
+			expanded, err := expandFrontMatter(name, item, data)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = expanded
+		}
+		return items, nil
+	case map[interface{}]interface{}:
+		items := make(map[interface{}]interface{}, len(value))
+		for key, item := range value {
+			expanded, err := expandFrontMatter(fmt.Sprint(key), item, data)
+			if err != nil {
+				return nil, err
+			}
+			items[key] = expanded
+		}
+		return items, nil
+	case map[string]interface{}:
+		items := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			expanded, err := expandFrontMatter(key, item, data)
+			if err != nil {
+				return nil, err
+			}
+			items[key] = expanded
+		}
+		return items, nil
+	}
+	return value, nil
+}
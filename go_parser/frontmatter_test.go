@@ -0,0 +1,94 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRenderFrontMatter(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "store.go")
+	writeFile(t, source, "package app\n")
+	modified := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := os.Chtimes(source, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	result := testResults()[0]
+	result.Package = "app"
+	result.pos = token.Position{Filename: source}
+
+	var values map[string]interface{}
+	if err := yaml.Unmarshal([]byte(`
+title: '{{ .InterfaceName }}: the "{{ .Package }}" store'
+weight: "{{ .Implementations }}"
+version: "10"
+draft: false
+tags: ["{{ .Package }}", interfaces]
+params: {lastmod: "{{ .LastModified }}"}
+`), &values); err != nil {
+		t.Fatal(err)
+	}
+	block, err := renderFrontMatter(values, interfaceFrontMatter(result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(block, "---\ndraft: false\nparams:\n") || !strings.HasSuffix(block, "\n---\n\n") {
+		t.Errorf("block = %q, want sorted keys between --- lines", block)
+	}
+
+	// The block must read back as the values, whatever the title holds
+	var got map[string]interface{}
+	if err := yaml.Unmarshal([]byte(strings.Trim(block, "-\n")), &got); err != nil {
+		t.Fatalf("front-matter isn't valid YAML: %v\n%s", err, block)
+	}
+	want := map[string]interface{}{
+		"title":   `Store: the "app" store`,
+		"weight":  1,
+		"version": "10",
+		"draft":   false,
+		"tags":    []interface{}{"app", "interfaces"},
+		"params":  map[interface{}]interface{}{"lastmod": "2024-03-04T05:06:07Z"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("front-matter = %#v, want %#v", got, want)
+	}
+
+	if block, err := renderFrontMatter(nil, interfaceFrontMatter(result)); block != "" || err != nil {
+		t.Errorf("without values = %q, %v, want nothing", block, err)
+	}
+}
+
+func TestFrontMatterValidated(t *testing.T) {
+	config := Config{MarkdownFrontmatter: map[string]interface{}{"title": "{{ .Name }}"}}
+	if err := config.validate(); err == nil || !strings.Contains(err.Error(), "markdown_frontmatter title") {
+		t.Errorf("err = %v, want the unknown field reported", err)
+	}
+}
+
+func TestRenderMarkdownFilesFrontMatter(t *testing.T) {
+	out := t.TempDir()
+	frontMatter := map[string]interface{}{"title": `{{ or .InterfaceName "Interfaces" }}`}
+	results := testResults()
+	results[0].Package = "app"
+	if err := renderMarkdownFiles(out, results, nil, nil, false, &Config{MarkdownFrontmatter: frontMatter}); err != nil {
+		t.Fatal(err)
+	}
+	for file, want := range map[string]string{
+		filepath.Join("interfaces", "app", "Store.md"): "---\ntitle: Store\n---\n\n# Store\n",
+		"index.md": "---\ntitle: Interfaces\n---\n\n# Interfaces\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(out, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), want) {
+			t.Errorf("%s starts with %q, want %q", file, data, want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Function to list the files changed since a git ref, as absolute paths,
+// including new files not yet added to git (ignored files aside). The
+// repository is the one containing dir.
+func changedFilesSince(dir, ref string) (map[string]bool, error) {
+	root, err := gitOutput(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	names, err := gitOutput(dir, "diff", "--name-only", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitOutput(dir, "ls-files", "--others", "--exclude-standard", "--full-name")
+	if err != nil {
+		return nil, err
+	}
+
+	changed := make(map[string]bool)
+	for _, name := range strings.Split(names+"\n"+untracked, "\n") {
+		if name != "" {
+			changed[filepath.Join(root, filepath.FromSlash(name))] = true
+		}
+	}
+	return changed, nil
+}
+
+// Function to run a git command in dir and return its trimmed output
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
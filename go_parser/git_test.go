@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestSinceKeepsInterfacesWithChangedImplementations(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	root := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = root
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	ifacePath := filepath.Join(root, "svc", "svc.go")
+	writeFile(t, ifacePath, "package svc\n\ntype Store interface{ Get() string }\n\ntype Cache interface{ Put() }\n")
+	writeFile(t, filepath.Join(root, "svc", "mem.go"), "package svc\n\ntype MemStore struct{}\n\nfunc (MemStore) Get() string { return \"\" }\n")
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "initial")
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: filepath.Join(root, "svc")}
+	analyzeSince := func() []InterfaceDetails {
+		t.Helper()
+		interfaces, _ := findInterfaces(ifacePath, config)
+		results, _, _ := findImplementations(context.Background(), []string{config.GoDirectory}, interfaces, config)
+		changed, err := changedFilesSince(root, "HEAD")
+		if err != nil {
+			t.Fatal(err)
+		}
+		kept, _ := keepChanged(results, changed)
+		return kept
+	}
+
+	if kept := analyzeSince(); len(kept) != 0 {
+		t.Fatalf("nothing changed but kept %d interfaces", len(kept))
+	}
+
+	// A modified implementation brings back only the interface it implements
+	writeFile(t, filepath.Join(root, "svc", "mem.go"), "package svc\n\ntype MemStore struct{ n int }\n\nfunc (MemStore) Get() string { return \"\" }\n")
+	if kept := analyzeSince(); len(kept) != 1 || kept[0].InterfaceName != "Store" {
+		t.Fatalf("kept %+v, want Store", kept)
+	}
+
+	// So does a new, untracked implementation
+	git("checkout", "--", ".")
+	writeFile(t, filepath.Join(root, "svc", "cache.go"), "package svc\n\ntype MemCache struct{}\n\nfunc (MemCache) Put() {}\n")
+	if kept := analyzeSince(); len(kept) != 1 || kept[0].InterfaceName != "Cache" {
+		t.Fatalf("kept %+v, want Cache", kept)
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PackageGraph is the dependency graph the interfaces make between
+// packages: a package implements the interfaces of the packages it has
+// implementing types for, and consumes those of the packages whose
+// interfaces it takes as a parameter, result, field or variable. Packages
+// are named by import path, or by directory outside a module. Edges within
+// a package are left out.
+type PackageGraph struct {
+	// Packages declaring, implementing or consuming an interface
+	Packages []string `json:"packages"`
+	// Adjacency lists, from a package to the packages it depends on
+	Implements map[string][]string `json:"implements,omitempty"`
+	Consumes   map[string][]string `json:"consumes,omitempty"`
+	// Packages depending on each other through these edges, one closed
+	// path per cycle found, e.g. ["adapter", "app", "adapter"]
+	Cycles [][]string `json:"cycles,omitempty"`
+}
+
+// Function to build the package graph of the results; nil without results
+func buildPackageGraph(results []InterfaceDetails) *PackageGraph {
+	if len(results) == 0 {
+		return nil
+	}
+
+	// Import paths are looked up once per directory
+	paths := make(map[string]string)
+	packageOf := func(pos token.Position, name string) string {
+		if pos.Filename == "" {
+			return name
+		}
+		dir := filepath.Dir(pos.Filename)
+		if path, ok := paths[dir]; ok {
+			return path
+		}
+		path := packageID(dir, name)
+		paths[dir] = path
+		return path
+	}
+
+	packages := make(map[string]bool)
+	implements := make(map[string]map[string]bool)
+	consumes := make(map[string]map[string]bool)
+	addEdge := func(edges map[string]map[string]bool, from, to string) {
+		packages[from] = true
+		if from == to {
+			return
+		}
+		if edges[from] == nil {
+			edges[from] = make(map[string]bool)
+		}
+		edges[from][to] = true
+	}
+	for _, result := range results {
+		declared := packageOf(result.pos, result.Package)
+		packages[declared] = true
+		for _, impl := range result.Implementations {
+			addEdge(implements, packageOf(impl.pos, impl.Package), declared)
+		}
+		for _, usage := range result.Usages {
+			addEdge(consumes, packageOf(usage.pos, usage.Package), declared)
+		}
+	}
+
+	graph := &PackageGraph{
+		Packages:   sortedKeys(packages),
+		Implements: adjacencyLists(implements),
+		Consumes:   adjacencyLists(consumes),
+	}
+	graph.Cycles = packageCycles(graph)
+	return graph
+}
+
+// Function to name the package in a directory by its import path, or by
+// the directory outside a module
+func packageID(dir, name string) string {
+	if path := packageImportPath(dir); path != "" {
+		return path
+	}
+	return filepath.ToSlash(packageRelPath(dir, name))
+}
+
+// Function to turn edge sets into sorted adjacency lists; nil without edges
+func adjacencyLists(edges map[string]map[string]bool) map[string][]string {
+	if len(edges) == 0 {
+		return nil
+	}
+	lists := make(map[string][]string, len(edges))
+	for from, targets := range edges {
+		lists[from] = sortedKeys(targets)
+	}
+	return lists
+}
+
+// Function to get the packages each package depends on through either
+// kind of edge, sorted
+func (g *PackageGraph) dependencies() map[string][]string {
+	edges := make(map[string]map[string]bool)
+	for _, lists := range []map[string][]string{g.Implements, g.Consumes} {
+		for from, targets := range lists {
+			if edges[from] == nil {
+				edges[from] = make(map[string]bool)
+			}
+			for _, to := range targets {
+				edges[from][to] = true
+			}
+		}
+	}
+	return adjacencyLists(edges)
+}
+
+// Function to find the cycles of the graph: for each group of packages
+// that all reach each other (a strongly connected component, found with
+// Tarjan's algorithm), the shortest closed path through its first package.
+// Every package in a cycle is in one of the paths, though not every cycle
+// of a group is listed.
+func packageCycles(g *PackageGraph) [][]string {
+	deps := g.dependencies()
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(pkg string)
+	visit = func(pkg string) {
+		index[pkg] = len(index)
+		lowlink[pkg] = index[pkg]
+		stack = append(stack, pkg)
+		onStack[pkg] = true
+		for _, dep := range deps[pkg] {
+			if _, seen := index[dep]; !seen {
+				visit(dep)
+				lowlink[pkg] = min(lowlink[pkg], lowlink[dep])
+			} else if onStack[dep] {
+				lowlink[pkg] = min(lowlink[pkg], index[dep])
+			}
+		}
+		if lowlink[pkg] != index[pkg] {
+			return
+		}
+		var component []string
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			component = append(component, top)
+			if top == pkg {
+				break
+			}
+		}
+		if len(component) > 1 {
+			sort.Strings(component)
+			components = append(components, component)
+		}
+	}
+	for _, pkg := range g.Packages {
+		if _, seen := index[pkg]; !seen {
+			visit(pkg)
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i][0] < components[j][0] })
+	var cycles [][]string
+	for _, component := range components {
+		cycles = append(cycles, shortestCycle(deps, component))
+	}
+	return cycles
+}
+
+// Function to find the shortest closed path from the first package of a
+// strongly connected component back to it, staying within the component
+func shortestCycle(deps map[string][]string, component []string) []string {
+	inComponent := make(map[string]bool)
+	for _, pkg := range component {
+		inComponent[pkg] = true
+	}
+	start := component[0]
+	previous := make(map[string]string)
+	queue := []string{start}
+	for len(queue) > 0 {
+		pkg := queue[0]
+		queue = queue[1:]
+		for _, dep := range deps[pkg] {
+			if dep == start {
+				path := []string{start}
+				for at := pkg; at != start; at = previous[at] {
+					path = append(path, at)
+				}
+				// The path was collected backwards from the last package
+				for i, j := 1, len(path)-1; i < j; i, j = i+1, j-1 {
+					path[i], path[j] = path[j], path[i]
+				}
+				return append(path, start)
+			}
+			if _, seen := previous[dep]; seen || !inComponent[dep] {
+				continue
+			}
+			previous[dep] = pkg
+			queue = append(queue, dep)
+		}
+	}
+	return nil
+}
+
+// Function to tell whether an edge is part of one of the listed cycles
+func (g *PackageGraph) inCycle(from, to string) bool {
+	for _, cycle := range g.Cycles {
+		for i := 0; i+1 < len(cycle); i++ {
+			if cycle[i] == from && cycle[i+1] == to {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Function to write the package graph in Graphviz DOT. Implements edges are
+// solid and consumes edges dashed, each labelled with its kind; edges of a
+// listed cycle are red.
+func writePackageDot(w io.Writer, graph *PackageGraph) error {
+	var b strings.Builder
+	b.WriteString("digraph packages {\n\trankdir=LR;\n\tnode [fontname=\"Helvetica\", shape=box];\n\n")
+	if graph != nil {
+		for _, pkg := range graph.Packages {
+			fmt.Fprintf(&b, "\t%s;\n", strconv.Quote(pkg))
+		}
+		b.WriteString("\n")
+		for _, kind := range []struct {
+			label string
+			edges map[string][]string
+		}{{"implements", graph.Implements}, {"consumes", graph.Consumes}} {
+			for _, from := range sortedKeys(kind.edges) {
+				for _, to := range kind.edges[from] {
+					attrs := []string{fmt.Sprintf("label=%q", kind.label)}
+					if kind.label == "consumes" {
+						attrs = append(attrs, "style=dashed")
+					}
+					if graph.inCycle(from, to) {
+						attrs = append(attrs, "color=red")
+					}
+					fmt.Fprintf(&b, "\t%s -> %s [%s];\n", strconv.Quote(from), strconv.Quote(to), strings.Join(attrs, ", "))
+				}
+			}
+		}
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPackageGraph(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	portPath := filepath.Join(root, "port", "port.go")
+	writeFile(t, portPath, `package port
+
+import "example.com/app/adapter"
+
+type Repo interface {
+	Find(id string) (string, error)
+}
+
+// The port importing the adapter back is what makes the cycle
+func Open(clock adapter.Clock) Repo { return nil }
+`)
+	adapterPath := filepath.Join(root, "adapter", "adapter.go")
+	writeFile(t, adapterPath, `package adapter
+
+type Clock interface {
+	Now() int64
+}
+
+type SQLRepo struct{}
+
+func (r SQLRepo) Find(id string) (string, error) { return "", nil }
+`)
+	writeFile(t, filepath.Join(root, "app", "app.go"), `package app
+
+import "example.com/app/port"
+
+type Service struct {
+	repo port.Repo
+}
+
+type fakeRepo struct{}
+
+func (f fakeRepo) Find(id string) (string, error) { return "", nil }
+`)
+
+	report, err := Analyze(Config{GoFilePath: portPath, InterfaceSources: []string{adapterPath}, GoDirectory: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	graph := report.Graph
+	if graph == nil {
+		t.Fatal("report has no graph")
+	}
+
+	want := &PackageGraph{
+		Packages: []string{"example.com/app/adapter", "example.com/app/app", "example.com/app/port"},
+		Implements: map[string][]string{
+			"example.com/app/adapter": {"example.com/app/port"},
+			"example.com/app/app":     {"example.com/app/port"},
+		},
+		Consumes: map[string][]string{
+			"example.com/app/app":  {"example.com/app/port"},
+			"example.com/app/port": {"example.com/app/adapter"},
+		},
+		Cycles: [][]string{{"example.com/app/adapter", "example.com/app/port", "example.com/app/adapter"}},
+	}
+	if !reflect.DeepEqual(graph, want) {
+		t.Errorf("graph = %+v\nwant %+v", graph, want)
+	}
+
+	var b strings.Builder
+	if err := writePackageDot(&b, graph); err != nil {
+		t.Fatal(err)
+	}
+	dot := b.String()
+	for _, want := range []string{
+		"digraph packages {",
+		`"example.com/app/app";`,
+		`"example.com/app/adapter" -> "example.com/app/port" [label="implements", color=red];`,
+		`"example.com/app/app" -> "example.com/app/port" [label="implements"];`,
+		`"example.com/app/port" -> "example.com/app/adapter" [label="consumes", style=dashed, color=red];`,
+		`"example.com/app/app" -> "example.com/app/port" [label="consumes", style=dashed];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT lacks %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestPackageCycles(t *testing.T) {
+	graph := &PackageGraph{
+		Packages: []string{"a", "b", "c", "d", "e"},
+		Implements: map[string][]string{
+			"a": {"b"},
+			"b": {"c"},
+			"d": {"e"},
+		},
+		Consumes: map[string][]string{
+			"b": {"a"},
+			"c": {"a"},
+			"e": {"d"},
+		},
+	}
+	want := [][]string{{"a", "b", "a"}, {"d", "e", "d"}}
+	if got := packageCycles(graph); !reflect.DeepEqual(got, want) {
+		t.Errorf("cycles = %v, want %v", got, want)
+	}
+
+	delete(graph.Consumes, "b")
+	delete(graph.Consumes, "e")
+	if got := packageCycles(graph); !reflect.DeepEqual(got, [][]string{{"a", "b", "c", "a"}}) {
+		t.Errorf("cycles = %v, want the longer path once the shortcut is gone", got)
+	}
+
+	if buildPackageGraph(nil) != nil {
+		t.Error("want no graph without results")
+	}
+}
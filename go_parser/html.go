@@ -0,0 +1,109 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// FormatHTML is a self-contained HTML page of the interfaces, for readers
+// without the tool or a markdown viewer
+const FormatHTML = "html"
+
+// Template of the HTML page. Its styles and search script are inline, so the
+// page can be mailed or opened from disk.
+//
+//go:embed templates/report.html
+var htmlTemplateSource string
+
+// Catalog text is markdown; the page shows it without the markup
+var markdownMarkup = strings.NewReplacer("**", "", "`", "")
+
+// Function to write a report as an HTML page: one collapsible section per
+// interface with its highlighted method signatures, implementations and
+// usages, and a search box filtering them
+func writeHTML(w io.Writer, report *Report, config *Config) error {
+	msgs := docMessages(config)
+	page, err := template.New("report").Funcs(template.FuncMap{
+		"text": func(key string, args ...interface{}) string {
+			text := strings.Trim(markdownMarkup.Replace(msgs.text(key)), "_")
+			if len(args) == 0 {
+				return text
+			}
+			return fmt.Sprintf(text, args...)
+		},
+		"signature":   highlightSignature,
+		"displayName": Implementation.displayName,
+		"position":    func(iface InterfaceDetails) string { return formatPosition(iface.pos) },
+		"pointerOnly": func(impl Implementation) bool { return impl.ReceiverSatisfaction == SatisfiedByPointer },
+		"testDouble":  func(impl Implementation) bool { return impl.Kind == KindTestDouble },
+	}).Parse(htmlTemplateSource)
+	if err != nil {
+		return err
+	}
+
+	lang := defaultDocLanguage
+	if config != nil && config.DocLanguage != "" {
+		lang = config.DocLanguage
+	}
+	return page.Execute(w, struct {
+		Lang       string
+		Partial    bool
+		Interfaces []InterfaceDetails
+	}{lang, report.Partial, report.Interfaces})
+}
+
+// Function to mark up a method signature, e.g. "Get(id string) error", for
+// the HTML page: the method name, keywords and predeclared types get a class
+// each, the rest is escaped as is
+func highlightSignature(signature string) template.HTML {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(signature))
+	var s scanner.Scanner
+	s.Init(file, []byte(signature), nil, 0)
+
+	var b strings.Builder
+	last := 0
+	for first := true; ; first = false {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		offset := file.Offset(pos)
+		if offset < last || tok == token.SEMICOLON && lit == "\n" {
+			// The semicolon inserted at the end
+			continue
+		}
+		text := signature[offset:]
+		if lit != "" {
+			text = text[:len(lit)]
+		} else {
+			text = text[:len(tok.String())]
+		}
+		b.WriteString(template.HTMLEscapeString(signature[last:offset]))
+		class := ""
+		switch {
+		case first && tok == token.IDENT:
+			class = "fn"
+		case tok.IsKeyword():
+			class = "kw"
+		case tok == token.IDENT:
+			if _, ok := types.Universe.Lookup(lit).(*types.TypeName); ok {
+				class = "builtin"
+			}
+		}
+		if class != "" {
+			fmt.Fprintf(&b, `<span class="%s">%s</span>`, class, template.HTMLEscapeString(text))
+		} else {
+			b.WriteString(template.HTMLEscapeString(text))
+		}
+		last = offset + len(text)
+	}
+	b.WriteString(template.HTMLEscapeString(signature[last:]))
+	return template.HTML(b.String())
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHighlightSignature(t *testing.T) {
+	got := string(highlightSignature("Get(ctx chan<- T, m map[string]any) (*T, error)"))
+	want := `<span class="fn">Get</span>(ctx <span class="kw">chan</span>&lt;- T, m <span class="kw">map</span>[<span class="builtin">string</span>]<span class="builtin">any</span>) (*T, <span class="builtin">error</span>)`
+	if got != want {
+		t.Errorf("highlightSignature = %s, want %s", got, want)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	results := testResults()
+	results[0].Package = "api"
+	results[0].Doc = "Store <persists> items."
+	results[0].Implementations[0].Kind = KindTestDouble
+	report := &Report{ReportResults: ReportResults{Interfaces: results, Partial: true}}
+
+	var buf bytes.Buffer
+	if err := writeHTML(&buf, report, &Config{DocLanguage: "ja"}); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		`<html lang="ja">`,
+		`placeholder="検索"`,
+		`<details class="interface" id="api.Store">`,
+		`<p class="doc">Store &lt;persists&gt; items.</p>`,
+		`<span class="fn">Get</span>`,
+		`<span class="tag">テストダブル</span>`,
+		`<p class="partial">`,
+		`addEventListener("input"`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page lacks %s:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "**") || strings.Contains(page, "`") {
+		t.Errorf("page has markdown markup:\n%s", page)
+	}
+}
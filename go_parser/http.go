@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+	// Wait before the first retry; doubled for every further attempt
+	defaultRetryBackoff = time.Second
+	// Largest response body read unless max_response_bytes is set; a
+	// completion is far smaller
+	defaultMaxResponseBytes = 8 << 20
+	// Longest a request may take unless request_timeout_seconds is set,
+	// long enough for a slow streamed completion
+	defaultRequestTimeout = 10 * time.Minute
+)
+
+// httpAPI posts JSON to provider endpoints, retrying rate limits and
+// server errors with exponential backoff
+type httpAPI struct {
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+	metrics    *metricsRecorder
+	apiKey     string // masked in errors
+	// Bounds of each request, so a misbehaving endpoint can neither stream
+	// forever nor exhaust memory
+	maxResponseBytes int64
+	timeout          time.Duration
+}
+
+// Function to build the HTTP API helper from the config. Proxies come from
+// HTTP_PROXY/HTTPS_PROXY/NO_PROXY unless proxy_url overrides them.
+func newHTTPAPI(config *Config) (*httpAPI, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if config.ProxyURL != "" {
+		proxyURL, err := url.Parse(config.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy_url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	api := &httpAPI{
+		client:     &http.Client{Transport: transport},
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultRetryBackoff,
+		metrics:    config.Metrics,
+		apiKey:     config.APIKey,
+
+		maxResponseBytes: defaultMaxResponseBytes,
+		timeout:          defaultRequestTimeout,
+	}
+	if config.MaxRetries != nil {
+		api.maxRetries = *config.MaxRetries
+	}
+	if config.MaxResponseBytes > 0 {
+		api.maxResponseBytes = int64(config.MaxResponseBytes)
+	}
+	if config.RequestTimeoutSeconds > 0 {
+		api.timeout = time.Duration(config.RequestTimeoutSeconds) * time.Second
+	}
+	return api, nil
+}
+
+// Function to POST a JSON payload, returning the response if the status is OK.
+// 429 and 5xx responses are retried until ctx is done. The caller must close
+// the response body.
+func (h *httpAPI) post(ctx context.Context, endpoint string, headers map[string]string, payload interface{}) (*http.Response, error) {
+	// Convert the payload to JSON
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling request: %w", err)
+	}
+	return h.do(ctx, "POST", endpoint, headers, data)
+}
+
+// Function to GET an endpoint, with the same retries as post
+func (h *httpAPI) get(ctx context.Context, endpoint string, headers map[string]string) (*http.Response, error) {
+	return h.do(ctx, "GET", endpoint, headers, nil)
+}
+
+// Function to send a request with an optional JSON body, retrying 429 and
+// 5xx responses. The API key is masked in the error of a failed request,
+// as some providers echo it back. Each attempt, reading the body of the
+// returned response included, is bounded by the timeout and the body by
+// maxResponseBytes.
+func (h *httpAPI) do(ctx context.Context, method, endpoint string, headers map[string]string, data []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+		reqCtx, cancel := context.WithTimeout(ctx, h.timeout)
+		req, err := http.NewRequestWithContext(reqCtx, method, endpoint, body)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("creating request: %w", err)
+		}
+		if data != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		// Execute the HTTP request
+		start := time.Now()
+		resp, err := h.client.Do(req)
+		if err != nil {
+			cancel()
+			h.metrics.llmRequest("error", time.Since(start))
+			if timedOut(ctx, reqCtx) {
+				return nil, fmt.Errorf("sending request: no response within %s (request_timeout_seconds)", h.timeout)
+			}
+			// The key can only be in the URL if base_url puts it there
+			if h.apiKey != "" && strings.Contains(err.Error(), h.apiKey) {
+				return nil, fmt.Errorf("sending request: %s", maskSecret(err.Error(), h.apiKey))
+			}
+			return nil, fmt.Errorf("sending request: %w", err)
+		}
+		h.metrics.llmRequest(strconv.Itoa(resp.StatusCode), time.Since(start))
+		resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: h.maxResponseBytes, api: h, ctx: ctx, reqCtx: reqCtx, cancel: cancel}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+
+		// Check the response status
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		statusErr := &ProviderError{StatusCode: resp.StatusCode, Body: maskSecret(strings.TrimSpace(string(respBody)), h.apiKey)}
+
+		if !retryable(resp.StatusCode) || attempt >= h.maxRetries {
+			return nil, statusErr
+		}
+		wait := h.retryDelay(attempt, resp.Header.Get("Retry-After"))
+		log.Printf("Request failed with status %d, retrying in %s (attempt %d of %d)", resp.StatusCode, wait, attempt+1, h.maxRetries)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Function to POST a JSON payload and decode the JSON response into out
+func (h *httpAPI) postJSON(ctx context.Context, endpoint string, headers map[string]string, payload, out interface{}) error {
+	resp, err := h.post(ctx, endpoint, headers, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+// limitedBody is the body of a provider response: reading it fails once
+// more than maxResponseBytes arrive or the request times out, and closing
+// it releases the request's timeout
+type limitedBody struct {
+	io.ReadCloser
+	remaining   int64
+	api         *httpAPI
+	ctx, reqCtx context.Context
+	cancel      context.CancelFunc
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// Only an error if there is more to come
+		var probe [1]byte
+		n, err := b.ReadCloser.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("response body is larger than %d bytes (max_response_bytes)", b.api.maxResponseBytes)
+		}
+		return 0, b.readError(err)
+	}
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	return n, b.readError(err)
+}
+
+// Function to tell a read cut off by the request timeout from one
+// cancelled by the caller
+func (b *limitedBody) readError(err error) error {
+	if err != nil && err != io.EOF && timedOut(b.ctx, b.reqCtx) {
+		return fmt.Errorf("reading response: not finished within %s (request_timeout_seconds)", b.api.timeout)
+	}
+	return err
+}
+
+func (b *limitedBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// Function to check whether a request failed on its own timeout rather
+// than because its caller's context ended
+func timedOut(ctx, reqCtx context.Context) bool {
+	return ctx.Err() == nil && errors.Is(reqCtx.Err(), context.DeadlineExceeded)
+}
+
+// Function to decide whether a failed request is worth retrying
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// Function to compute the wait before a retry, preferring the server's
+// Retry-After (in seconds) over exponential backoff
+func (h *httpAPI) retryDelay(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return h.backoff << attempt
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Name of the ignore files read during the walk, in the scan root and any
+// directory below it
+const ignoreFileName = ".godocignore"
+
+// An ignore pattern, relative to the directory of the file declaring it
+type ignoreRule struct {
+	base    string // slash-separated directory relative to the scan root, "" for the root
+	pattern *regexp.Regexp
+	negate  bool
+	dirOnly bool
+	source  string // e.g. "services/.godocignore:3" or "exclude_dirs"
+	text    string
+}
+
+// ignoreMatcher decides which paths of a walk are ignored, with gitignore
+// semantics: the last matching rule wins, "!" re-includes, a trailing "/"
+// only matches directories, a pattern with a "/" other than a trailing one
+// is anchored to its file's directory, and "**" spans directories. As in
+// git, nothing inside an ignored directory can be re-included.
+type ignoreMatcher struct {
+	rules []ignoreRule
+	// Rules that ignored something, for the warning when nothing is left
+	used map[string]bool
+}
+
+// Function to build a matcher from the exclude_dirs patterns, which apply
+// to the scan root with the lowest precedence
+func newIgnoreMatcher(patterns []string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{used: make(map[string]bool)}
+	for _, pattern := range patterns {
+		if err := m.add("", pattern, "exclude_dirs"); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Function to add the rules of the ignore file in dir, if there is one;
+// rel is dir relative to the scan root
+func (m *ignoreMatcher) load(dir, rel string) error {
+	file, err := os.Open(filepath.Join(dir, ignoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	base := filepath.ToSlash(rel)
+	if base == "." {
+		base = ""
+	}
+	source := filepath.ToSlash(filepath.Join(rel, ignoreFileName))
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if err := m.add(base, scanner.Text(), fmt.Sprintf("%s:%d", source, line)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Function to parse one pattern line into a rule; blank lines and comments
+// add nothing
+func (m *ignoreMatcher) add(base, line, source string) error {
+	text := strings.TrimRight(line, " \t\r")
+	if text == "" || strings.HasPrefix(text, "#") {
+		return nil
+	}
+	rule := ignoreRule{base: base, source: source, text: text}
+	pattern := text
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	} else if strings.HasPrefix(pattern, `\!`) || strings.HasPrefix(pattern, `\#`) {
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	expr := ignorePatternRegexp(pattern)
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return fmt.Errorf("%s: invalid pattern %q: %w", source, text, err)
+	}
+	rule.pattern = re
+	m.rules = append(m.rules, rule)
+	return nil
+}
+
+// Function to translate a gitignore glob into a regular expression
+func ignorePatternRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		switch rest := pattern[i:]; {
+		case strings.HasPrefix(rest, "**/"):
+			// Zero or more directories
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case rest == "**":
+			b.WriteString(".*")
+			i++
+		case rest[0] == '*':
+			b.WriteString("[^/]*")
+		case rest[0] == '?':
+			b.WriteString("[^/]")
+		case rest[0] == '[':
+			end := strings.IndexByte(rest[1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := rest[1 : end+1]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case rest[0] == '\\' && len(rest) > 1:
+			b.WriteString(regexp.QuoteMeta(rest[1:2]))
+			i++
+		default:
+			b.WriteString(regexp.QuoteMeta(rest[:1]))
+		}
+	}
+	return b.String()
+}
+
+// Function to check whether a path, slash-separated and relative to the
+// scan root, is ignored
+func (m *ignoreMatcher) ignored(rel string, isDir bool) bool {
+	var last *ignoreRule
+	for i := range m.rules {
+		rule := &m.rules[i]
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		sub := rel
+		if rule.base != "" {
+			var ok bool
+			if sub, ok = strings.CutPrefix(rel, rule.base+"/"); !ok {
+				continue
+			}
+		}
+		if rule.pattern.MatchString(sub) {
+			last = rule
+		}
+	}
+	if last == nil || last.negate {
+		return false
+	}
+	m.used[fmt.Sprintf("%s pattern %q", last.source, last.text)] = true
+	return true
+}
+
+// Function to describe the rules that ignored something, sorted
+func (m *ignoreMatcher) usedRules() []string {
+	var rules []string
+	for rule := range m.used {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	return rules
+}
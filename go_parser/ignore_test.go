@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Function to list the files walkGoFiles visits under root, relative to it
+func walkedFiles(t *testing.T, root string, config *Config) []string {
+	t.Helper()
+	var files []string
+	if err := walkGoFiles(context.Background(), root, config, func(path string) {
+		rel, _ := filepath.Rel(root, path)
+		files = append(files, filepath.ToSlash(rel))
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestIgnorePatterns(t *testing.T) {
+	m, err := newIgnoreMatcher(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"# comment", "", "*.pb.go", "/build", "mocks/", "docs/**/*.go", "**/testdata", "!keep.pb.go", `\#hash.go`} {
+		if err := m.add("", line, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"api.pb.go", false, true},
+		{"svc/api.pb.go", false, true},
+		{"svc/keep.pb.go", false, false}, // negated later
+		{"build", true, true},
+		{"svc/build", true, false}, // anchored to the root
+		{"mocks", true, true},
+		{"svc/mocks", true, true},
+		{"mocks", false, false}, // directories only
+		{"docs/a.go", false, true},
+		{"docs/x/y/a.go", false, true},
+		{"svc/docs/a.go", false, false},
+		{"a/b/testdata", true, true},
+		{"#hash.go", false, true},
+		{"store.go", false, false},
+	}
+	for _, test := range tests {
+		if got := m.ignored(test.path, test.isDir); got != test.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", test.path, test.isDir, got, test.want)
+		}
+	}
+
+	if err := m.add("", "[z-a]", "test"); err == nil {
+		t.Error("invalid character class accepted")
+	}
+}
+
+func TestGodocignore(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"store.go", "gen/api.go", "gen/keep/api.go", "svc/store.go", "svc/mocks/mock.go", "svc/legacy/old.go", "vendor/lib/lib.go", "third_party/x.go"} {
+		writeFile(t, filepath.Join(root, name), "package p\n")
+	}
+	writeFile(t, filepath.Join(root, ".godocignore"), "# generated code\ngen/\nthird_party/\n!vendor/\n")
+	// A nested file's patterns are relative to its own directory
+	writeFile(t, filepath.Join(root, "svc", ".godocignore"), "mocks/\n/legacy\n")
+
+	// A .godocignore comes after exclude_dirs, so it can re-include vendor/;
+	// nothing inside the ignored gen/ can be re-included
+	config := &Config{ExcludeDirs: []string{"vendor/", "/store.go", "!gen/keep/"}}
+	want := []string{"svc/store.go", "vendor/lib/lib.go"}
+	if got := walkedFiles(t, root, config); !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+}
+
+func TestGodocignoreExcludingEverything(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "svc", "store.go"), "package svc\n")
+	writeFile(t, filepath.Join(root, ".godocignore"), "*\n")
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+	if got := walkedFiles(t, root, &Config{}); len(got) != 0 {
+		t.Errorf("files = %v, want none", got)
+	}
+	if !strings.Contains(logs.String(), `every Go file under `+root+` is ignored (by .godocignore:1 pattern "*")`) {
+		t.Errorf("log = %q, want a warning", logs.String())
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Function to fail unless f is a terminal, so -interactive doesn't wait
+// forever on a pipe or in CI
+func requireTerminal(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("-interactive needs a terminal on standard input; use include_interfaces in the config instead")
+	}
+	return nil
+}
+
+// Function to let the user tick the interfaces to keep. Every interface
+// starts selected; numbers and ranges such as "1 3-5" toggle entries, "a"
+// and "n" select all or none and an empty line (or end of input) confirms.
+// The prompt is written to out, so stdout can still carry the output.
+func selectInterfaces(in io.Reader, out io.Writer, results []InterfaceDetails) []InterfaceDetails {
+	selected := make([]bool, len(results))
+	for i := range selected {
+		selected[i] = true
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintln(out, "Select the interfaces to document:")
+		for i, result := range results {
+			mark := " "
+			if selected[i] {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "%3d [%s] %s (%d methods, %d implementations)\n",
+				i+1, mark, result.InterfaceName, len(result.Methods), len(result.Implementations))
+		}
+		fmt.Fprint(out, "Toggle by number or range (e.g. 1 3-5), a for all, n for none, enter to continue: ")
+
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			break
+		}
+		for _, field := range strings.Fields(strings.ReplaceAll(line, ",", " ")) {
+			switch field {
+			case "a":
+				for i := range selected {
+					selected[i] = true
+				}
+			case "n":
+				for i := range selected {
+					selected[i] = false
+				}
+			default:
+				first, last, err := parseRange(field, len(results))
+				if err != nil {
+					fmt.Fprintf(out, "Ignoring %q: %v\n", field, err)
+					continue
+				}
+				for i := first; i <= last; i++ {
+					selected[i-1] = !selected[i-1]
+				}
+			}
+		}
+	}
+
+	var kept []InterfaceDetails
+	for i, result := range results {
+		if selected[i] {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+// Function to parse "3" or "3-5" into a range of entry numbers from 1 to n
+func parseRange(field string, n int) (int, int, error) {
+	from, to, isRange := strings.Cut(field, "-")
+	first, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("not a number")
+	}
+	last := first
+	if isRange {
+		if last, err = strconv.Atoi(to); err != nil {
+			return 0, 0, fmt.Errorf("not a range")
+		}
+	}
+	if first < 1 || last > n || first > last {
+		return 0, 0, fmt.Errorf("out of range 1-%d", n)
+	}
+	return first, last, nil
+}
+
+// Function to record the selected interface names as include_interfaces in
+// the config file, so later runs need no prompt. The file is re-read rather
+// than written from the running config, which holds flag overrides.
+// Comments in the file are not preserved. A YAML file is edited key by key
+// instead, so its include directive keeps supplying the included values.
+func saveIncludeInterfaces(path string, results []InterfaceDetails) error {
+	config, err := readConfig(path, "")
+	if err != nil {
+		return err
+	}
+	config.IncludeInterfaces = nil
+	for _, result := range results {
+		config.IncludeInterfaces = append(config.IncludeInterfaces, result.InterfaceName)
+	}
+
+	var data []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		data, err = json.MarshalIndent(config, "", "  ")
+	case ".toml":
+		var buf bytes.Buffer
+		err = toml.NewEncoder(&buf).Encode(config)
+		data = buf.Bytes()
+	default:
+		data, err = setYAMLKey(path, "include_interfaces", config.IncludeInterfaces)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Function to set one top-level key of a YAML file, keeping the other keys
+// as written and in their order
+func setYAMLKey(path, key string, value interface{}) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for i := range doc {
+		if doc[i].Key == key {
+			doc[i].Value = value
+			return yaml.Marshal(doc)
+		}
+	}
+	return yaml.Marshal(append(doc, yaml.MapItem{Key: key, Value: value}))
+}
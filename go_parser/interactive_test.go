@@ -0,0 +1,85 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func interfaceNames(results []InterfaceDetails) []string {
+	var names []string
+	for _, result := range results {
+		names = append(names, result.InterfaceName)
+	}
+	return names
+}
+
+func TestSelectInterfaces(t *testing.T) {
+	results := []InterfaceDetails{{InterfaceName: "Closer"}, {InterfaceName: "Reader"}, {InterfaceName: "Store"}, {InterfaceName: "Writer"}}
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"\n", []string{"Closer", "Reader", "Store", "Writer"}},
+		{"", []string{"Closer", "Reader", "Store", "Writer"}},
+		{"2-3\n\n", []string{"Closer", "Writer"}},
+		{"n\n3\n\n", []string{"Store"}},
+		{"n 1,4 9 x\n\n", []string{"Closer", "Writer"}},
+	}
+	for _, test := range tests {
+		got := interfaceNames(selectInterfaces(strings.NewReader(test.input), io.Discard, results))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("input %q selected %v, want %v", test.input, got, test.want)
+		}
+	}
+}
+
+func TestRequireTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if err := requireTerminal(r); err == nil {
+		t.Error("a pipe should not count as a terminal")
+	}
+}
+
+func TestSaveIncludeInterfaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeFile(t, path, "go_file_path: api.go\ngo_directory: .\ninclude_interfaces: [Old]\n")
+
+	if err := saveIncludeInterfaces(path, []InterfaceDetails{{InterfaceName: "Store"}, {InterfaceName: "Closer"}}); err != nil {
+		t.Fatal(err)
+	}
+	config, err := readConfig(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.GoFilePath != "api.go" || !reflect.DeepEqual(config.IncludeInterfaces, []string{"Store", "Closer"}) {
+		t.Errorf("saved config = %+v", config)
+	}
+}
+
+func TestSaveIncludeInterfacesKeepsInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "shared.yaml"), "go_directory: services\n")
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "include: shared.yaml\ngo_file_path: api.go\n")
+
+	if err := saveIncludeInterfaces(path, []InterfaceDetails{{InterfaceName: "Store"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "include: shared.yaml\ngo_file_path: api.go\ninclude_interfaces:\n- Store\n"; string(data) != want {
+		t.Errorf("saved config:\n%s\nwant:\n%s", data, want)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// KnownInterface describes an interface from outside the scanned tree by its
+// qualified name and method signatures, e.g. "Read(p []byte) (n int, err error)"
+type KnownInterface struct {
+	Name    string   `yaml:"name" json:"name" toml:"name"`
+	Methods []string `yaml:"methods" json:"methods" toml:"methods"`
+}
+
+// Well-known standard library interfaces every scanned type is checked against
+var stdlibInterfaces = []KnownInterface{
+	{Name: "error", Methods: []string{"Error() string"}},
+	{Name: "fmt.Stringer", Methods: []string{"String() string"}},
+	{Name: "io.Reader", Methods: []string{"Read(p []byte) (n int, err error)"}},
+	{Name: "io.Writer", Methods: []string{"Write(p []byte) (n int, err error)"}},
+	{Name: "io.Closer", Methods: []string{"Close() error"}},
+	{Name: "sort.Interface", Methods: []string{"Len() int", "Less(i, j int) bool", "Swap(i, j int)"}},
+	{Name: "json.Marshaler", Methods: []string{"MarshalJSON() ([]byte, error)"}},
+	{Name: "json.Unmarshaler", Methods: []string{"UnmarshalJSON(data []byte) error"}},
+	{Name: "http.Handler", Methods: []string{"ServeHTTP(w http.ResponseWriter, r *http.Request)"}},
+}
+
+// A known interface with its method signatures parsed
+type knownInterface struct {
+	name    string
+	methods []MethodDetails
+}
+
+// Function to parse the built-in catalog plus extra_known_interfaces
+func loadKnownInterfaces(config *Config) ([]knownInterface, error) {
+	fset := token.NewFileSet()
+	var known []knownInterface
+
+	for _, entry := range append(stdlibInterfaces, config.ExtraKnownInterfaces...) {
+		iface := knownInterface{name: entry.Name}
+		for _, signature := range entry.Methods {
+			method, err := parseMethodSignature(fset, signature)
+			if err != nil {
+				return nil, fmt.Errorf("known interface %s: %w", entry.Name, err)
+			}
+			iface.methods = append(iface.methods, method)
+		}
+		known = append(known, iface)
+	}
+	return known, nil
+}
+
+// Function to parse a method signature written as "Name(params) results"
+func parseMethodSignature(fset *token.FileSet, signature string) (MethodDetails, error) {
+	open := strings.Index(signature, "(")
+	if open <= 0 {
+		return MethodDetails{}, fmt.Errorf("invalid method signature %q", signature)
+	}
+
+	expr, err := parser.ParseExpr("func" + signature[open:])
+	if err != nil {
+		return MethodDetails{}, fmt.Errorf("invalid method signature %q: %w", signature, err)
+	}
+	funcType, ok := expr.(*ast.FuncType)
+	if !ok {
+		return MethodDetails{}, fmt.Errorf("invalid method signature %q", signature)
+	}
+
+	return methodDetails(fset, strings.TrimSpace(signature[:open]), funcType), nil
+}
+
+// Function to list the known interfaces a type's pointer method set satisfies.
+// Known interfaces spell out their signatures, so unlike scanned interfaces
+// they are matched on parameter and result types, not just arity.
+func matchKnownInterfaces(known []knownInterface, methods []typeMethod) []string {
+	types := make(map[string]string)
+	for _, method := range methods {
+		types[method.Name] = method.Types
+	}
+
+	var names []string
+	for _, iface := range known {
+		if hasMethodTypes(iface.methods, types) {
+			names = append(names, iface.name)
+		}
+	}
+	return names
+}
+
+func hasMethodTypes(ifaceMethods []MethodDetails, types map[string]string) bool {
+	for _, method := range ifaceMethods {
+		if types[method.Name] != method.Types {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestMatchKnownInterfacesComparesTypes(t *testing.T) {
+	src := `package p
+
+import "io"
+
+type Good struct{}
+
+func (Good) String() string                 { return "" }
+func (Good) Error() string                  { return "" }
+func (*Good) Read(buf []byte) (int, error)  { return 0, io.EOF }
+
+type Bad struct{}
+
+func (Bad) String() error                   { return nil }
+func (Bad) Error() int                      { return 0 }
+func (*Bad) Read(buf []byte) (int64, error) { return 0, io.EOF }
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	known, err := loadKnownInterfaces(&Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := matchKnownInterfaces(known, getMethodsForType(fset, file, "Good", &Config{})), []string{"error", "fmt.Stringer", "io.Reader"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Good matches %v, want %v", got, want)
+	}
+	if got := matchKnownInterfaces(known, getMethodsForType(fset, file, "Bad", &Config{})); len(got) != 0 {
+		t.Errorf("Bad matches %v, want none", got)
+	}
+}
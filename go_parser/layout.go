@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Output layouts of render: one markdown document, or a file per interface
+// under interfaces/<package path>/ plus an index
+const (
+	LayoutSingle = "single"
+	LayoutSplit  = "split"
+)
+
+// Directory the split layout is written to unless output_dir or -out-dir
+// is set
+const defaultOutputDir = "docs"
+
+// Function to name the directory of a package relative to the working
+// directory, or after the package when it is outside of it
+func packageRelPath(dir, name string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if abs, err := filepath.Abs(dir); err == nil {
+			if r, err := filepath.Rel(wd, abs); err == nil && r != "." && !strings.HasPrefix(r, "..") {
+				return r
+			}
+		}
+	}
+	return name
+}
+
+// Characters that can't be in a file name on Windows, or are awkward in one
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// Names Windows reserves for devices, whatever the extension
+var reservedFileNames = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9]|lpt[0-9])(\.|$)`)
+
+// Function to make one path element safe on every filesystem: anything but
+// letters, digits, dots, dashes and underscores becomes "_", e.g. the ":"
+// and brackets of an instantiation, and reserved device names get a "_"
+func safeFileName(name string) string {
+	name = strings.TrimRight(unsafeFileNameChars.ReplaceAllString(name, "_"), ".")
+	if name == "" {
+		return "_"
+	}
+	if reservedFileNames.MatchString(name) {
+		return "_" + name
+	}
+	return name
+}
+
+// Function to choose the file of each interface in the split layout,
+// slash-separated and relative to the output directory, in the order of
+// results. Interfaces of packages with the same name are told apart by
+// their directories; names that only differ in case, which would collide
+// on Windows and macOS, get a numbered suffix.
+func interfacePaths(results []InterfaceDetails) []string {
+	paths := make([]string, len(results))
+	taken := make(map[string]bool)
+	for i, result := range results {
+		dir := result.Package
+		if result.pos.Filename != "" {
+			dir = packageRelPath(filepath.Dir(result.pos.Filename), result.Package)
+		}
+		var elems []string
+		for _, elem := range strings.Split(filepath.ToSlash(dir), "/") {
+			elems = append(elems, safeFileName(elem))
+		}
+		base := path.Join(append([]string{"interfaces"}, elems...)...) + "/" + safeFileName(result.InterfaceName)
+		p := base + ".md"
+		for n := 2; taken[strings.ToLower(p)]; n++ {
+			p = fmt.Sprintf("%s-%d.md", base, n)
+		}
+		taken[strings.ToLower(p)] = true
+		paths[i] = p
+	}
+	return paths
+}
+
+// interfaceLinks turns references between interfaces into relative links
+// between their files in the split layout
+type interfaceLinks struct {
+	paths map[interfaceRef]string
+	// File being rendered, relative to the output directory
+	from string
+}
+
+// Function to index the files of the interfaces by reference
+func newInterfaceLinks(results []InterfaceDetails, paths []string) *interfaceLinks {
+	links := &interfaceLinks{paths: make(map[interfaceRef]string)}
+	for i, result := range results {
+		links.paths[interfaceRef{pkg: result.packageKey, name: result.InterfaceName}] = paths[i]
+	}
+	return links
+}
+
+// Function to link an interface file to another, relative to the file
+// being rendered
+func (l *interfaceLinks) link(name, target string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(l.from)), filepath.FromSlash(target))
+	if err != nil {
+		rel = target
+	}
+	return fmt.Sprintf("[`%s`](%s)", name, filepath.ToSlash(rel))
+}
+
+// Function to find the interfaces a type expression, written in the
+// declaring file of result, refers to: unqualified names of its own package
+// and names qualified with one of its imports. Returns links in order of
+// appearance, each once; the interface itself is left out.
+func (l *interfaceLinks) refs(result InterfaceDetails, expr ast.Expr) []string {
+	if l == nil || expr == nil {
+		return nil
+	}
+	imports := make(map[string]string)
+	for _, spec := range result.imports {
+		name, quoted, named := strings.Cut(spec, " ")
+		if !named {
+			quoted = name
+		}
+		importPath, err := strconv.Unquote(quoted)
+		if err != nil {
+			continue
+		}
+		if !named {
+			name = importName(&ast.ImportSpec{}, importPath)
+		}
+		imports[name] = importPath
+	}
+
+	var links []string
+	seen := map[string]bool{result.InterfaceName: true}
+	add := func(ref interfaceRef, name string) {
+		if target, ok := l.paths[ref]; ok && !seen[name] {
+			seen[name] = true
+			links = append(links, l.link(name, target))
+		}
+	}
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if x, ok := n.X.(*ast.Ident); ok {
+				if importPath, ok := imports[x.Name]; ok {
+					add(interfaceRef{pkg: importPath, name: n.Sel.Name}, x.Name+"."+n.Sel.Name)
+				}
+			}
+			return false
+		case *ast.Ident:
+			add(interfaceRef{pkg: result.packageKey, name: n.Name}, n.Name)
+		}
+		return true
+	})
+	return links
+}
+
+// Function to parse the types of a method signature such as
+// "Get(id string) (T, error)" into a function type
+func signatureExpr(method MethodDetails) ast.Expr {
+	expr, err := parser.ParseExpr("func" + strings.TrimPrefix(method.Signature, method.Name))
+	if err != nil {
+		return nil
+	}
+	// Only the types, not the parameter names
+	funcType := expr.(*ast.FuncType)
+	for _, list := range []*ast.FieldList{funcType.Params, funcType.Results} {
+		if list == nil {
+			continue
+		}
+		for _, field := range list.List {
+			field.Names = nil
+		}
+	}
+	return funcType
+}
+
+// Function to write one file per interface under dir/interfaces, in a
+// directory per package, plus dir/index.md linking them by package, under
+// the summary of the package when there is one, and listing the constants
+// and variables. References between interfaces, in
+// method signatures and embeds, become relative links. The index of a
+// partial run starts with a note, after the front-matter every file gets
+// when markdown_frontmatter is set. The static text is in doc_language.
+func renderMarkdownFiles(dir string, results []InterfaceDetails, values []ValueDeclaration, summaries []PackageSummary, partial bool, config *Config) error {
+	paths := interfacePaths(results)
+	links := newInterfaceLinks(results, paths)
+	frontMatter := config.MarkdownFrontmatter
+	msgs := docMessages(config)
+
+	byPackage := make(map[string][]int)
+	for i, result := range results {
+		links.from = paths[i]
+		header, err := renderFrontMatter(frontMatter, interfaceFrontMatter(result))
+		if err != nil {
+			return err
+		}
+		var b strings.Builder
+		b.WriteString(header)
+		renderInterface(&b, result, 1, links, msgs)
+
+		file := filepath.Join(dir, filepath.FromSlash(paths[i]))
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, []byte(b.String()), 0o644); err != nil {
+			return err
+		}
+		pkg := path.Dir(strings.TrimPrefix(paths[i], "interfaces/"))
+		byPackage[pkg] = append(byPackage[pkg], i)
+	}
+
+	var packages []string
+	for pkg := range byPackage {
+		packages = append(packages, pkg)
+	}
+	sort.Strings(packages)
+	header, err := renderFrontMatter(frontMatter, pageFrontMatter(results))
+	if err != nil {
+		return err
+	}
+	var index strings.Builder
+	index.WriteString(header + partialMarker(partial, msgs) + "# " + msgs.text("interfaces") + "\n")
+	summaryByDir := make(map[string]string)
+	for _, summary := range summaries {
+		summaryByDir[summary.dir] = summary.Summary
+	}
+	for _, pkg := range packages {
+		fmt.Fprintf(&index, "\n## %s\n\n", pkg)
+		if summary := summaryByDir[filepath.Dir(results[byPackage[pkg][0]].pos.Filename)]; summary != "" {
+			fmt.Fprintf(&index, "%s\n\n", summary)
+		}
+		for _, i := range byPackage[pkg] {
+			fmt.Fprintf(&index, "- [%s](%s): %s\n", results[i].InterfaceName, paths[i],
+				msgs.text("index_entry", len(results[i].Methods), implementationCount(results[i])))
+		}
+	}
+	index.WriteString(renderValues(values, msgs))
+
+	return os.WriteFile(filepath.Join(dir, "index.md"), []byte(index.String()), 0o644)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterfacePaths(t *testing.T) {
+	at := func(name, pkg, file string) InterfaceDetails {
+		return InterfaceDetails{InterfaceName: name, Package: pkg, pos: token.Position{Filename: file}}
+	}
+	results := []InterfaceDetails{
+		at("Store", "api", filepath.Join("a", "api", "store.go")),
+		at("Store", "api", filepath.Join("b", "api", "store.go")),
+		at("store", "api", filepath.Join("a", "api", "store.go")),
+		at("Con", "con", filepath.Join("c", "con", "con.go")),
+	}
+	want := []string{
+		"interfaces/a/api/Store.md",
+		"interfaces/b/api/Store.md",
+		"interfaces/a/api/store-2.md",
+		"interfaces/c/_con/_Con.md",
+	}
+	if got := interfacePaths(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %q, want %q", got, want)
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	tests := map[string]string{
+		"Store":         "Store",
+		"Pair[K:V any]": "Pair_K_V_any_",
+		"aux.go":        "_aux.go",
+		"auxiliary":     "auxiliary",
+		"..":            "_",
+	}
+	for name, want := range tests {
+		if got := safeFileName(name); got != want {
+			t.Errorf("safeFileName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRenderMarkdownFilesLinksInterfaces(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(root, "store", "store.go"), `package store
+
+type Store interface {
+	Get(id string) (string, error)
+}
+`)
+	writeFile(t, filepath.Join(root, "opener", "opener.go"), `package opener
+
+import "example.com/app/store"
+
+type Opener interface {
+	Closer
+	Open(store string) (store.Store, error)
+	Reopen(Closer) Closer
+}
+
+type Closer interface {
+	Close() error
+}
+`)
+	report, err := Analyze(Config{GoFilePath: filepath.Join(root, "store"), InterfaceSources: []string{filepath.Join(root, "opener")}, GoDirectory: root})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	out := t.TempDir()
+	if err := renderMarkdownFiles(out, report.Interfaces, nil, nil, false, &Config{}); err != nil {
+		t.Fatal(err)
+	}
+	opener, err := os.ReadFile(filepath.Join(out, "interfaces", "opener", "Opener.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Embeds [`Closer`](Closer.md).",
+		"- `Open(store string) (store.Store, error)` (see [`store.Store`](../store/Store.md))",
+		"- `Reopen(Closer) Closer` (see [`Closer`](Closer.md))",
+	} {
+		if !strings.Contains(string(opener), want) {
+			t.Errorf("Opener.md lacks %q:\n%s", want, opener)
+		}
+	}
+
+	index, err := os.ReadFile(filepath.Join(out, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Interfaces\n\n## opener\n\n- [Closer](interfaces/opener/Closer.md): 1 methods, 0 implementations\n" +
+		"- [Opener](interfaces/opener/Opener.md): 3 methods, 0 implementations\n\n" +
+		"## store\n\n- [Store](interfaces/store/Store.md): 1 methods, 0 implementations\n"
+	if string(index) != want {
+		t.Errorf("index.md:\n%s\nwant:\n%s", index, want)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Function to print the results as aligned columns, one interface per line:
+// its name, package, methods and implementations. Implementations only *T
+// satisfies are marked with "*".
+func writeList(w io.Writer, results []InterfaceDetails) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "INTERFACE\tPACKAGE\tMETHODS\tIMPLEMENTATIONS")
+	for _, result := range results {
+		var methods []string
+		for _, method := range methodsInOrder(result.Methods) {
+			methods = append(methods, method.Name)
+		}
+		var impls []string
+		for _, impl := range result.Implementations {
+			impls = append(impls, impl.displayName())
+		}
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\n", result.InterfaceName, result.TypeParams, result.Package,
+			orDash(strings.Join(methods, ", ")), orDash(strings.Join(impls, ", ")))
+	}
+	return tw.Flush()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteList(t *testing.T) {
+	results := []InterfaceDetails{
+		{
+			InterfaceName: "Store",
+			Package:       "api",
+			Methods:       []MethodDetails{{Name: "Get"}, {Name: "Put"}},
+			Implementations: []Implementation{
+				{TypeName: "MemStore", Package: "mem", ReceiverSatisfaction: SatisfiedByPointer},
+				{TypeName: "Disk", Package: "disk", ReceiverSatisfaction: SatisfiedByBoth},
+			},
+		},
+		{InterfaceName: "Marker", Package: "api"},
+	}
+
+	var b strings.Builder
+	if err := writeList(&b, results); err != nil {
+		t.Fatal(err)
+	}
+	want := `INTERFACE  PACKAGE  METHODS   IMPLEMENTATIONS
+Store      api      Get, Put  *mem.MemStore, disk.Disk
+Marker     api      -         -
+`
+	if b.String() != want {
+		t.Errorf("list =\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LLMClient sends a prompt to a language model provider and returns the
+// generated text. Adding a provider means adding an implementation and a
+// case in newLLMClient.
+type LLMClient interface {
+	Complete(ctx context.Context, prompt string) (*Completion, error)
+}
+
+// Completion is the reply to a single request
+type Completion struct {
+	Content string
+	Usage   TokenUsage
+}
+
+// Function to build the client for the configured provider
+func newLLMClient(config *Config) (LLMClient, error) {
+	api, err := newHTTPAPI(config)
+	if err != nil {
+		return nil, err
+	}
+
+	model := modelName(config)
+	var client LLMClient
+	// Completion tokens a request may use, for the tokens_per_minute budget
+	maxOutputTokens := config.MaxCompletionTokens
+	switch config.Provider {
+	case "", "openai":
+		client = &openAIClient{api: api, baseURL: baseURL(config, openAIBaseURL), apiKey: config.APIKey, model: model, stream: !config.NoStream, config: config}
+	case "anthropic":
+		anthropic := &anthropicClient{api: api, baseURL: baseURL(config, anthropicBaseURL), apiKey: config.APIKey, model: model, config: config}
+		maxOutputTokens = anthropic.maxTokens()
+		client = anthropic
+	case "ollama":
+		client = &ollamaClient{api: api, baseURL: baseURL(config, ollamaBaseURL), model: model, stream: !config.NoStream, config: config}
+	case "mock":
+		client = &mockClient{config: config}
+	default:
+		return nil, fmt.Errorf("unknown provider %q (use openai, anthropic, ollama or mock)", config.Provider)
+	}
+
+	// Recorded inside the limiter, so latencies leave out the queueing
+	if config.TranscriptDir != "" {
+		if client, err = newTranscriptClient(client, config.TranscriptDir, config); err != nil {
+			return nil, err
+		}
+	}
+	if limiter := newRateLimiter(config); limiter != nil {
+		client = &rateLimitedClient{LLMClient: client, limiter: limiter, config: config, maxOutputTokens: maxOutputTokens}
+	}
+	return client, nil
+}
+
+// Function to get the configured model, or the provider's default
+func modelName(config *Config) string {
+	switch {
+	case config.Model != "":
+		return config.Model
+	case config.Provider == "anthropic":
+		return anthropicDefaultModel
+	case config.Provider == "ollama":
+		return ollamaDefaultModel
+	case config.Provider == "mock":
+		return mockModel
+	default:
+		return openAIDefaultModel
+	}
+}
+
+// Function to get the configured base URL, or the provider's default
+func baseURL(config *Config, fallback string) string {
+	if config.BaseURL != "" {
+		return strings.TrimSuffix(config.BaseURL, "/")
+	}
+	return fallback
+}
+
+// Function to send the data to the configured LLM provider and return the
+// generated documentation. Results that don't fit in max_prompt_tokens are
+// sent as several requests whose replies are joined. Token usage is added
+// to tracker. When a request fails, the replies received before it are
+// returned along with the error.
+func sendData(ctx context.Context, config *Config, results []InterfaceDetails, tracker *usageTracker) (string, error) {
+	structured := config.StructuredOutput
+	if structured && !supportsStructuredOutput(config.Provider) {
+		log.Printf("Warning: the %s provider can't be asked for JSON, so structured_output is ignored and the reply is free text", config.Provider)
+		structured = false
+	}
+	if structured {
+		withSchema := *config
+		withSchema.ResponseSchema = structuredReplySchema
+		config = &withSchema
+	}
+
+	client, err := newLLMClient(config)
+	if err != nil {
+		return "", err
+	}
+
+	// Convert the results to user messages and mask any secrets in them
+	var prompts []string
+	var findings []RedactionFinding
+	chunks := chunkResults(results, config.maxPromptTokens())
+	chunkOf := make(map[string][]InterfaceDetails)
+	for i, chunk := range chunks {
+		message := formatResultsForMessage(chunk)
+		if structured {
+			message += structuredInstruction
+		}
+		prompt, chunkFindings, err := redactPrompt(message, config)
+		if err != nil {
+			return "", err
+		}
+		chunkOf[prompt] = chunk
+		for j := range chunkFindings {
+			chunkFindings[j].Request = i + 1
+		}
+		prompts = append(prompts, prompt)
+		findings = append(findings, chunkFindings...)
+	}
+	if len(findings) > 0 {
+		log.Printf("Redacted %d possible secrets from the prompt", len(findings))
+	}
+	if config.RedactReport != "" {
+		if err := writeRedactReport(config.RedactReport, findings); err != nil {
+			log.Printf("Error writing redaction report: %v", err)
+		}
+	}
+	if config.BlockOnSecrets && len(findings) > 0 {
+		return "", withExitCode(exitPolicy, fmt.Errorf("prompt contained %d possible secrets and block_on_secrets is set", len(findings)))
+	}
+
+	// Echoing several streams at once would interleave them on stderr
+	if len(prompts) > 1 && config.concurrency() > 1 && !config.NoStream {
+		quiet := *config
+		quiet.NoStream = true
+		if client, err = newLLMClient(&quiet); err != nil {
+			return "", err
+		}
+	}
+
+	if structured {
+		client = &structuredClient{LLMClient: client, chunks: chunkOf}
+	}
+
+	completions, err := completeAll(ctx, client, prompts, config.concurrency(), tracker)
+	err = withExitCode(exitProvider, err)
+	if structured {
+		documented := mergeInterfaceDocs(chunks, completions)
+		if len(documented) == 0 {
+			return "", err
+		}
+		return renderMarkdown(documented, docMessages(config)), err
+	}
+	var contents []string
+	for _, completion := range completions {
+		contents = append(contents, completion.Content)
+	}
+	return strings.Join(contents, "\n\n"), err
+}
+
+// Function to send the prompts with at most limit requests in flight and
+// return the replies in prompt order, adding the usage of each to tracker.
+// Rate limits and server errors are retried by each request. When a request
+// fails the others are cancelled, and only the replies before the first
+// missing one are returned, followed by whatever part of that one was
+// streamed, along with the error of the request that failed first.
+func completeAll(ctx context.Context, client LLMClient, prompts []string, limit int, tracker *usageTracker) ([]*Completion, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	completions := make([]*Completion, len(prompts))
+	errs := make([]error, len(prompts))
+	slots := make(chan struct{}, limit)
+	var mu sync.Mutex
+	failed := -1
+	var wg sync.WaitGroup
+	for i, prompt := range prompts {
+		wg.Add(1)
+		go func(i int, prompt string) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				completions[i], errs[i] = client.Complete(ctx, prompt)
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+			}
+			if errs[i] != nil {
+				mu.Lock()
+				if failed < 0 {
+					failed = i
+				}
+				mu.Unlock()
+				cancel()
+				return
+			}
+			tracker.record(completions[i].Usage)
+		}(i, prompt)
+	}
+	wg.Wait()
+
+	var kept []*Completion
+	for i, completion := range completions {
+		if errs[i] != nil {
+			if completion != nil && completion.Content != "" {
+				kept = append(kept, completion)
+			}
+			break
+		}
+		kept = append(kept, completion)
+	}
+	if failed >= 0 {
+		return kept, fmt.Errorf("request %d of %d: %w", failed+1, len(prompts), errs[failed])
+	}
+	return kept, nil
+}
+
+// Helper function to format the results as a message for the LLM
+func formatResultsForMessage(results []InterfaceDetails) string {
+	message := "Here are the interfaces and their implementations:\n"
+	var files []string
+	fileSources := make(map[string]string)
+	for _, result := range results {
+		var implementations []string
+		for _, impl := range result.Implementations {
+			implementation := fmt.Sprintf("%s (satisfied by %s)", impl.displayName(), impl.ReceiverSatisfaction)
+			if impl.ReceiverSatisfaction == SatisfiedByPointer {
+				implementation = fmt.Sprintf("%s (pointer only: only the pointer form has all the methods, so callers must use a pointer)", impl.displayName())
+			}
+			if len(impl.StdlibInterfaces) > 0 {
+				implementation += fmt.Sprintf(", also implements %s", strings.Join(impl.StdlibInterfaces, ", "))
+			}
+			if len(impl.Fields) > 0 {
+				var fields []string
+				for _, field := range impl.Fields {
+					fields = append(fields, strings.TrimSpace(fmt.Sprintf("%s %s %s", field.Name, field.Type, field.Tag)))
+				}
+				implementation += fmt.Sprintf(", fields: {%s}", strings.Join(fields, "; "))
+			}
+			if impl.Delegated {
+				implementation += fmt.Sprintf(", delegates %s to its embedded %s", delegatedMethodNames(impl.Methods), strings.Join(impl.DelegateFields, ", "))
+			}
+			if impl.Partial {
+				implementation += ", partial match: the methods of unresolved embeds were not checked"
+			}
+			if impl.Kind == KindTestDouble {
+				implementation += ", test double"
+			}
+			if impl.Deprecated != "" {
+				implementation += fmt.Sprintf(", deprecated: %s", impl.Deprecated)
+			}
+			implementations = append(implementations, implementation)
+		}
+		var methods []string
+		for _, method := range methodsInOrder(result.Methods) {
+			if method.Deprecated != "" {
+				methods = append(methods, fmt.Sprintf("%s (deprecated: %s)", method.Signature, method.Deprecated))
+				continue
+			}
+			methods = append(methods, method.Signature)
+		}
+		message += fmt.Sprintf("Interface: %s%s\nPackage: %s\nMethods: %v\n", result.InterfaceName, result.TypeParams, result.Package, methods)
+		if result.Deprecated != "" {
+			message += fmt.Sprintf("Deprecated: %s\nNote: say it is deprecated and don't recommend it, or deprecated methods, in new code; point to the replacement the notice names\n", result.Deprecated)
+		}
+		if len(result.Embeds) > 0 {
+			message += fmt.Sprintf("Embeds: %v\n", result.Embeds)
+		}
+		if result.Anonymous != "" {
+			message += fmt.Sprintf("Note: anonymous interface literal, named by its location, the type of %s\n", result.Anonymous)
+		}
+		if result.Sealed {
+			message += fmt.Sprintf("Note: sealed, all methods are unexported so only types in package %s can implement it\n", result.Package)
+		}
+		message += fmt.Sprintf("Implementations: %v\n", implementations)
+		if result.hiddenTestDoubles > 0 {
+			message += fmt.Sprintf("Test doubles (mocks, fakes, stubs): %d, not listed\n", result.hiddenTestDoubles)
+		}
+		message += fmt.Sprintf("Usage: implemented by %d types and %s\n", implementationCount(result), usageSummary(result.Usages))
+		if examples := interfaceExamples(result); len(examples) > 0 {
+			message += "Examples from its tests, to include in the documentation as Go code blocks:\n"
+			for _, example := range examples {
+				message += fmt.Sprintf("%s:\n```go\n%s```\n", example.Name, example.Code)
+				if example.Output != "" {
+					message += fmt.Sprintf("Output:\n```\n%s\n```\n", example.Output)
+				}
+			}
+		}
+		switch {
+		case result.sourceFile != "":
+			// Whole files are attached once, after all their interfaces
+			if _, ok := fileSources[result.sourceFile]; !ok {
+				files = append(files, result.sourceFile)
+				fileSources[result.sourceFile] = result.sourceContext
+			}
+		case result.sourceContext != "":
+			message += fmt.Sprintf("Source:\n```go\n%s\n```\n", result.sourceContext)
+		}
+		message += "\n"
+	}
+	for _, file := range files {
+		message += fmt.Sprintf("Source of %s:\n```go\n%s\n```\n\n", filepath.Base(file), fileSources[file])
+	}
+	return message
+}
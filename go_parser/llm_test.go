@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeLLM replies with the prompt after a delay, failing the prompts in fail
+type fakeLLM struct {
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	fail        map[string]bool
+}
+
+func (f *fakeLLM) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	f.mu.Lock()
+	f.inFlight++
+	if f.inFlight > f.maxInFlight {
+		f.maxInFlight = f.inFlight
+	}
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+
+	if f.fail[prompt] {
+		return &Completion{Content: "partial " + prompt}, errors.New("status code 500")
+	}
+	// Later prompts finish first
+	select {
+	case <-time.After(time.Duration(10-len(prompt)) * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &Completion{Content: "reply " + prompt, Usage: TokenUsage{PromptTokens: 10, CompletionTokens: 1}}, nil
+}
+
+func TestCompleteAllKeepsOrderAndLimit(t *testing.T) {
+	var prompts []string
+	for i := 1; i <= 6; i++ {
+		prompts = append(prompts, strings.Repeat("p", i))
+	}
+	client := &fakeLLM{}
+	tracker := newUsageTracker(&Config{})
+
+	completions, err := completeAll(context.Background(), client, prompts, 2, tracker)
+	if err != nil {
+		t.Fatalf("completeAll: %v", err)
+	}
+	for i, completion := range completions {
+		if want := "reply " + prompts[i]; completion.Content != want {
+			t.Errorf("completion %d = %q, want %q", i, completion.Content, want)
+		}
+	}
+	if client.maxInFlight != 2 {
+		t.Errorf("max requests in flight = %d, want 2", client.maxInFlight)
+	}
+	if tracker.requests != 6 || tracker.total.PromptTokens != 60 {
+		t.Errorf("tracked %d requests, %+v", tracker.requests, tracker.total)
+	}
+}
+
+func TestCompleteAllStopsAtFirstFailure(t *testing.T) {
+	prompts := []string{"a", "bb", "ccc", "dddd"}
+	client := &fakeLLM{fail: map[string]bool{"bb": true}}
+
+	completions, err := completeAll(context.Background(), client, prompts, 1, newUsageTracker(&Config{}))
+	if err == nil || !strings.Contains(err.Error(), "request 2 of 4: status code 500") {
+		t.Fatalf("err = %v, want request 2 to fail", err)
+	}
+	var got []string
+	for _, completion := range completions {
+		got = append(got, completion.Content)
+	}
+	if fmt.Sprint(got) != "[reply a partial bb]" {
+		t.Errorf("completions = %q, want the first reply and the partial second", got)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Message catalogs of the static text of the markdown, one file per
+// language named after its code, e.g. locales/ja.json. Adding a language is
+// adding a file: a key missing from it falls back to English.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Language used when doc_language is unset
+const defaultDocLanguage = "en"
+
+// messages maps the keys of a catalog to its text, format strings where
+// the English text has verbs. Explicit argument indexes, as in %[2]d, let a
+// language reorder them. A nil catalog is English.
+type messages map[string]string
+
+var englishMessages = mustLoadMessages(defaultDocLanguage)
+
+// Function to load the catalog of a language code, e.g. "ja"; empty is
+// English
+func loadMessages(language string) (messages, error) {
+	if language == "" {
+		language = defaultDocLanguage
+	}
+	data, err := localeFiles.ReadFile(path.Join("locales", language+".json"))
+	if err != nil {
+		return nil, configError("doc_language", "%q (use %s)", language, strings.Join(docLanguages(), ", "))
+	}
+	var catalog messages
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("decoding the %s catalog: %w", language, err)
+	}
+	return catalog, nil
+}
+
+func mustLoadMessages(language string) messages {
+	catalog, err := loadMessages(language)
+	if err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// Function to list the language codes there is a catalog for
+func docLanguages() []string {
+	entries, _ := localeFiles.ReadDir("locales")
+	var languages []string
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Function to get the catalog of the configured doc_language; English when
+// it is unset or, as the config was validated, can't be loaded
+func docMessages(config *Config) messages {
+	if config == nil || config.DocLanguage == "" {
+		return nil
+	}
+	catalog, err := loadMessages(config.DocLanguage)
+	if err != nil {
+		return nil
+	}
+	return catalog
+}
+
+// Function to get the text of a key, formatted with args when given
+func (m messages) text(key string, args ...interface{}) string {
+	text, ok := m[key]
+	if !ok || text == "" {
+		text = englishMessages[key]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Function to get the system message asking for the documentation in the
+// configured language; empty for English
+func languageInstruction(config *Config) string {
+	if config.DocLanguage == "" || config.DocLanguage == defaultDocLanguage {
+		return ""
+	}
+	return "Write all documentation in " + docMessages(config).text("language") + "."
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Verbs of a catalog text, with an optional explicit argument index
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?([sd])`)
+
+// Function to make arguments of the types the English text of a key takes
+func catalogArgs(key string) []interface{} {
+	var args []interface{}
+	for _, verb := range verbPattern.FindAllStringSubmatch(englishMessages[key], -1) {
+		if verb[2] == "d" {
+			args = append(args, len(args)+1)
+		} else {
+			args = append(args, "arg"+strconv.Itoa(len(args)+1))
+		}
+	}
+	return args
+}
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	for _, language := range docLanguages() {
+		catalog, err := loadMessages(language)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if catalog["language"] == "" {
+			t.Errorf("%s: no language name", language)
+		}
+		for key, text := range catalog {
+			if _, ok := englishMessages[key]; !ok {
+				t.Errorf("%s: unknown key %s", language, key)
+				continue
+			}
+			// The verbs must take the arguments of the English text
+			if args := catalogArgs(key); len(args) > 0 && strings.Contains(catalog.text(key, args...), "%!") {
+				t.Errorf("%s: %s = %q formats as %q", language, key, text, catalog.text(key, args...))
+			}
+		}
+	}
+}
+
+func TestRenderMarkdownInLanguage(t *testing.T) {
+	msgs := docMessages(&Config{DocLanguage: "ja"})
+	markdown := renderMarkdown(testResults(), msgs)
+	for _, want := range []string{"# インターフェース\n", "### メソッド\n", "### 実装\n", "- `*.MemStore` (ポインタのみ)", "### 使用箇所\n"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(markdown, "Implementations") {
+		t.Errorf("English heading left in:\n%s", markdown)
+	}
+
+	// Keys missing from a catalog fall back to English
+	if got := (messages{}).text("implementations"); got != "Implementations" {
+		t.Errorf("fallback = %q", got)
+	}
+}
+
+func TestDocLanguagePrompt(t *testing.T) {
+	messages := buildMessages(&Config{DocLanguage: "ja"}, "RESULTS")
+	if len(messages) != 2 || messages[0].Role != RoleSystem || messages[0].Content != "Write all documentation in Japanese." {
+		t.Errorf("messages = %+v", messages)
+	}
+	if messages := buildMessages(&Config{DocLanguage: "en"}, "RESULTS"); len(messages) != 1 {
+		t.Errorf("messages = %+v, want no instruction for English", messages)
+	}
+
+	// Configured messages can place the language themselves
+	config := &Config{DocLanguage: "es", Messages: []PromptMessage{
+		{Role: RoleSystem, Content: "You are a technical writer. Answer in {{language}}."},
+		{Role: RoleUser, Content: "{{results}}"},
+	}}
+	want := []PromptMessage{
+		{Role: RoleSystem, Content: "You are a technical writer. Answer in Spanish."},
+		{Role: RoleUser, Content: "RESULTS"},
+	}
+	if messages := buildMessages(config, "RESULTS"); !reflect.DeepEqual(messages, want) {
+		t.Errorf("messages = %+v, want %+v", messages, want)
+	}
+
+	err := (&Config{DocLanguage: "xx"}).validate()
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "doc_language" || !strings.Contains(err.Error(), `invalid doc_language: "xx" (use de, en, es, ja)`) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -1,156 +1,786 @@
-
-
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/token"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
-	"gopkg.in/yaml.v2"
-	"io"
+	"sort"
 	"strings"
+	"time"
+	"unicode"
 )
 
-type Config struct {
-	GoFilePath  string `yaml:"go_file_path"`
-	GoDirectory string `yaml:"go_directory"`
-	APIKey      string // This will hold the API key from the environment
+type InterfaceDetails struct {
+	InterfaceName string `json:"interface_name"`
+	// Name in the package clause of the declaring file
+	Package string `json:"package"`
+	Doc     string `json:"doc,omitempty"`
+	// What an interface literal found with anonymous_interfaces types, e.g.
+	// "parameter c of func Open"; InterfaceName is then its location
+	Anonymous string `json:"anonymous,omitempty"`
+	// Type parameter list of a generic interface, e.g. "[T any]"
+	TypeParams string `json:"type_params,omitempty"`
+	// Set for interfaces with type elements that can only be used as constraints
+	IsConstraint bool `json:"is_constraint,omitempty"`
+	// Set for interfaces with no known methods, such as interface{} or marker
+	// interfaces, which every type satisfies; no implementations are listed
+	IsEmpty bool `json:"is_empty,omitempty"`
+	// Set when every method is unexported, which seals the interface: only
+	// types in its own package can implement it
+	Sealed  bool            `json:"sealed,omitempty"`
+	Methods []MethodDetails `json:"methods"`
+	// Embedded interfaces as written, e.g. "io.Reader" or "Base[T]". Their
+	// methods are part of Methods when the embedded interface is declared in
+	// the scanned tree or is a known interface; the others are listed in
+	// UnresolvedEmbeds and their methods are unknown.
+	Embeds           []string         `json:"embeds,omitempty"`
+	UnresolvedEmbeds []string         `json:"unresolved_embeds,omitempty"`
+	Implementations  []Implementation `json:"implementations"`
+	// Types with every method name of the interface that still don't
+	// implement it because some signatures differ
+	NearMisses []NearMiss `json:"near_misses,omitempty"`
+	// Parameters, results, fields and variables typed with the interface
+	Usages []Usage `json:"usages,omitempty"`
+	// Example functions of the interface itself, see attachExamples
+	Examples []Example `json:"examples,omitempty"`
+	// Message of the Deprecated: paragraph of the doc, see markDeprecated
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// Source sent along with the interface, depending on context_level. With
+	// context_level file, sourceFile names the file so it is sent only once
+	// for all the interfaces it declares.
+	sourceContext string
+	sourceFile    string
+	// Where the interface is declared
+	pos token.Position
+	// Import path of the declaring package, type parameter names and the
+	// imports its method signatures need, used to generate stubs
+	importPath     string
+	typeParamNames []string
+	imports        []string
+	// What the embedded interfaces refer to
+	embedRefs  []interfaceRef
+	packageKey string
+	// Test doubles left out of Implementations for the markdown and the
+	// LLM, see collapseTestDoubles
+	hiddenTestDoubles int
+	// Documentation from a structured reply, see structured_output
+	generatedDoc *InterfaceDoc
 }
 
-type InterfaceDetails struct {
-	InterfaceName   string   `json:"interface_name"`
-	Methods         []string `json:"methods"`
-	Implementations []string `json:"implementations"`
+type MethodDetails struct {
+	Name      string `json:"name"`
+	Signature string `json:"signature"` // e.g. "Get(id string) (T, error)"
+	Doc       string `json:"doc,omitempty"`
+	// Position in the interface: its own methods as declared, then those of
+	// its embeds in the order the embeds are listed
+	Order   int `json:"order"`
+	Params  int `json:"-"`
+	Results int `json:"-"`
+	// Parameter and result types without names, e.g. "(string) (T, error)"
+	Types string `json:"-"`
+	// Example functions of the method, e.g. ExampleStore_Get
+	Examples   []Example `json:"examples,omitempty"`
+	Deprecated string    `json:"deprecated,omitempty"`
+	// Name of the embedded interface declaring the method, if not the
+	// interface itself
+	embeddedFrom string
+	// Types of each parameter and result, e.g. "string" and "error", and
+	// the type parameters of the interface or receiver, which stand for
+	// any type; see sameMethodTypes
+	paramTypes  []string
+	resultTypes []string
+	typeParams  []string
+
+	pos token.Position
 }
 
-func main() {
-	// Get the API key from the environment
-	apiKey := os.Getenv("API_KEY")
-	if apiKey == "" {
-		log.Fatal("API_KEY environment variable not set")
-	}
+type Implementation struct {
+	TypeName string `json:"type_name"`
+	// Name in the package clause of the file declaring the type
+	Package    string `json:"package"`
+	TypeParams string `json:"type_params,omitempty"`
+	Doc        string `json:"doc,omitempty"`
+	// Which form of the type satisfies the interface, see receiverSatisfaction
+	ReceiverSatisfaction string `json:"receiver_satisfaction"`
+	// "production" or "test-double", see classifyImplementations
+	Kind string `json:"kind"`
+	// Well-known interfaces (stdlib and extra_known_interfaces) the type satisfies
+	StdlibInterfaces []string `json:"stdlib_interfaces,omitempty"`
+	// Aliased type when the implementation is an alias, e.g. "Bar" for
+	// type Foo = Bar
+	AliasOf string `json:"alias_of,omitempty"`
+	// Exported fields of the implementing struct
+	Fields []FieldDetails `json:"fields,omitempty"`
+	// Set when the interface has unresolved embeds, so only the methods that
+	// are known could be checked
+	Partial bool `json:"partial,omitempty"`
+	// Where the type implements each interface method, in interface order
+	Methods []ImplementedMethod `json:"methods,omitempty"`
+	// Set when some methods are provided by interfaces embedded in the
+	// struct, e.g. Store in struct{ Store }; DelegateFields names them
+	Delegated      bool     `json:"delegated,omitempty"`
+	DelegateFields []string `json:"delegate_fields,omitempty"`
+	// Example functions of the type and of its methods implementing the
+	// interface
+	Examples   []Example `json:"examples,omitempty"`
+	Deprecated string    `json:"deprecated,omitempty"`
+
+	pos token.Position
+}
 
-	// Read the YAML configuration
-	config, err := readConfig("config.yaml")
-	if err != nil {
-		log.Fatalf("Error reading config file: %v", err)
+// NearMiss is a type that has all the methods of an interface by name, but
+// not with the right signatures
+type NearMiss struct {
+	TypeName   string              `json:"type_name"`
+	Package    string              `json:"package"`
+	Position   string              `json:"position"`
+	Mismatches []SignatureMismatch `json:"mismatches"`
+}
+
+// SignatureMismatch is a method whose signature differs between an
+// interface and a type
+type SignatureMismatch struct {
+	Method             string `json:"method"`
+	InterfaceSignature string `json:"interface_signature"`
+	TypeSignature      string `json:"type_signature"`
+}
+
+// ImplementedMethod is the method of an implementing type that provides an
+// interface method
+type ImplementedMethod struct {
+	Name       string `json:"name"`
+	Order      int    `json:"order"`    // Order of the interface method it provides
+	Position   string `json:"position"` // file:line of the method declaration
+	Documented bool   `json:"documented"`
+	// Embedded interface field the method is delegated to, in which case
+	// Position is that of the field
+	DelegatedTo string `json:"delegated_to,omitempty"`
+	// Embedded interface field whose method the type's own one overrides
+	Overrides string `json:"overrides,omitempty"`
+
+	pos token.Position
+}
+
+type FieldDetails struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Tag  string `json:"tag,omitempty"`
+	Doc  string `json:"doc,omitempty"`
+}
+
+// Receiver satisfaction values. The method set of *T always contains the
+// methods of T, so a type satisfied by its value form is satisfied by both.
+const (
+	SatisfiedByBoth    = "both"
+	SatisfiedByPointer = "*T"
+)
+
+// Function to get the name an implementation is shown by, e.g. "repo.User",
+// with a leading * when only its pointer form satisfies the interface, as
+// callers then have to pass a *repo.User
+func (impl Implementation) displayName() string {
+	name := impl.Package + "." + impl.TypeName + impl.TypeParams
+	if impl.ReceiverSatisfaction == SatisfiedByPointer {
+		return "*" + name
 	}
+	return name
+}
 
-	// Assign the API key to the config struct
-	config.APIKey = apiKey
+// A method declared on a type, along with the kind of its receiver
+type typeMethod struct {
+	MethodDetails
+	PointerReceiver bool
+	decl            *ast.FuncDecl
+	// Embedded interface field providing the method, for a method the type
+	// gets by delegation, or whose method a declared one overrides
+	delegatedTo string
+	overrides   string
+}
+
+func main() {
+	dispatch(os.Args[1:])
+}
 
-	// Parse the file to find all interfaces and their methods
-	interfaces := findInterfaces(config.GoFilePath)
+// InterfaceConflict lists the declarations of an interface name declared more
+// than once in a package with different method sets; only the first
+// declaration is analyzed. Identical declarations aren't conflicts, and nor
+// are interfaces of the same name in different packages.
+type InterfaceConflict struct {
+	InterfaceName string   `json:"interface_name"`
+	Positions     []string `json:"positions"` // file:line of each declaration
+	// Methods and embeds that not every declaration has, e.g. "Close() error"
+	Differences []string `json:"differences,omitempty"`
+}
 
-	// Walk the services directory to find implementations of these interfaces
-	result := findImplementations(config.GoDirectory, interfaces)
+// Function to find all interfaces in a given Go file, along with names that
+// were declared more than once
+func findInterfaces(filePath string, config *Config) (map[interfaceRef]*InterfaceDetails, []InterfaceConflict) {
+	src, err := os.ReadFile(filePath)
+	if err != nil {
+		fatalf(exitUsage, "Error reading Go file: %v", err)
+	}
 
-	// Send the data via HTTP to an API
-	sendData(config.APIKey, result)
+	declared := declaredInterfaces(context.Background(), []string{filePath}, config)
+	interfaces, conflicts, err := findInterfacesInSource(filePath, src, config, declared)
+	if err != nil {
+		fatalf(exitParse, "Error parsing Go file: %v", err)
+	}
+	return interfaces, conflicts
 }
 
-// Function to find all interfaces in a given Go file
-func findInterfaces(filePath string) map[string][]string {
+// Function to find the interfaces in Go source that is said to be filePath,
+// which need not exist on disk. Embeds resolve through declared, the index
+// of declaredInterfaces, to which the file's own interfaces are added from
+// src. Returns parse errors and errors in extra_known_interfaces.
+func findInterfacesInSource(filePath string, src []byte, config *Config, declared map[interfaceRef]*declaredInterface) (map[interfaceRef]*InterfaceDetails, []InterfaceConflict, error) {
 	fset := token.NewFileSet()
 
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+	node, err := parser.ParseFile(fset, filePath, src, parser.ParseComments)
 	if err != nil {
-		log.Fatalf("Error parsing Go file: %v", err)
+		config.Metrics.parseError()
+		return nil, nil, &ParseError{File: filePath, Err: err}
 	}
+	config.Metrics.fileParsed()
 
-	interfaceMethods := make(map[string][]string)
+	interfaces := make(map[interfaceRef]*InterfaceDetails)
+	var conflicts []InterfaceConflict
+	docs := typeDocs(node)
+	pkg := packageKey(filepath.Dir(filePath))
 
 	// Traverse the AST to find interface declarations
 	ast.Inspect(node, func(n ast.Node) bool {
 		if iface, ok := n.(*ast.TypeSpec); ok {
 			if interfaceType, ok := iface.Type.(*ast.InterfaceType); ok {
-				var methods []string
-				for _, method := range interfaceType.Methods.List {
-					if len(method.Names) > 0 { // Make sure the method has a name
-						methods = append(methods, method.Names[0].Name)
-					}
+				if config.ExportedOnly && !ast.IsExported(iface.Name.Name) {
+					return true
 				}
-				interfaceMethods[iface.Name.Name] = methods
+				pos := fset.Position(iface.Pos())
+				details := newInterfaceDetails(fset, node, interfaceType, iface.Name.Name, iface.TypeParams, pos, config)
+				if existing, ok := interfaces[details.ref()]; ok {
+					// Keep the first declaration rather than silently replacing it
+					conflicts = addDuplicate(conflicts, existing, details)
+					return true
+				}
+				details.Doc = docs[iface]
+				if config.ContextLevel == ContextFile {
+					details.sourceContext = truncateContext(string(src), config.contextMaxBytes())
+					details.sourceFile = filePath
+				}
+				interfaces[details.ref()] = details
 			}
 		}
 		return true
 	})
 
-	return interfaceMethods
+	if config.AnonymousInterfaces {
+		for _, details := range findAnonymousInterfaces(fset, node, config) {
+			if config.ContextLevel == ContextFile {
+				details.sourceContext = truncateContext(string(src), config.contextMaxBytes())
+				details.sourceFile = filePath
+			}
+			interfaces[details.ref()] = details
+		}
+	}
+
+	// Embedded interfaces may be declared in any of the analyzed files. The
+	// file itself is indexed from src, which may differ from the disk copy.
+	indexDeclaredInterfaces(declared, fset, node, pkg)
+
+	known, err := loadKnownInterfaces(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading known interfaces: %w", err)
+	}
+	resolveEmbeddedInterfaces(interfaces, declared, known)
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		iface := interfaces[ref]
+		iface.IsEmpty = !iface.IsConstraint && len(iface.Methods) == 0
+		for i := range iface.Methods {
+			iface.Methods[i].Order = i
+		}
+		iface.Sealed = sealed(iface.Methods)
+		if iface.Sealed {
+			log.Printf("Warning: interface %s at %s has only unexported methods, so only types in package %s can implement it",
+				iface.InterfaceName, formatPosition(iface.pos), iface.Package)
+		}
+	}
+
+	return interfaces, conflicts, nil
 }
 
-// Function to find all types in a directory that implement the detected interfaces
-func findImplementations(dirPath string, interfaceMethods map[string][]string) []InterfaceDetails {
-	var results []InterfaceDetails
+// Function to describe an interface type declared in a file under the
+// given name, with its methods and embeds
+func newInterfaceDetails(fset *token.FileSet, node *ast.File, interfaceType *ast.InterfaceType, name string, typeParams *ast.FieldList, pos token.Position, config *Config) *InterfaceDetails {
+	pkg := packageKey(filepath.Dir(pos.Filename))
+	imports := fileImports(node)
+	details := &InterfaceDetails{
+		InterfaceName: name,
+		Package:       node.Name.Name,
+		pos:           pos,
+		TypeParams:    typeParamsString(fset, typeParams),
+		IsConstraint:  hasTypeElements(interfaceType),
+
+		importPath:     packageImportPath(filepath.Dir(pos.Filename)),
+		typeParamNames: fieldNames(typeParams),
+		imports:        usedImports(node, interfaceType),
+		embedRefs:      embeddedRefs(interfaceType, pkg, imports),
+		packageKey:     pkg,
+	}
+	for _, method := range interfaceType.Methods.List {
+		funcType, ok := method.Type.(*ast.FuncType)
+		if len(method.Names) > 0 && ok { // Make sure the method has a name
+			details.Methods = append(details.Methods, interfaceMethod(fset, method, funcType, details.typeParamNames))
+		} else if len(method.Names) == 0 && !details.IsConstraint {
+			details.Embeds = append(details.Embeds, exprString(fset, method.Type))
+			if _, ok := embeddedRef(method.Type, pkg, imports); !ok {
+				// e.g. a selector of a dot or missing import
+				details.UnresolvedEmbeds = append(details.UnresolvedEmbeds, exprString(fset, method.Type))
+			}
+		}
+	}
+	return details
+}
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+// Function to tell whether an interface's methods are all unexported
+func sealed(methods []MethodDetails) bool {
+	for _, method := range methods {
+		if ast.IsExported(method.Name) {
+			return false
 		}
+	}
+	return len(methods) > 0
+}
+
+// Function to handle another declaration of an interface already found in
+// the same package; interfaces of other packages are separate entries, see
+// InterfaceDetails.ref. A duplicate with the same method set, e.g. one
+// generated twice, is dropped quietly; one with other methods is logged and
+// recorded as a conflict.
+func addDuplicate(conflicts []InterfaceConflict, existing, iface *InterfaceDetails) []InterfaceConflict {
+	if existing.ref() != iface.ref() {
+		return conflicts
+	}
+	differences := methodSetDifferences(existing, iface)
+	if len(differences) == 0 {
+		return conflicts
+	}
+	first, position := formatPosition(existing.pos), formatPosition(iface.pos)
+	log.Printf("Warning: interface %s declared at %s is already declared at %s with other methods (%s); ignoring it",
+		iface.InterfaceName, position, first, strings.Join(differences, ", "))
+	return addConflict(conflicts, iface.InterfaceName, first, position, differences)
+}
+
+// Function to list the methods and embeds that only one of two declarations
+// of an interface has, sorted. A method whose signature differs is listed in
+// both forms. Empty when they declare the same method set.
+func methodSetDifferences(a, b *InterfaceDetails) []string {
+	members := func(iface *InterfaceDetails) map[string]bool {
+		set := make(map[string]bool)
+		for _, method := range iface.Methods {
+			set[method.Signature] = true
+		}
+		for _, embed := range iface.Embeds {
+			set["embeds "+embed] = true
+		}
+		if iface.TypeParams != "" {
+			set["type params "+iface.TypeParams] = true
+		}
+		return set
+	}
+	inA, inB := members(a), members(b)
+	var differences []string
+	for member := range inA {
+		if !inB[member] {
+			differences = append(differences, member)
+		}
+	}
+	for member := range inB {
+		if !inA[member] {
+			differences = append(differences, member)
+		}
+	}
+	sort.Strings(differences)
+	return differences
+}
+
+// Function to record another declaration of an interface name, with the
+// methods it doesn't share with the first. The first position tells apart
+// conflicts of the same name in different packages.
+func addConflict(conflicts []InterfaceConflict, name, first, position string, differences []string) []InterfaceConflict {
+	for i := range conflicts {
+		if conflicts[i].InterfaceName == name && conflicts[i].Positions[0] == first {
+			conflicts[i].Positions = append(conflicts[i].Positions, position)
+			conflicts[i].Differences = mergeDifferences(conflicts[i].Differences, differences)
+			return conflicts
+		}
+	}
+	return append(conflicts, InterfaceConflict{InterfaceName: name, Positions: []string{first, position}, Differences: differences})
+}
+
+// Function to add differences to a sorted list, without repeats
+func mergeDifferences(list, differences []string) []string {
+	for _, difference := range differences {
+		if i := sort.SearchStrings(list, difference); i == len(list) || list[i] != difference {
+			list = append(list[:i], append([]string{difference}, list[i:]...)...)
+		}
+	}
+	return list
+}
+
+// A package-level type declaration of a scanned file
+type typeDeclaration struct {
+	Name    string
+	Package string
+	Doc     string
+	// "struct", "interface", or the underlying type of other types, e.g.
+	// "float64" or "= Bar" for an alias
+	Kind string
+	// Signatures of the package's New functions returning the type, e.g.
+	// "NewStore(dsn string) (*Store, error)"
+	Constructors []string
+	pos          token.Position
+}
 
-		// Only process Go files
-		if strings.HasSuffix(info.Name(), ".go") {
-			fset := token.NewFileSet()
+// Function to describe what kind of type a type spec declares
+func typeKind(fset *token.FileSet, typeSpec *ast.TypeSpec) string {
+	switch typeSpec.Type.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	}
+	if typeSpec.Assign.IsValid() {
+		return "= " + exprString(fset, typeSpec.Type)
+	}
+	return exprString(fset, typeSpec.Type)
+}
 
-			node, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
-			if err != nil {
-				log.Printf("Error parsing Go file %s: %v", path, err)
-				return nil
+// A New function of a scanned file and the type it constructs
+type constructorDeclaration struct {
+	Type      string
+	Signature string
+	dir       string
+}
+
+// Function to tell New and NewT function names from the likes of Newline
+func isConstructorName(name string) bool {
+	rest, ok := strings.CutPrefix(name, "New")
+	return ok && (rest == "" || unicode.IsUpper([]rune(rest)[0]))
+}
+
+// Function to attach the constructors to the types they return in the
+// same package directory
+func attachConstructors(types []typeDeclaration, constructors []constructorDeclaration) {
+	byType := make(map[string][]string)
+	for _, constructor := range constructors {
+		key := constructor.dir + "\x00" + constructor.Type
+		byType[key] = append(byType[key], constructor.Signature)
+	}
+	for i := range types {
+		types[i].Constructors = byType[filepath.Dir(types[i].pos.Filename)+"\x00"+types[i].Name]
+	}
+}
+
+// Function to find all types under the root directories that implement the
+// detected interfaces, exiting on errors; see scanImplementations
+func findImplementations(ctx context.Context, roots []string, interfaces map[interfaceRef]*InterfaceDetails, config *Config) ([]InterfaceDetails, []typeDeclaration, []ValueDeclaration) {
+	results, types, values, err := scanImplementations(ctx, roots, interfaces, config)
+	if err != nil {
+		fatalf(exitCode(err), "Error scanning for implementations: %v", err)
+	}
+	return results, types, values
+}
+
+// Function to note a scanned Go file that couldn't be read or parsed, and so
+// was skipped, for the report and -strict
+func (c *Config) skipFile(path string, err error) {
+	c.Metrics.parseError()
+	c.skippedFiles = append(c.skippedFiles, FileError{File: filepath.ToSlash(path), Error: err.Error()})
+}
+
+// Function to find all types under the root directories that implement the
+// detected interfaces, merging the matches of every root. It also returns
+// every package-level type and the exported constants and variables
+// declared in the scanned files. When ctx is done, the matches of the files
+// scanned so far are returned without an error.
+func scanImplementations(ctx context.Context, roots []string, interfaces map[interfaceRef]*InterfaceDetails, config *Config) ([]InterfaceDetails, []typeDeclaration, []ValueDeclaration, error) {
+	known, err := loadKnownInterfaces(config)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("loading known interfaces: %w", err)
+	}
+	// Only the files this scan skips are reported
+	config.skippedFiles = nil
+	config.limitedFiles = nil
+	patterns, err := config.testDoublePatterns()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid test_double_patterns: %w", err)
+	}
+
+	var types []typeDeclaration
+	var values []ValueDeclaration
+	var constructors []constructorDeclaration
+
+	var progress *progressReporter
+	if config.Progress {
+		progress = startProgress(500 * time.Millisecond)
+		defer progress.stop()
+	}
+
+	// Match the types of one parsed file against the interfaces
+	packageKeys := make(map[string]string)
+	scanFile := func(fset *token.FileSet, node *ast.File, src []byte) {
+		dir := filepath.Dir(fset.Position(node.Pos()).Filename)
+		pkg, ok := packageKeys[dir]
+		if !ok {
+			pkg = packageKey(dir)
+			packageKeys[dir] = pkg
+		}
+
+		// Record where the interfaces are consumed
+		collectUsages(fset, node, pkg, interfaces)
+
+		imports := fileImports(node)
+		// Interfaces embedded in structs delegate their methods to the field
+		resolve := func(expr ast.Expr) *InterfaceDetails {
+			if ref, ok := embeddedRef(expr, pkg, imports); ok {
+				return interfaces[ref]
 			}
+			return nil
+		}
 
-			// Traverse the file to find type declarations and methods
-			ast.Inspect(node, func(n ast.Node) bool {
-				if typeSpec, ok := n.(*ast.TypeSpec); ok {
-					if _, ok := typeSpec.Type.(*ast.StructType); ok {
-						typeName := typeSpec.Name.Name
-						methods := getMethodsForType(node, typeName)
-
-						// Check if this type implements any interface
-						for iface, ifaceMethods := range interfaceMethods {
-							if implementsInterface(ifaceMethods, methods) {
-								// Add the implementation to the result
-								found := false
-								for i, detail := range results {
-									if detail.InterfaceName == iface {
-										results[i].Implementations = append(results[i].Implementations, typeName)
-										found = true
-										break
-									}
-								}
-								if !found {
-									results = append(results, InterfaceDetails{
-										InterfaceName:   iface,
-										Methods:         ifaceMethods,
-										Implementations: []string{typeName},
-									})
-								}
-							}
-						}
-					}
+		docs := typeDocs(node)
+		for _, decl := range node.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok == token.CONST || decl.Tok == token.VAR {
+					values = append(values, valueDeclarations(fset, node.Name.Name, decl)...)
+					continue
+				}
+				if decl.Tok != token.TYPE {
+					continue
 				}
+				for _, spec := range decl.Specs {
+					typeSpec := spec.(*ast.TypeSpec)
+					types = append(types, typeDeclaration{
+						Name:    typeSpec.Name.Name,
+						Package: node.Name.Name,
+						Doc:     docs[typeSpec],
+						Kind:    typeKind(fset, typeSpec),
+						pos:     fset.Position(typeSpec.Pos()),
+					})
+				}
+			case *ast.FuncDecl:
+				// NewT functions construct the type of their first result
+				if decl.Recv != nil || !isConstructorName(decl.Name.Name) || decl.Type.Results == nil {
+					continue
+				}
+				if name, _ := receiverTypeName(decl.Type.Results.List[0].Type); name != "" {
+					constructors = append(constructors, constructorDeclaration{
+						Type:      name,
+						Signature: methodDetails(fset, decl.Name.Name, decl.Type).Signature,
+						dir:       filepath.Dir(fset.Position(decl.Pos()).Filename),
+					})
+				}
+			}
+		}
+
+		// Traverse the file to find type declarations and methods
+		ast.Inspect(node, func(n ast.Node) bool {
+			typeSpec, ok := n.(*ast.TypeSpec)
+			if !ok {
 				return true
+			}
+			// Interfaces have no methods of their own; any other type, be it
+			// a struct, a named basic, func or map type or an alias, may
+			// implement the interfaces
+			if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				return true
+			}
+			typeName := typeSpec.Name.Name
+			if config.ExportedOnly && !ast.IsExported(typeName) {
+				return true
+			}
+			methods := getMethodsForType(fset, node, typeName, config)
+
+			// Methods declared on an alias belong to the aliased type, so an
+			// alias of a local type has the methods of both names
+			var aliasOf string
+			if typeSpec.Assign.IsValid() {
+				aliasOf = exprString(fset, typeSpec.Type)
+				if target, ok := typeSpec.Type.(*ast.Ident); ok {
+					methods = append(methods, getMethodsForType(fset, node, target.Name, config)...)
+				}
+			}
+			var fields []FieldDetails
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				fields = structFields(fset, structType)
+				methods = withDelegatedMethods(fset, node, structType, methods, resolve, config)
+			}
+			stdlib := matchKnownInterfaces(known, methods)
+
+			// Check if this type implements any interface
+			for _, iface := range interfaces {
+				// Constraint interfaces can't be implemented by a type, and
+				// listing every type under an empty interface is noise
+				if iface.IsConstraint || iface.IsEmpty {
+					continue
+				}
+				satisfaction := receiverSatisfaction(iface.Methods, methods)
+				if satisfaction == "" {
+					if mismatches := signatureMismatches(iface.Methods, methods); len(mismatches) > 0 {
+						iface.NearMisses = append(iface.NearMisses, NearMiss{
+							TypeName:   typeName,
+							Package:    node.Name.Name,
+							Position:   formatPosition(fset.Position(typeSpec.Pos())),
+							Mismatches: mismatches,
+						})
+					} else if config.Debug {
+						logMissingMethods(iface, typeName, fset.Position(typeSpec.Pos()), methods)
+					}
+					continue
+				}
+				// The first implementation found serves as the representative one
+				if config.ContextLevel == ContextBodies && iface.sourceContext == "" {
+					iface.sourceContext = truncateContext(methodSources(fset, src, methods, iface.Methods), config.contextMaxBytes())
+				}
+				implemented := implementedMethods(iface.Methods, methods)
+				delegates := delegateFields(implemented)
+				iface.Implementations = append(iface.Implementations, Implementation{
+					TypeName:             typeName,
+					Package:              node.Name.Name,
+					TypeParams:           typeParamsString(fset, typeSpec.TypeParams),
+					Doc:                  docs[typeSpec],
+					AliasOf:              aliasOf,
+					ReceiverSatisfaction: satisfaction,
+					StdlibInterfaces:     stdlib,
+					Fields:               fields,
+					Partial:              len(iface.UnresolvedEmbeds) > 0,
+					Methods:              implemented,
+					Delegated:            len(delegates) > 0,
+					DelegateFields:       delegates,
+					pos:                  fset.Position(typeSpec.Pos()),
+				})
+			}
+			return true
+		})
+	}
+
+	// Roots may overlap, e.g. when packages match the same files from two
+	// of them; scan each file once
+	scanned := make(map[string]bool)
+	firstScan := func(path string) bool {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if scanned[abs] {
+			return false
+		}
+		scanned[abs] = true
+		return true
+	}
+
+	for _, dirPath := range roots {
+		if len(config.Packages) > 0 {
+			// Resolve the packages through the go tool instead of walking dirPath
+			err = loadPackageFiles(ctx, dirPath, config, func(fset *token.FileSet, node *ast.File, src []byte) {
+				if !firstScan(fset.File(node.Pos()).Name()) {
+					return
+				}
+				defer progress.increment()
+				config.Metrics.fileParsed()
+				scanFile(fset, node, src)
 			})
+		} else {
+			err = walkGoFiles(ctx, dirPath, config, func(path string) {
+				if !firstScan(path) {
+					return
+				}
+				defer progress.increment()
+
+				fset := token.NewFileSet()
+
+				src, err := os.ReadFile(path)
+				if err != nil {
+					log.Printf("Error reading Go file %s: %v", path, err)
+					config.skipFile(path, err)
+					return
+				}
+
+				node, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+				if err != nil {
+					log.Printf("Error parsing Go file %s: %v", path, err)
+					config.skipFile(path, err)
+					return
+				}
+				config.Metrics.fileParsed()
+				scanFile(fset, node, src)
+			})
+		}
+		if err != nil {
+			break
 		}
-		return nil
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// Interrupted: report what was matched in the files scanned so far
+		log.Printf("Scan interrupted, reporting partial results: %v", err)
+	case err != nil:
+		return nil, nil, nil, err
+	}
+
+	// Report every interface, ordered by name
+	var results []InterfaceDetails
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		results = append(results, *interfaces[ref])
+	}
+	attachConstructors(types, constructors)
+	classifyImplementations(results, patterns)
+	return results, types, values, nil
+}
+
+// Function to list the keys of interfaces in a stable order: by name, then
+// by package
+func sortedInterfaceRefs(interfaces map[interfaceRef]*InterfaceDetails) []interfaceRef {
+	refs := make([]interfaceRef, 0, len(interfaces))
+	for ref := range interfaces {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].name != refs[j].name {
+			return refs[i].name < refs[j].name
+		}
+		return refs[i].pkg < refs[j].pkg
 	})
+	return refs
+}
 
-	if err != nil {
-		log.Fatalf("Error walking directory: %v", err)
+// Function to list the names of interfaces in the order of
+// sortedInterfaceRefs; a name declared in several packages is repeated
+func sortedInterfaceNames(interfaces map[interfaceRef]*InterfaceDetails) []string {
+	var names []string
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		names = append(names, ref.name)
 	}
+	return names
+}
 
-	return results
+// Function to get the key of an interface in a map of interfaces: its
+// package and name, as packages may declare interfaces of the same name
+func (iface *InterfaceDetails) ref() interfaceRef {
+	return interfaceRef{pkg: iface.packageKey, name: iface.InterfaceName}
 }
 
-// Function to get methods for a specific type (e.g., a struct)
-func getMethodsForType(file *ast.File, typeName string) []string {
-	var methods []string
+// Function to get methods for a specific type (e.g., a struct or a named func type),
+// leaving out the unexported ones with ignore_unexported_methods
+func getMethodsForType(fset *token.FileSet, file *ast.File, typeName string, config *Config) []typeMethod {
+	var methods []typeMethod
 
 	// Traverse the file and collect methods for the given type
 	ast.Inspect(file, func(n ast.Node) bool {
@@ -159,12 +789,17 @@ func getMethodsForType(file *ast.File, typeName string) []string {
 			if fn.Recv != nil {
 				for _, field := range fn.Recv.List {
 					// Get the type name of the receiver (pointer or non-pointer)
-					if starExpr, ok := field.Type.(*ast.StarExpr); ok {
-						if ident, ok := starExpr.X.(*ast.Ident); ok && ident.Name == typeName {
-							methods = append(methods, fn.Name.Name)
-						}
-					} else if ident, ok := field.Type.(*ast.Ident); ok && ident.Name == typeName {
-						methods = append(methods, fn.Name.Name)
+					name, pointer := receiverTypeName(field.Type)
+					if name == typeName && (!config.IgnoreUnexportedMethods || ast.IsExported(fn.Name.Name)) {
+						details := methodDetails(fset, fn.Name.Name, fn.Type)
+						details.Doc = commentText(fn.Doc)
+						details.typeParams = receiverTypeParams(field.Type)
+						details.pos = fset.Position(fn.Pos())
+						methods = append(methods, typeMethod{
+							MethodDetails:   details,
+							PointerReceiver: pointer,
+							decl:            fn,
+						})
 					}
 				}
 			}
@@ -175,94 +810,169 @@ func getMethodsForType(file *ast.File, typeName string) []string {
 	return methods
 }
 
-// Function to check if a type implements an interface
-func implementsInterface(ifaceMethods, typeMethods []string) bool {
-	methodSet := make(map[string]bool)
+// Function to get the base type name of a receiver such as T, *T or *T[K, V]
+func receiverTypeName(expr ast.Expr) (string, bool) {
+	pointer := false
+	if starExpr, ok := expr.(*ast.StarExpr); ok {
+		expr = starExpr.X
+		pointer = true
+	}
+
+	// Generic receivers carry their type parameters as index expressions
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		expr = index.X
+	case *ast.IndexListExpr:
+		expr = index.X
+	}
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name, pointer
+	}
+	return "", false
+}
+
+// Function to get the type parameter names of a generic receiver, e.g. K
+// and V of *T[K, V]
+func receiverTypeParams(expr ast.Expr) []string {
+	if starExpr, ok := expr.(*ast.StarExpr); ok {
+		expr = starExpr.X
+	}
+	var indices []ast.Expr
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		indices = []ast.Expr{index.Index}
+	case *ast.IndexListExpr:
+		indices = index.Indices
+	}
+	var names []string
+	for _, index := range indices {
+		if ident, ok := index.(*ast.Ident); ok && ident.Name != "_" {
+			names = append(names, ident.Name)
+		}
+	}
+	return names
+}
+
+// Function to check if a type implements an interface. Methods match on name
+// and parameter and result types, see sameMethodTypes, so generic methods
+// match whatever the type parameters are called.
+func implementsInterface(ifaceMethods []MethodDetails, typeMethods []typeMethod) bool {
+	methodSet := make(map[string]MethodDetails)
 	for _, method := range typeMethods {
-		methodSet[method] = true
+		methodSet[method.Name] = method.MethodDetails
 	}
 
 	for _, ifaceMethod := range ifaceMethods {
-		if !methodSet[ifaceMethod] {
+		method, ok := methodSet[ifaceMethod.Name]
+		if !ok || !sameMethodTypes(ifaceMethod, method) {
 			return false
 		}
 	}
 	return true
 }
-// Function to send the data via HTTP to OpenAI API
-func sendData(apiKey string, results []InterfaceDetails) {
-	// Convert the results to a user message
-	userMessageContent := formatResultsForMessage(results)
 
-	// Construct the JSON payload for the API
-	payload := map[string]interface{}{
-		"model": "gpt-4", // You can adjust the model if needed
-		"messages": []map[string]string{
-			{
-				"role":    "user",
-				"content": userMessageContent,
-			},
-		},
+// Function to list the methods of an interface, by name, that a type
+// doesn't have at all, in interface order
+func missingMethods(ifaceMethods, typeMethods []string) []string {
+	has := make(map[string]bool)
+	for _, name := range typeMethods {
+		has[name] = true
 	}
-
-	// Convert the payload to JSON
-	data, err := json.Marshal(payload)
-	if err != nil {
-		log.Fatalf("Error marshaling results: %v", err)
+	var missing []string
+	for _, name := range ifaceMethods {
+		if !has[name] {
+			missing = append(missing, name)
+		}
 	}
+	return missing
+}
 
-	// Define the API endpoint to which you will send the data
-	url := "https://api.openai.com/v1/chat/completions"
-
-	// Prepare the HTTP request with the API key in the headers
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
-	if err != nil {
-		log.Fatalf("Error creating request: %v", err)
+// Function to explain, with -debug, why a type that has at least half of
+// the method names of an interface doesn't implement it. Types with fewer
+// are not candidates and are left out, as nearly every type would be.
+func logMissingMethods(iface *InterfaceDetails, typeName string, pos token.Position, methods []typeMethod) {
+	var ifaceNames, typeNames []string
+	for _, method := range iface.Methods {
+		ifaceNames = append(ifaceNames, method.Name)
 	}
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-	req.Header.Set("Content-Type", "application/json")
-
-	// Execute the HTTP request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		log.Fatalf("Error sending request: %v", err)
+	for _, method := range methods {
+		typeNames = append(typeNames, method.Name)
 	}
-	defer resp.Body.Close()
-
-	// Check the response status
-	if resp.StatusCode == http.StatusOK {
-		fmt.Println("Data sent successfully!")
-	} else {
-		fmt.Printf("Failed to send data. Status code: %d\n", resp.StatusCode)
+	missing := missingMethods(ifaceNames, typeNames)
+	if len(missing) == 0 || 2*len(missing) > len(ifaceNames) {
+		return
 	}
+	log.Printf("Debug: %s (%s) doesn't implement %s: missing %s", typeName, formatPosition(pos), iface.InterfaceName, strings.Join(missing, ", "))
 }
 
-// Helper function to format the results as a message for OpenAI API
-func formatResultsForMessage(results []InterfaceDetails) string {
-	message := "Here are the interfaces and their implementations:\n"
-	for _, result := range results {
-		message += fmt.Sprintf("Interface: %s\nMethods: %v\nImplementations: %v\n\n", result.InterfaceName, result.Methods, result.Implementations)
+// Function to explain why a type that has every method name of an
+// interface doesn't implement it: the methods whose parameter or result
+// types differ. Returns nil when some method name is missing altogether.
+func signatureMismatches(ifaceMethods []MethodDetails, typeMethods []typeMethod) []SignatureMismatch {
+	byName := make(map[string]MethodDetails)
+	for _, method := range typeMethods {
+		byName[method.Name] = method.MethodDetails
+	}
+
+	var mismatches []SignatureMismatch
+	for _, ifaceMethod := range ifaceMethods {
+		method, ok := byName[ifaceMethod.Name]
+		if !ok {
+			return nil
+		}
+		if !sameMethodTypes(ifaceMethod, method) {
+			mismatches = append(mismatches, SignatureMismatch{
+				Method:             ifaceMethod.Name,
+				InterfaceSignature: ifaceMethod.Signature,
+				TypeSignature:      method.Signature,
+			})
+		}
 	}
-	return message
+	return mismatches
 }
-// Function to read YAML config
-func readConfig(path string) (*Config, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
+
+// Function to locate the type's method for each interface method, in the
+// order of the interface rather than the order the type declares them
+func implementedMethods(ifaceMethods []MethodDetails, typeMethods []typeMethod) []ImplementedMethod {
+	byName := make(map[string]typeMethod)
+	for _, method := range typeMethods {
+		byName[method.Name] = method
 	}
-	defer file.Close()
 
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		return nil, err
+	var implemented []ImplementedMethod
+	for _, ifaceMethod := range ifaceMethods {
+		if method, ok := byName[ifaceMethod.Name]; ok {
+			implemented = append(implemented, ImplementedMethod{
+				Name:        method.Name,
+				Order:       ifaceMethod.Order,
+				Position:    formatPosition(method.pos),
+				Documented:  method.Doc != "",
+				DelegatedTo: method.delegatedTo,
+				Overrides:   method.overrides,
+				pos:         method.pos,
+			})
+		}
 	}
+	return implemented
+}
 
-	var config Config
-	if err := yaml.Unmarshal(bytes, &config); err != nil {
-		return nil, err
+// Function to work out whether T or only *T satisfies an interface.
+// Value receiver methods belong to both T and *T, pointer receiver methods
+// only to *T. An empty result means the interface is not satisfied at all.
+func receiverSatisfaction(ifaceMethods []MethodDetails, typeMethods []typeMethod) string {
+	var valueMethods []typeMethod
+	for _, method := range typeMethods {
+		if !method.PointerReceiver {
+			valueMethods = append(valueMethods, method)
+		}
 	}
 
-	return &config, nil
-}
\ No newline at end of file
+	switch {
+	case implementsInterface(ifaceMethods, valueMethods):
+		return SatisfiedByBoth
+	case implementsInterface(ifaceMethods, typeMethods):
+		return SatisfiedByPointer
+	}
+	return ""
+}
@@ -0,0 +1,450 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPackageNames(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface{ Get(id string) string }\n")
+	for _, pkg := range []string{"memory", "disk"} {
+		writeFile(t, filepath.Join(root, pkg, "store.go"), "package "+pkg+"\n\ntype Store struct{}\n\nfunc (Store) Get(id string) string { return id }\n")
+	}
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+	if len(results) != 1 || results[0].Package != "api" {
+		t.Fatalf("results = %+v, want Store of package api", results)
+	}
+
+	var impls []string
+	for _, impl := range results[0].Implementations {
+		impls = append(impls, impl.Package+"."+impl.TypeName)
+	}
+	sort.Strings(impls)
+	if strings.Join(impls, ",") != "disk.Store,memory.Store" {
+		t.Errorf("implementations = %v, want disk.Store and memory.Store", impls)
+	}
+
+	message := formatResultsForMessage(results)
+	if !strings.Contains(message, "Package: api") || !strings.Contains(message, "memory.Store") {
+		t.Errorf("message doesn't name the packages:\n%s", message)
+	}
+}
+
+func TestNonStructImplementations(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Stringer interface{ String() string }\n")
+	writeFile(t, filepath.Join(root, "impl", "impl.go"), `package impl
+
+type Celsius float64
+
+func (c Celsius) String() string { return "" }
+
+type HandlerFunc func()
+
+func (f *HandlerFunc) String() string { return "" }
+
+type Labels map[string]string
+
+func (Labels) String() string { return "" }
+
+type Temp = Celsius
+
+type Kelvin = float64
+
+type Node struct{}
+
+func (*Node) String() string { return "" }
+
+type Named = Node
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+
+	var impls []string
+	for _, impl := range results[0].Implementations {
+		name := impl.TypeName
+		if impl.ReceiverSatisfaction == SatisfiedByPointer {
+			name = "*" + name
+		}
+		if impl.AliasOf != "" {
+			name += "=" + impl.AliasOf
+		}
+		impls = append(impls, name)
+	}
+	want := "Celsius,*HandlerFunc,Labels,Temp=Celsius,*Node,*Named=Node"
+	if strings.Join(impls, ",") != want {
+		t.Errorf("implementations = %s, want %s", strings.Join(impls, ","), want)
+	}
+}
+
+func TestReceiverSatisfaction(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"value receivers", "type Mem struct{}\n\nfunc (Mem) Get(id string) string { return id }\n\nfunc (Mem) Put(id string) {}\n", SatisfiedByBoth},
+		{"pointer receivers", "type Mem struct{}\n\nfunc (*Mem) Get(id string) string { return id }\n\nfunc (*Mem) Put(id string) {}\n", SatisfiedByPointer},
+		{"mixed receivers", "type Mem struct{}\n\nfunc (Mem) Get(id string) string { return id }\n\nfunc (*Mem) Put(id string) {}\n", SatisfiedByPointer},
+		{"embedded pointer", "type Base struct{}\n\nfunc (*Base) Get(id string) string { return id }\n\nfunc (*Base) Put(id string) {}\n\ntype Mem struct{ *Base }\n", SatisfiedByBoth},
+		{"embedded value", "type Base struct{}\n\nfunc (*Base) Get(id string) string { return id }\n\nfunc (Base) Put(id string) {}\n\ntype Mem struct{ Base }\n", SatisfiedByPointer},
+		{"embedded pointer with a pointer receiver override", "type Base struct{}\n\nfunc (Base) Get(id string) string { return id }\n\nfunc (Base) Put(id string) {}\n\ntype Mem struct{ *Base }\n\nfunc (*Mem) Put(id string) {}\n", SatisfiedByPointer},
+		{"embedded interface", "type Mem struct{ api.Store }\n", SatisfiedByBoth},
+		{"embedded interface with a pointer receiver override", "type Mem struct{ api.Store }\n\nfunc (*Mem) Get(id string) string { return id }\n", SatisfiedByPointer},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+			ifacePath := filepath.Join(root, "api", "api.go")
+			writeFile(t, ifacePath, "package api\n\ntype Store interface {\n\tGet(id string) string\n\tPut(id string)\n}\n")
+			writeFile(t, filepath.Join(root, "mem", "mem.go"), "package mem\n\nimport \"example.com/app/api\"\n\nvar _ api.Store = nil\n\n"+test.src)
+
+			config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+			interfaces, _ := findInterfaces(ifacePath, config)
+			results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+
+			var got []string
+			for _, impl := range results[0].Implementations {
+				if impl.TypeName == "Mem" {
+					got = append(got, impl.ReceiverSatisfaction)
+				}
+			}
+			if len(got) != 1 || got[0] != test.want {
+				t.Errorf("satisfaction of Mem = %v, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSealedInterfaces(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+type Token interface{ isToken() }
+
+type Node interface {
+	Pos() int
+	node()
+}
+
+type base interface{ sealed() }
+
+type Expr interface{ base }
+`)
+
+	config := &Config{GoFilePath: ifacePath}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	var sealedNames []string
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		if interfaces[ref].Sealed {
+			sealedNames = append(sealedNames, ref.name)
+		}
+	}
+	// Expr gets its only method from base
+	if strings.Join(sealedNames, ",") != "Expr,Token,base" {
+		t.Errorf("sealed = %v, want Expr, Token and base", sealedNames)
+	}
+
+	markdown := renderMarkdown([]InterfaceDetails{*interfaceNamed(interfaces, "Token")}, nil)
+	if !strings.Contains(markdown, "only types in package `api` can implement it") {
+		t.Errorf("markdown doesn't flag the sealed interface:\n%s", markdown)
+	}
+}
+
+func TestIgnoreUnexportedMethods(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+type Node interface {
+	Pos() int
+	node()
+}
+
+type Named interface{ Name() string }
+
+type Ident struct{}
+
+func (Ident) Pos() int      { return 0 }
+func (Ident) node()         {}
+func (Ident) Name() string  { return "" }
+func (Ident) resolve() bool { return false }
+`)
+
+	implemented := func(config Config) string {
+		report, err := Analyze(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, result := range report.Interfaces {
+			if len(result.Implementations) > 0 {
+				names = append(names, result.InterfaceName)
+			}
+		}
+		return strings.Join(names, ",")
+	}
+	config := Config{GoFilePath: ifacePath, GoDirectory: root}
+	if got := implemented(config); got != "Named,Node" {
+		t.Errorf("implemented = %s, want Named and Node", got)
+	}
+	// node() no longer counts, so Ident doesn't implement the sealed Node
+	config.IgnoreUnexportedMethods = true
+	if got := implemented(config); got != "Named" {
+		t.Errorf("implemented with ignore_unexported_methods = %s, want Named", got)
+	}
+}
+
+func TestMethodOrder(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+type Closer interface{ Close() error }
+
+type Store interface {
+	Put(key, value string)
+	Closer
+	Get(key string) string
+	Delete(key string)
+}
+`)
+	// Methods of two types interleaved, in another order than the interface
+	writeFile(t, filepath.Join(root, "impl", "impl.go"), `package impl
+
+type Disk struct{}
+type Memory struct{}
+
+func (Memory) Close() error          { return nil }
+func (Disk) Delete(key string)       {}
+func (Memory) Get(key string) string { return "" }
+func (Disk) Close() error            { return nil }
+func (Memory) Delete(key string)     {}
+func (Disk) Get(key string) string   { return "" }
+func (Memory) Put(key, value string) {}
+func (Disk) Put(key, value string)   {}
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	store := interfaceNamed(interfaces, "Store")
+	var declared []string
+	for _, method := range store.Methods {
+		declared = append(declared, fmt.Sprintf("%d:%s", method.Order, method.Name))
+	}
+	// Own methods as declared, then the embedded Close
+	if got := strings.Join(declared, " "); got != "0:Put 1:Get 2:Delete 3:Close" {
+		t.Errorf("interface methods = %s", got)
+	}
+
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+	for _, result := range results {
+		if result.InterfaceName != "Store" {
+			continue
+		}
+		if len(result.Implementations) != 2 {
+			t.Fatalf("implementations = %+v, want Disk and Memory", result.Implementations)
+		}
+		for _, impl := range result.Implementations {
+			var names []string
+			for _, method := range impl.Methods {
+				names = append(names, fmt.Sprintf("%d:%s", method.Order, method.Name))
+			}
+			if got := strings.Join(names, " "); got != "0:Put 1:Get 2:Delete 3:Close" {
+				t.Errorf("methods of %s = %s, want the interface order", impl.TypeName, got)
+			}
+		}
+
+		// Rendering follows Order even if the methods were shuffled
+		shuffled := result
+		shuffled.Methods = []MethodDetails{result.Methods[2], result.Methods[0], result.Methods[3], result.Methods[1]}
+		markdown := renderMarkdown([]InterfaceDetails{shuffled}, nil)
+		put, get, del, closeAt := strings.Index(markdown, "`Put("), strings.Index(markdown, "`Get("), strings.Index(markdown, "`Delete("), strings.Index(markdown, "`Close(")
+		if !(put < get && get < del && del < closeAt) {
+			t.Errorf("markdown lists the methods out of order:\n%s", markdown)
+		}
+	}
+}
+
+func TestNearMisses(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+type Store interface {
+	Get(id string) (string, error)
+	Put(id, value string) error
+}
+`)
+	writeFile(t, filepath.Join(root, "impl", "impl.go"), `package impl
+
+// Get lacks the error result
+type Cache struct{}
+
+func (Cache) Get(id string) string       { return "" }
+func (Cache) Put(id, value string) error { return nil }
+
+// Missing Put altogether: not a near miss
+type Reader struct{}
+
+func (Reader) Get(id string) string { return "" }
+
+type Memory struct{}
+
+func (*Memory) Get(id string) (string, error) { return "", nil }
+func (*Memory) Put(id, value string) error    { return nil }
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+	store := results[0]
+	if len(store.Implementations) != 1 || store.Implementations[0].TypeName != "Memory" {
+		t.Errorf("implementations = %+v, want Memory", store.Implementations)
+	}
+	if len(store.NearMisses) != 1 {
+		t.Fatalf("near misses = %+v, want Cache", store.NearMisses)
+	}
+	miss := store.NearMisses[0]
+	want := SignatureMismatch{Method: "Get", InterfaceSignature: "Get(id string) (string, error)", TypeSignature: "Get(id string) string"}
+	if miss.TypeName != "Cache" || len(miss.Mismatches) != 1 || miss.Mismatches[0] != want {
+		t.Errorf("near miss = %+v, want Cache with %+v", miss, want)
+	}
+	if summary := summarize(results, 0); summary.NearMisses != 1 {
+		t.Errorf("summary near misses = %d", summary.NearMisses)
+	}
+	if markdown := renderMarkdown(results, nil); !strings.Contains(markdown, "`Get` has `Get(id string) string`, the interface wants `Get(id string) (string, error)`") {
+		t.Errorf("markdown doesn't explain the near miss:\n%s", markdown)
+	}
+}
+
+func TestNearMissesWithSameArity(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, `package api
+
+type Item struct{}
+
+type Store interface {
+	Get(id string) (string, error)
+	Find(id string) Item
+}
+`)
+	writeFile(t, filepath.Join(root, "impl", "impl.go"), `package impl
+
+import "example.com/api"
+
+// As many parameters and results, of other types
+type IntStore struct{}
+
+func (IntStore) Get(id int) (int, bool) { return 0, false }
+func (IntStore) Find(id string) api.Item { return api.Item{} }
+
+// Item qualified with its package matches the interface's Item
+type Disk struct{}
+
+func (Disk) Get(key string) (string, error) { return "", nil }
+func (Disk) Find(key string) api.Item       { return api.Item{} }
+
+// T stands for any type
+type Box[T any] struct{}
+
+func (Box[T]) Get(id string) (T, error) { var v T; return v, nil }
+func (Box[T]) Find(id string) T         { var v T; return v }
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+	store := results[0]
+	var names []string
+	for _, impl := range store.Implementations {
+		names = append(names, impl.TypeName)
+	}
+	if got := strings.Join(names, ","); got != "Disk,Box" {
+		t.Errorf("implementations = %s, want Disk and Box", got)
+	}
+	want := SignatureMismatch{Method: "Get", InterfaceSignature: "Get(id string) (string, error)", TypeSignature: "Get(id int) (int, bool)"}
+	if len(store.NearMisses) != 1 || store.NearMisses[0].TypeName != "IntStore" || len(store.NearMisses[0].Mismatches) != 1 || store.NearMisses[0].Mismatches[0] != want {
+		t.Errorf("near misses = %+v, want IntStore with %+v", store.NearMisses, want)
+	}
+}
+
+func TestMissingMethods(t *testing.T) {
+	if got := missingMethods([]string{"Get", "Put", "Delete"}, []string{"Put", "Close"}); !reflect.DeepEqual(got, []string{"Get", "Delete"}) {
+		t.Errorf("missing = %q, want Get and Delete", got)
+	}
+	if got := missingMethods([]string{"Get"}, []string{"Get", "Put"}); got != nil {
+		t.Errorf("missing = %q, want none", got)
+	}
+}
+
+func TestDebugLogsMissingMethods(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api.go")
+	writeFile(t, ifacePath, `package api
+
+type Store interface {
+	Get(id string) (string, error)
+	Put(id, value string) error
+}
+`)
+	writeFile(t, filepath.Join(root, "impl.go"), `package api
+
+type Reader struct{}
+
+func (Reader) Get(id string) (string, error) { return "", nil }
+
+type Clock struct{}
+
+func (Clock) Now() int { return 0 }
+`)
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	findImplementations(context.Background(), []string{root}, interfaces, config)
+	if strings.Contains(logs.String(), "Debug:") {
+		t.Errorf("logged without -debug: %q", logs.String())
+	}
+
+	config.Debug = true
+	findImplementations(context.Background(), []string{root}, interfaces, config)
+	if !strings.Contains(logs.String(), "Debug: Reader ("+filepath.Join(root, "impl.go")+":3) doesn't implement Store: missing Put") {
+		t.Errorf("logs = %q, want Reader's missing Put", logs.String())
+	}
+	// Clock has none of the methods, so it isn't a candidate
+	if strings.Contains(logs.String(), "Clock") {
+		t.Errorf("logs = %q, want Clock left out", logs.String())
+	}
+}
+
+// Function to look an interface up by name, whatever its package
+func interfaceNamed(interfaces map[interfaceRef]*InterfaceDetails, name string) *InterfaceDetails {
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		if ref.name == name {
+			return interfaces[ref]
+		}
+	}
+	return nil
+}
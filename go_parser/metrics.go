@@ -0,0 +1,129 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const defaultMetricsNamespace = "go_documentator"
+
+// metricsRecorder holds the Prometheus metrics of a run. All methods are safe
+// to call on a nil recorder, which is what runs without -metrics-addr use.
+// Collectors update atomically, so scraping never waits for a scan.
+type metricsRecorder struct {
+	scans           prometheus.Counter
+	filesParsed     prometheus.Counter
+	parseErrors     prometheus.Counter
+	interfacesFound prometheus.Counter
+	llmRequests     *prometheus.CounterVec
+	llmTokens       *prometheus.CounterVec
+	scanDuration    prometheus.Histogram
+	requestLatency  prometheus.Histogram
+
+	// Address the server listens on, which has the port picked for :0
+	addr net.Addr
+}
+
+// Function to create the metrics under a namespace and register them
+func newMetricsRecorder(namespace string, registry prometheus.Registerer) *metricsRecorder {
+	if namespace == "" {
+		namespace = defaultMetricsNamespace
+	}
+	m := &metricsRecorder{
+		scans: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "scans_total", Help: "Scans performed.",
+		}),
+		filesParsed: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "files_parsed_total", Help: "Go files parsed.",
+		}),
+		parseErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "parse_errors_total", Help: "Go files that could not be read or parsed.",
+		}),
+		interfacesFound: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Name: "interfaces_found_total", Help: "Interfaces reported by scans.",
+		}),
+		llmRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "llm_requests_total", Help: "Requests sent to the LLM API, by response status.",
+		}, []string{"status"}),
+		llmTokens: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "llm_tokens_total", Help: "Tokens used by LLM requests.",
+		}, []string{"kind"}),
+		scanDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "scan_duration_seconds", Help: "Duration of scans.",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+		}),
+		requestLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "llm_request_duration_seconds", Help: "Latency of LLM API requests.",
+			Buckets: prometheus.ExponentialBuckets(0.25, 2, 10),
+		}),
+	}
+	registry.MustRegister(m.scans, m.filesParsed, m.parseErrors, m.interfacesFound,
+		m.llmRequests, m.llmTokens, m.scanDuration, m.requestLatency)
+	return m
+}
+
+// Function to serve /metrics on addr in the background. The listener is
+// opened up front so a taken port fails the run instead of going unnoticed.
+// The server lives as long as the process, so one-shot runs only expose the
+// metrics while they are running.
+func startMetricsServer(addr, namespace string) (*metricsRecorder, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := prometheus.NewRegistry()
+	metrics := newMetricsRecorder(namespace, registry)
+	metrics.addr = listener.Addr()
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Printf("Error serving metrics on %s: %v", addr, err)
+		}
+	}()
+	return metrics, nil
+}
+
+func (m *metricsRecorder) fileParsed() {
+	if m != nil {
+		m.filesParsed.Inc()
+	}
+}
+
+func (m *metricsRecorder) parseError() {
+	if m != nil {
+		m.parseErrors.Inc()
+	}
+}
+
+// Function to record a finished scan and the interfaces it reported
+func (m *metricsRecorder) scanDone(duration time.Duration, interfaces int) {
+	if m != nil {
+		m.scans.Inc()
+		m.interfacesFound.Add(float64(interfaces))
+		m.scanDuration.Observe(duration.Seconds())
+	}
+}
+
+// Function to record one LLM API request; status is the HTTP status code, or
+// "error" when no response arrived
+func (m *metricsRecorder) llmRequest(status string, latency time.Duration) {
+	if m != nil {
+		m.llmRequests.WithLabelValues(status).Inc()
+		m.requestLatency.Observe(latency.Seconds())
+	}
+}
+
+func (m *metricsRecorder) tokensUsed(usage TokenUsage) {
+	if m != nil {
+		m.llmTokens.WithLabelValues("prompt").Add(float64(usage.PromptTokens))
+		m.llmTokens.WithLabelValues("completion").Add(float64(usage.CompletionTokens))
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMetricsServer(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface{ Get(id string) string }\n")
+	writeFile(t, filepath.Join(root, "memory", "store.go"), "package memory\n\ntype Store struct{}\n\nfunc (Store) Get(id string) string { return id }\n")
+
+	metrics, err := startMetricsServer(":0", "test")
+	if err != nil {
+		t.Fatalf("startMetricsServer: %v", err)
+	}
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root, Metrics: metrics}
+	if _, err := analyzeReport(context.Background(), config); err != nil {
+		t.Fatalf("analyzeReport: %v", err)
+	}
+
+	port := metrics.addr.(*net.TCPAddr).Port
+	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/metrics", port))
+	if err != nil {
+		t.Fatalf("scraping /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d:\n%s", resp.StatusCode, body)
+	}
+
+	for _, want := range []string{
+		"\ntest_scans_total 1\n",
+		"\ntest_interfaces_found_total 1\n",
+		"\ntest_scan_duration_seconds_count 1\n",
+		"\ntest_scan_duration_seconds_sum ",
+		`test_scan_duration_seconds_bucket{le="+Inf"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics lack %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Model the mock provider reports, for the usage summary
+const mockModel = "mock"
+
+// mockClient answers without a network or an API key, with a reply made
+// from the interfaces the prompt describes, so demos, CI and smoke tests can
+// run the whole pipeline offline. The same prompt always gets the same reply.
+type mockClient struct {
+	config *Config
+}
+
+// Function to let send -check-auth succeed, as there is nothing to check
+func (c *mockClient) checkAuth(ctx context.Context) error {
+	return nil
+}
+
+func (c *mockClient) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	names := promptInterfaces(prompt)
+
+	var content string
+	if c.config.ResponseSchema != nil {
+		reply := structuredReply{Interfaces: []InterfaceDoc{}}
+		for _, name := range names {
+			reply.Interfaces = append(reply.Interfaces, InterfaceDoc{
+				Interface:  name,
+				Summary:    mockSummary(name),
+				MethodDocs: []MethodDoc{},
+				Caveats:    []string{},
+			})
+		}
+		data, err := json.Marshal(reply)
+		if err != nil {
+			return nil, err
+		}
+		content = string(data)
+	} else {
+		var sections []string
+		for _, name := range names {
+			sections = append(sections, fmt.Sprintf("## %s\n\n%s", name, mockSummary(name)))
+		}
+		content = strings.Join(sections, "\n\n")
+	}
+	return &Completion{Content: content, Usage: TokenUsage{PromptTokens: estimateTokens(prompt), CompletionTokens: estimateTokens(content)}}, nil
+}
+
+// Function to list the interfaces a prompt made by formatResultsForMessage
+// describes, in order, from its "Interface: " lines
+func promptInterfaces(prompt string) []string {
+	var names []string
+	for _, line := range strings.Split(prompt, "\n") {
+		if name, ok := strings.CutPrefix(line, "Interface: "); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Function to make the canned documentation of an interface
+func mockSummary(name string) string {
+	return fmt.Sprintf("%s is documented by the mock provider.", name)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMockProviderEndToEnd(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("API_KEY", "")
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "store.go"), `package app
+
+type Store interface {
+	Get(id string) (string, error)
+}
+
+type Cache[K comparable] interface {
+	Evict(key K)
+}
+
+type MemStore struct{}
+
+func (m *MemStore) Get(id string) (string, error) { return "", nil }
+`)
+	report, err := Analyze(Config{GoFilePath: root, GoDirectory: root, Provider: "mock"})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	// No API key, and the same reply every time
+	first, err := report.SendTo(context.Background(), "")
+	if err != nil {
+		t.Fatalf("SendTo: %v", err)
+	}
+	second, _ := report.SendTo(context.Background(), "")
+	if first != second {
+		t.Errorf("replies differ:\n%s\n---\n%s", first, second)
+	}
+	for _, want := range []string{"## Store\n\nStore is documented by the mock provider.", "## Cache[K comparable]\n\n"} {
+		if !strings.Contains(first, want) {
+			t.Errorf("reply lacks %q:\n%s", want, first)
+		}
+	}
+}
+
+func TestMockProviderStructured(t *testing.T) {
+	config := &Config{Provider: "mock", StructuredOutput: true}
+	tracker := newUsageTracker(config)
+	content, err := sendData(context.Background(), config, testResults(), tracker)
+	if err != nil {
+		t.Fatalf("sendData: %v", err)
+	}
+	if !strings.Contains(content, "## Store\n\nStore is documented by the mock provider.\n\n### Methods") {
+		t.Errorf("markdown:\n%s", content)
+	}
+
+	var summary bytes.Buffer
+	tracker.printSummary(&summary)
+	if tracker.total.PromptTokens == 0 || !strings.Contains(summary.String(), "~$0.00") {
+		t.Errorf("usage = %+v, summary %q", tracker.total, summary.String())
+	}
+	if code := runCheckAuth(config); code != 0 {
+		t.Errorf("check-auth exit code = %d", code)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"go/ast"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Function to work out the import path of the package in dir from the
+// nearest go.mod, e.g. "example.com/app/services". It is empty when dir is
+// not inside a module.
+func packageImportPath(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for current := dir; ; current = filepath.Dir(current) {
+		if module := modulePath(filepath.Join(current, "go.mod")); module != "" {
+			rel, err := filepath.Rel(current, dir)
+			if err != nil {
+				return ""
+			}
+			if rel == "." {
+				return module
+			}
+			return module + "/" + filepath.ToSlash(rel)
+		}
+		if filepath.Dir(current) == current {
+			return ""
+		}
+	}
+}
+
+// Function to read the module path declared in a go.mod file
+func modulePath(goMod string) string {
+	data, err := os.ReadFile(goMod)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			if unquoted, err := strconv.Unquote(fields[1]); err == nil {
+				return unquoted
+			}
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// Function to map the names a file refers to its imports by to their paths
+func fileImports(file *ast.File) map[string]string {
+	imports := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		imports[importName(spec, importPath)] = importPath
+	}
+	return imports
+}
+
+// Function to get the name an import is referred to by. Without an explicit
+// name this assumes the package name is the last path element, skipping a
+// major version suffix such as /v2.
+func importName(spec *ast.ImportSpec, importPath string) string {
+	if spec.Name != nil {
+		return spec.Name.Name
+	}
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	return name
+}
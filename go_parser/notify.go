@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// When to post a run summary, listed in notify_on
+const (
+	NotifyAlways   = "always"
+	NotifyChanges  = "changes"
+	NotifyFailures = "failures"
+)
+
+const (
+	// State file the previous run's undocumented symbols are kept in
+	defaultNotifyStateFile = ".go_documentator_state.json"
+	// New undocumented symbols listed in a message before "and N more"
+	maxNotifySymbols = 10
+	notifyTimeout    = 10 * time.Second
+)
+
+// NotificationConfig sets up the run summary posted to Slack
+type NotificationConfig struct {
+	// Incoming webhook the summary is posted to; falls back to the
+	// SLACK_WEBHOOK_URL environment variable, so it needn't be committed
+	SlackWebhookURL string `yaml:"slack_webhook_url" json:"slack_webhook_url" toml:"slack_webhook_url"`
+	// always (default), changes (new undocumented symbols or a coverage
+	// change) and/or failures
+	NotifyOn []string `yaml:"notify_on" json:"notify_on" toml:"notify_on"`
+	// Link to the generated documentation, e.g. the CI artifact
+	ArtifactURL string `yaml:"artifact_url" json:"artifact_url" toml:"artifact_url"`
+	// Where the last run's undocumented symbols are kept to compare against;
+	// defaults to .go_documentator_state.json
+	StateFile string `yaml:"state_file" json:"state_file" toml:"state_file"`
+}
+
+// Function to check the notify_on values
+func (n NotificationConfig) validate() error {
+	for _, when := range n.NotifyOn {
+		switch when {
+		case NotifyAlways, NotifyChanges, NotifyFailures:
+		default:
+			return configError("notify_on", "%q (use always, changes or failures)", when)
+		}
+	}
+	return nil
+}
+
+// Function to get the webhook from the config or the environment
+func (n NotificationConfig) webhookURL() string {
+	if n.SlackWebhookURL != "" {
+		return n.SlackWebhookURL
+	}
+	return os.Getenv("SLACK_WEBHOOK_URL")
+}
+
+// The documentation state saved between runs
+type notifyState struct {
+	Undocumented []string `json:"undocumented"`
+	Coverage     float64  `json:"coverage"`
+}
+
+// runSummary is what a notification reports about one run
+type runSummary struct {
+	Command          string
+	Interfaces       int
+	Coverage         float64
+	PreviousCoverage *float64 // nil on the first run
+	NewUndocumented  []string
+	ArtifactURL      string
+	Err              error
+}
+
+// Function to tell whether the run changed the documentation state
+func (s *runSummary) changed() bool {
+	return len(s.NewUndocumented) > 0 || (s.PreviousCoverage != nil && *s.PreviousCoverage != s.Coverage)
+}
+
+// Function to decide whether notify_on asks for this run to be reported
+func (s *runSummary) wanted(notifyOn []string) bool {
+	if len(notifyOn) == 0 {
+		return true
+	}
+	for _, when := range notifyOn {
+		switch {
+		case when == NotifyAlways,
+			when == NotifyChanges && s.changed(),
+			when == NotifyFailures && s.Err != nil:
+			return true
+		}
+	}
+	return false
+}
+
+// Function to post a summary of the run to Slack when a webhook is
+// configured and notify_on asks for it, then save the state the next run
+// compares against. report may be nil when the run failed before the scan.
+// Notification problems are logged and never fail the run.
+func notifyRun(config *Config, command string, report *Report, runErr error) {
+	webhook := config.Notifications.webhookURL()
+	if webhook == "" {
+		return
+	}
+	statePath := config.Notifications.StateFile
+	if statePath == "" {
+		statePath = defaultNotifyStateFile
+	}
+
+	summary := &runSummary{Command: command, ArtifactURL: config.Notifications.ArtifactURL, Err: runErr}
+	var state *notifyState
+	if report != nil {
+		summary.Interfaces = len(report.Interfaces)
+		state = documentationState(report)
+		summary.Coverage = state.Coverage
+
+		previous, err := readNotifyState(statePath)
+		if err != nil {
+			log.Printf("Error reading notification state: %v", err)
+		}
+		if previous != nil {
+			summary.PreviousCoverage = &previous.Coverage
+			summary.NewUndocumented = newSymbols(previous.Undocumented, state.Undocumented)
+		}
+	}
+
+	if summary.wanted(config.Notifications.NotifyOn) {
+		if err := postSlack(config, webhook, slackMessage(summary)); err != nil {
+			log.Printf("Error sending Slack notification: %v", err)
+		}
+	}
+	if state != nil {
+		if err := writeNotifyState(statePath, state); err != nil {
+			log.Printf("Error writing notification state: %v", err)
+		}
+	}
+}
+
+// Function to get the undocumented symbols, qualified with their package,
+// and the share of exported symbols that have doc comments
+func documentationState(report *Report) *notifyState {
+	symbols, total := documentationCoverage(report)
+	state := &notifyState{Coverage: 100}
+	if total > 0 {
+		state.Coverage = 100 * float64(total-len(symbols)) / float64(total)
+	}
+	for _, symbol := range symbols {
+		name := symbol.name
+		if symbol.pkg != "" {
+			name = symbol.pkg + "." + name
+		}
+		state.Undocumented = append(state.Undocumented, name)
+	}
+	sort.Strings(state.Undocumented)
+	return state
+}
+
+// Function to list the symbols in current that weren't in previous
+func newSymbols(previous, current []string) []string {
+	seen := make(map[string]bool, len(previous))
+	for _, name := range previous {
+		seen[name] = true
+	}
+	var added []string
+	for _, name := range current {
+		if !seen[name] {
+			added = append(added, name)
+		}
+	}
+	return added
+}
+
+// Function to read the saved state, or nil when there is none yet
+func readNotifyState(path string) (*notifyState, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var state notifyState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &state, nil
+}
+
+func writeNotifyState(path string, state *notifyState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Function to format the summary as a Slack Block Kit message, with a plain
+// text fallback for notifications
+func slackMessage(s *runSummary) map[string]interface{} {
+	status := "succeeded"
+	if s.Err != nil {
+		status = "failed"
+	}
+	title := fmt.Sprintf("Go Documentator %s %s", s.Command, status)
+
+	coverage := fmt.Sprintf("%.1f%%", s.Coverage)
+	if s.PreviousCoverage != nil {
+		coverage += fmt.Sprintf(" (%+.1f pts)", s.Coverage-*s.PreviousCoverage)
+	}
+	text := fmt.Sprintf("%s: %d interfaces scanned, doc coverage %s, %d new undocumented symbols",
+		title, s.Interfaces, coverage, len(s.NewUndocumented))
+
+	blocks := []map[string]interface{}{
+		{"type": "header", "text": slackText("plain_text", title)},
+		{"type": "section", "fields": []map[string]interface{}{
+			slackText("mrkdwn", fmt.Sprintf("*Interfaces scanned*\n%d", s.Interfaces)),
+			slackText("mrkdwn", "*Doc coverage*\n"+coverage),
+			slackText("mrkdwn", fmt.Sprintf("*New undocumented symbols*\n%d", len(s.NewUndocumented))),
+		}},
+	}
+	if len(s.NewUndocumented) > 0 {
+		var b strings.Builder
+		b.WriteString("*New undocumented symbols*")
+		for i, name := range s.NewUndocumented {
+			if i == maxNotifySymbols {
+				fmt.Fprintf(&b, "\n…and %d more", len(s.NewUndocumented)-maxNotifySymbols)
+				break
+			}
+			fmt.Fprintf(&b, "\n• `%s`", name)
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": slackText("mrkdwn", b.String())})
+	}
+	if s.Err != nil {
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": slackText("mrkdwn", "*Error*\n```"+s.Err.Error()+"```")})
+	}
+	if s.ArtifactURL != "" {
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": slackText("mrkdwn", fmt.Sprintf("<%s|View the generated documentation>", s.ArtifactURL))})
+	}
+
+	return map[string]interface{}{"text": text, "blocks": blocks}
+}
+
+func slackText(kind, text string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "text": text}
+}
+
+// Function to post the message to the webhook through the configured proxy,
+// with the usual retries but a short overall timeout
+func postSlack(config *Config, webhook string, message map[string]interface{}) error {
+	api, err := newHTTPAPI(config)
+	if err != nil {
+		return err
+	}
+	api.metrics = nil // not an LLM request
+
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	resp, err := api.post(ctx, webhook, nil, message)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Function to start a fake Slack webhook recording the messages posted to it
+func fakeSlack(t *testing.T, status int) (*httptest.Server, *[]map[string]interface{}) {
+	var messages []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("decoding message: %v", err)
+		}
+		messages = append(messages, message)
+		w.WriteHeader(status)
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &messages
+}
+
+func TestNotifyOnChanges(t *testing.T) {
+	server, messages := fakeSlack(t, http.StatusOK)
+	retries := 0
+	config := &Config{MaxRetries: &retries, Notifications: NotificationConfig{
+		SlackWebhookURL: server.URL,
+		NotifyOn:        []string{NotifyChanges},
+		ArtifactURL:     "https://ci.example.com/docs",
+		StateFile:       filepath.Join(t.TempDir(), "state.json"),
+	}}
+
+	documented := testResults()
+	documented[0].Doc = "Store persists items."
+	documented[0].Methods[0].Doc = "Get returns an item."
+	notifyRun(config, "send", &Report{ReportResults: ReportResults{Interfaces: documented}}, nil)
+	if len(*messages) != 0 {
+		t.Fatalf("first run without undocumented symbols posted %d messages", len(*messages))
+	}
+
+	// Nothing changed since the last run
+	notifyRun(config, "send", &Report{ReportResults: ReportResults{Interfaces: documented}}, nil)
+	if len(*messages) != 0 {
+		t.Fatalf("unchanged run posted %d messages", len(*messages))
+	}
+
+	undocumented := testResults()
+	undocumented[0].Doc = "Store persists items."
+	notifyRun(config, "send", &Report{ReportResults: ReportResults{Interfaces: undocumented}}, nil)
+	if len(*messages) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(*messages))
+	}
+	data, _ := json.Marshal((*messages)[0])
+	for _, want := range []string{"`Store.Get`", "50.0% (-50.0 pts)", "Go Documentator send succeeded", "https://ci.example.com/docs|View"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("message lacks %q:\n%s", want, data)
+		}
+	}
+	if text, _ := (*messages)[0]["text"].(string); !strings.Contains(text, "1 new undocumented symbols") {
+		t.Errorf("fallback text = %q", text)
+	}
+}
+
+func TestNotifyOnFailures(t *testing.T) {
+	server, messages := fakeSlack(t, http.StatusOK)
+	config := &Config{Notifications: NotificationConfig{
+		SlackWebhookURL: server.URL,
+		NotifyOn:        []string{NotifyFailures},
+		StateFile:       filepath.Join(t.TempDir(), "state.json"),
+	}}
+
+	notifyRun(config, "send", &Report{ReportResults: ReportResults{Interfaces: testResults()}}, nil)
+	if len(*messages) != 0 {
+		t.Fatalf("successful run posted %d messages", len(*messages))
+	}
+	notifyRun(config, "send", &Report{ReportResults: ReportResults{Interfaces: testResults()}}, errors.New("status code 500"))
+	if len(*messages) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(*messages))
+	}
+	data, _ := json.Marshal((*messages)[0])
+	if !strings.Contains(string(data), "send failed") || !strings.Contains(string(data), "status code 500") {
+		t.Errorf("message doesn't report the failure:\n%s", data)
+	}
+}
+
+func TestNotifyErrorsDontFailTheRun(t *testing.T) {
+	server, messages := fakeSlack(t, http.StatusNotFound)
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	config := &Config{Notifications: NotificationConfig{SlackWebhookURL: server.URL, StateFile: statePath}}
+
+	notifyRun(config, "render", &Report{ReportResults: ReportResults{Interfaces: testResults()}}, nil)
+	if len(*messages) != 1 {
+		t.Fatalf("posted %d messages, want 1", len(*messages))
+	}
+	// The state is saved even though the post failed
+	state, err := readNotifyState(statePath)
+	if err != nil || state == nil {
+		t.Fatalf("readNotifyState = %v, %v", state, err)
+	}
+	if strings.Join(state.Undocumented, ",") != "Store,Store.Get" || state.Coverage != 0 {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestInvalidNotifyOn(t *testing.T) {
+	config := &Config{Notifications: NotificationConfig{NotifyOn: []string{"sometimes"}}}
+	if err := config.validate(); err == nil || !strings.Contains(err.Error(), "notify_on") {
+		t.Errorf("validate = %v, want a notify_on error", err)
+	}
+}
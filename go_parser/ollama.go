@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	ollamaBaseURL      = "http://localhost:11434"
+	ollamaDefaultModel = "llama3.1"
+)
+
+// ollamaClient talks to a local Ollama server through its native chat API,
+// so no API key is needed and nothing leaves the machine
+type ollamaClient struct {
+	api     *httpAPI
+	baseURL string
+	model   string
+	// When set, the reply is streamed and echoed to stderr as it arrives
+	stream bool
+	config *Config
+
+	// The model is looked up once, before the first request
+	checked  sync.Once
+	checkErr error
+}
+
+// The reply of /api/chat, and each line of it when streamed
+type ollamaResponse struct {
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+func (r *ollamaResponse) tokenUsage() TokenUsage {
+	return TokenUsage{PromptTokens: r.PromptEvalCount, CompletionTokens: r.EvalCount}
+}
+
+// The reply of /api/tags, listing the pulled models
+type ollamaTags struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+// Function to check that the server is up and has the model pulled, with an
+// error saying how to pull it otherwise. Names without a tag are :latest,
+// as in the ollama CLI.
+func (c *ollamaClient) checkModel(ctx context.Context) error {
+	resp, err := c.api.get(ctx, c.baseURL+"/api/tags", nil)
+	if err != nil {
+		return fmt.Errorf("listing the models of Ollama at %s: %w", c.baseURL, err)
+	}
+	defer resp.Body.Close()
+	var tags ollamaTags
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return fmt.Errorf("decoding the models of Ollama at %s: %w", c.baseURL, err)
+	}
+
+	want := c.model
+	if !strings.Contains(want, ":") {
+		want += ":latest"
+	}
+	var names []string
+	for _, model := range tags.Models {
+		if model.Name == want || model.Name == c.model {
+			return nil
+		}
+		names = append(names, model.Name)
+	}
+	available := "none"
+	if len(names) > 0 {
+		available = strings.Join(names, ", ")
+	}
+	return fmt.Errorf("model %s is not pulled in Ollama at %s (available: %s); run: ollama pull %s", c.model, c.baseURL, available, c.model)
+}
+
+// Function to let send -check-auth check the server and the model, as
+// there is no API key
+func (c *ollamaClient) checkAuth(ctx context.Context) error {
+	return c.checkModel(ctx)
+}
+
+func (c *ollamaClient) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	c.checked.Do(func() { c.checkErr = c.checkModel(ctx) })
+	if c.checkErr != nil {
+		return nil, c.checkErr
+	}
+
+	options := map[string]interface{}{}
+	if c.config.Temperature != nil {
+		options["temperature"] = *c.config.Temperature
+	}
+	if c.config.MaxCompletionTokens > 0 {
+		options["num_predict"] = c.config.MaxCompletionTokens
+	}
+	payload := map[string]interface{}{
+		"model":    c.model,
+		"messages": buildMessages(c.config, prompt),
+		"stream":   c.stream,
+	}
+	if len(options) > 0 {
+		payload["options"] = options
+	}
+	if c.config.ResponseSchema != nil {
+		payload["format"] = c.config.ResponseSchema
+	}
+
+	if c.stream {
+		resp, err := c.api.post(ctx, c.baseURL+"/api/chat", nil, payload)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return readOllamaStream(resp.Body, os.Stderr)
+	}
+
+	var response ollamaResponse
+	if err := c.api.postJSON(ctx, c.baseURL+"/api/chat", nil, payload, &response); err != nil {
+		return nil, err
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("ollama: %s", response.Error)
+	}
+	return &Completion{Content: response.Message.Content, Usage: response.tokenUsage()}, nil
+}
+
+// Function to read a streamed reply, one JSON object per line, echoing each
+// piece to live and returning the assembled content. The last object has
+// done set and the token counts. When the stream breaks off, the content
+// received so far is returned along with the error.
+func readOllamaStream(body io.Reader, live io.Writer) (*Completion, error) {
+	var content strings.Builder
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var chunk ollamaResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return nil, fmt.Errorf("decoding stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return nil, fmt.Errorf("stream error: %s", chunk.Error)
+		}
+		fmt.Fprint(live, chunk.Message.Content)
+		content.WriteString(chunk.Message.Content)
+		if chunk.Done {
+			fmt.Fprintln(live)
+			return &Completion{Content: content.String(), Usage: chunk.tokenUsage()}, nil
+		}
+	}
+	partial := &Completion{Content: content.String()}
+	if err := scanner.Err(); err != nil {
+		return partial, fmt.Errorf("reading stream: %w", err)
+	}
+	return partial, fmt.Errorf("stream ended before done")
+}
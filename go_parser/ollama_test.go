@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// Function to read a response recorded from an Ollama server
+func ollamaFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "ollama", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// Function to start a fake Ollama server answering /api/tags with the
+// recorded model list and /api/chat with the given fixture; chats counts
+// the chat requests and payload holds the last one
+func fakeOllama(t *testing.T, chat string) (server *httptest.Server, chats *int32, payload *map[string]interface{}) {
+	t.Helper()
+	chats = new(int32)
+	payload = new(map[string]interface{})
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			t.Errorf("Authorization = %q, want none", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/api/tags":
+			w.Write(ollamaFixture(t, "tags.json"))
+		case "/api/chat":
+			atomic.AddInt32(chats, 1)
+			if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+				t.Errorf("decoding request: %v", err)
+			}
+			w.Write(ollamaFixture(t, chat))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, chats, payload
+}
+
+func TestSendDataOllama(t *testing.T) {
+	server, chats, payload := fakeOllama(t, "chat.json")
+	temperature := 0.2
+	config := &Config{Provider: "ollama", BaseURL: server.URL, NoStream: true, Temperature: &temperature, MaxCompletionTokens: 500}
+	tracker := newUsageTracker(config)
+
+	content, err := sendData(context.Background(), config, testResults(), tracker)
+	if err != nil {
+		t.Fatalf("sendData: %v", err)
+	}
+	if content != "Store persists items." || *chats != 1 {
+		t.Errorf("content = %q after %d requests", content, *chats)
+	}
+	if tracker.total.PromptTokens != 120 || tracker.total.CompletionTokens != 30 {
+		t.Errorf("usage = %+v, want the eval counts", tracker.total)
+	}
+	options, _ := (*payload)["options"].(map[string]interface{})
+	if (*payload)["model"] != ollamaDefaultModel || (*payload)["stream"] != false ||
+		options["temperature"] != 0.2 || options["num_predict"] != 500.0 {
+		t.Errorf("payload = %v", *payload)
+	}
+}
+
+func TestOllamaStream(t *testing.T) {
+	var live bytes.Buffer
+	completion, err := readOllamaStream(bytes.NewReader(ollamaFixture(t, "chat_stream.ndjson")), &live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completion.Content != "Store persists items." || completion.Usage.PromptTokens != 120 || completion.Usage.CompletionTokens != 30 {
+		t.Errorf("completion = %+v", completion)
+	}
+	if live.String() != "Store persists items.\n" {
+		t.Errorf("echoed %q", live.String())
+	}
+
+	completion, err = readOllamaStream(bytes.NewReader(ollamaFixture(t, "chat_stream_error.ndjson")), &live)
+	if err == nil || !strings.Contains(err.Error(), "unexpected EOF") || completion != nil {
+		t.Errorf("err = %v, want the stream's error", err)
+	}
+	completion, err = readOllamaStream(strings.NewReader(`{"message":{"content":"Store"},"done":false}`+"\n"), &live)
+	if err == nil || completion.Content != "Store" {
+		t.Errorf("completion = %+v, err = %v, want the partial content and an error", completion, err)
+	}
+}
+
+func TestOllamaChecksModel(t *testing.T) {
+	server, chats, _ := fakeOllama(t, "chat.json")
+	for _, model := range []string{"llama3.1", "llama3.1:latest", "qwen2.5-coder:7b"} {
+		client, err := newLLMClient(&Config{Provider: "ollama", BaseURL: server.URL, Model: model, NoStream: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Complete(context.Background(), "prompt"); err != nil {
+			t.Errorf("%s: %v", model, err)
+		}
+	}
+
+	*chats = 0
+	client, err := newLLMClient(&Config{Provider: "ollama", BaseURL: server.URL, Model: "mistral", NoStream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = client.Complete(context.Background(), "prompt")
+		if err == nil || !strings.Contains(err.Error(), "run: ollama pull mistral") || !strings.Contains(err.Error(), "llama3.1:latest, qwen2.5-coder:7b") {
+			t.Errorf("err = %v, want the pull command and the available models", err)
+		}
+	}
+	if *chats != 0 {
+		t.Errorf("%d chat requests sent for a missing model", *chats)
+	}
+}
+
+func TestOllamaNeedsNoAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "")
+	t.Setenv("API_KEY", "")
+	server, _, _ := fakeOllama(t, "chat.json")
+	config := &Config{Provider: "ollama", BaseURL: server.URL}
+	if code := runCheckAuth(config); code != 0 {
+		t.Errorf("check-auth exit code = %d, want 0 without an API key", code)
+	}
+
+	report := &Report{ReportResults: ReportResults{Interfaces: testResults()}, config: &Config{BaseURL: server.URL, NoStream: true}}
+	if content, err := report.SendTo(context.Background(), "ollama"); err != nil || content != "Store persists items." {
+		t.Errorf("SendTo = %q, %v", content, err)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	openAIBaseURL      = "https://api.openai.com/v1"
+	openAIDefaultModel = "gpt-4"
+)
+
+// openAIClient talks to the OpenAI chat completions API
+type openAIClient struct {
+	api     *httpAPI
+	baseURL string
+	apiKey  string
+	model   string
+	// When set, the reply is streamed and echoed to stderr as it arrives
+	stream bool
+	config *Config
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *openAIUsage) tokenUsage() TokenUsage {
+	if u == nil {
+		return TokenUsage{}
+	}
+	return TokenUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *openAIUsage `json:"usage"`
+}
+
+// A single server-sent event of a streamed completion
+type openAIStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	// Only set on the final chunk, when include_usage is requested
+	Usage *openAIUsage `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Function to validate the API key by listing the models
+func (c *openAIClient) checkAuth(ctx context.Context) error {
+	resp, err := c.api.get(ctx, c.baseURL+"/models", map[string]string{"Authorization": "Bearer " + c.apiKey})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (c *openAIClient) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	// Construct the JSON payload for the API
+	payload := map[string]interface{}{
+		"model":    c.model,
+		"messages": buildMessages(c.config, prompt),
+	}
+	if c.config.Temperature != nil {
+		payload["temperature"] = *c.config.Temperature
+	}
+	if c.config.MaxCompletionTokens > 0 {
+		payload["max_completion_tokens"] = c.config.MaxCompletionTokens
+	}
+	if c.config.ResponseSchema != nil {
+		payload["response_format"] = map[string]interface{}{
+			"type":        "json_schema",
+			"json_schema": map[string]interface{}{"name": "interface_docs", "strict": true, "schema": c.config.ResponseSchema},
+		}
+	}
+	headers := map[string]string{"Authorization": "Bearer " + c.apiKey}
+
+	if c.stream {
+		payload["stream"] = true
+		payload["stream_options"] = map[string]bool{"include_usage": true}
+		resp, err := c.api.post(ctx, c.baseURL+"/chat/completions", headers, payload)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		return readOpenAIStream(resp.Body, os.Stderr)
+	}
+
+	var response openAIResponse
+	if err := c.api.postJSON(ctx, c.baseURL+"/chat/completions", headers, payload, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("response contained no choices")
+	}
+	return &Completion{
+		Content: response.Choices[0].Message.Content,
+		Usage:   response.Usage.tokenUsage(),
+	}, nil
+}
+
+// Function to read a server-sent event stream of completion chunks, echoing
+// each delta to live and returning the assembled content. When the stream
+// breaks off, e.g. because the request was cancelled, the content received
+// so far is returned along with the error.
+func readOpenAIStream(body io.Reader, live io.Writer) (*Completion, error) {
+	var content strings.Builder
+	var usage TokenUsage
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue // blank separators, comments and other SSE fields
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			fmt.Fprintln(live)
+			return &Completion{Content: content.String(), Usage: usage}, nil
+		}
+
+		var chunk openAIStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return nil, fmt.Errorf("decoding stream chunk: %w", err)
+		}
+		if chunk.Error != nil {
+			return nil, fmt.Errorf("stream error: %s", chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.tokenUsage()
+		}
+		for _, choice := range chunk.Choices {
+			fmt.Fprint(live, choice.Delta.Content)
+			content.WriteString(choice.Delta.Content)
+		}
+	}
+	partial := &Completion{Content: content.String(), Usage: usage}
+	if err := scanner.Err(); err != nil {
+		return partial, fmt.Errorf("reading stream: %w", err)
+	}
+	return partial, fmt.Errorf("stream ended before [DONE]")
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const cannedCompletion = `{
+	"choices": [{"message": {"role": "assistant", "content": "Store persists items."}}],
+	"usage": {"prompt_tokens": 120, "completion_tokens": 30}
+}`
+
+// Function to start a fake chat completions endpoint that answers with the
+// given statuses in turn, then with the canned completion
+func fakeOpenAI(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %s, want /chat/completions", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+
+		if int(n) <= len(statuses) {
+			http.Error(w, fmt.Sprintf(`{"error": {"message": "status %d"}}`, statuses[n-1]), statuses[n-1])
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, cannedCompletion)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func testResults() []InterfaceDetails {
+	return []InterfaceDetails{{
+		InterfaceName:   "Store",
+		Methods:         []MethodDetails{{Name: "Get", Signature: "Get(id string) (string, error)"}},
+		Implementations: []Implementation{{TypeName: "MemStore", ReceiverSatisfaction: SatisfiedByPointer}},
+	}}
+}
+
+func TestSendDataOpenAI(t *testing.T) {
+	server, calls := fakeOpenAI(t)
+	config := &Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true}
+	tracker := newUsageTracker(config)
+
+	content, err := sendData(context.Background(), config, testResults(), tracker)
+	if err != nil {
+		t.Fatalf("sendData: %v", err)
+	}
+	if content != "Store persists items." {
+		t.Errorf("content = %q", content)
+	}
+	if *calls != 1 {
+		t.Errorf("requests = %d, want 1", *calls)
+	}
+	if tracker.requests != 1 || tracker.total.PromptTokens != 120 || tracker.total.CompletionTokens != 30 {
+		t.Errorf("tracked usage = %d requests, %+v", tracker.requests, tracker.total)
+	}
+}
+
+func TestOpenAIRetriesRateLimit(t *testing.T) {
+	server, calls := fakeOpenAI(t, http.StatusTooManyRequests)
+	client, err := newLLMClient(&Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.(*openAIClient).api.backoff = time.Millisecond
+
+	completion, err := client.Complete(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if completion.Content != "Store persists items." {
+		t.Errorf("content = %q", completion.Content)
+	}
+	if *calls != 2 {
+		t.Errorf("requests = %d, want a 429 and a retry", *calls)
+	}
+}
+
+func TestOpenAIGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := fakeOpenAI(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	maxRetries := 1
+	client, err := newLLMClient(&Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true, MaxRetries: &maxRetries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.(*openAIClient).api.backoff = time.Millisecond
+
+	if _, err := client.Complete(context.Background(), "prompt"); err == nil || !strings.Contains(err.Error(), "status code 503") {
+		t.Fatalf("Complete error = %v, want status code 503", err)
+	}
+	if *calls != 2 {
+		t.Errorf("requests = %d, want the first attempt and one retry", *calls)
+	}
+}
+
+func TestOpenAIResponseLimits(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") == "stall" {
+			// Start the body, then never finish it
+			fmt.Fprint(w, `{"choices": [`)
+			w.(http.Flusher).Flush()
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		fmt.Fprintf(w, `{"choices": [{"message": {"content": %q}}]}`, strings.Repeat("x", 4096))
+	}))
+	defer server.Close()
+	defer close(release)
+
+	api, err := newHTTPAPI(&Config{MaxResponseBytes: 1024, MaxRetries: new(int)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response openAIResponse
+	err = api.postJSON(context.Background(), server.URL, nil, map[string]string{}, &response)
+	if err == nil || !strings.Contains(err.Error(), "larger than 1024 bytes (max_response_bytes)") {
+		t.Errorf("oversized response: err = %v", err)
+	}
+
+	api.timeout = 50 * time.Millisecond
+	err = api.postJSON(context.Background(), server.URL, map[string]string{"X-Test": "stall"}, map[string]string{}, &response)
+	if err == nil || !strings.Contains(err.Error(), "not finished within 50ms") {
+		t.Errorf("stalled response: err = %v", err)
+	}
+}
+
+func TestReadOpenAIStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`data: {"choices": [{"delta": {"content": "Store "}}]}`,
+		``,
+		`data: {"choices": [{"delta": {"content": "persists items."}}]}`,
+		``,
+		`data: {"choices": [], "usage": {"prompt_tokens": 10, "completion_tokens": 4}}`,
+		``,
+		`data: [DONE]`,
+		``,
+	}, "\n")
+
+	var live strings.Builder
+	completion, err := readOpenAIStream(strings.NewReader(stream), &live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completion.Content != "Store persists items." || live.String() != completion.Content+"\n" {
+		t.Errorf("content = %q, live = %q", completion.Content, live.String())
+	}
+	if completion.Usage != (TokenUsage{PromptTokens: 10, CompletionTokens: 4}) {
+		t.Errorf("usage = %+v", completion.Usage)
+	}
+}
+
+func TestReadOpenAIStreamKeepsPartialContent(t *testing.T) {
+	stream := `data: {"choices": [{"delta": {"content": "Store "}}]}` + "\n\n"
+
+	completion, err := readOpenAIStream(strings.NewReader(stream), io.Discard)
+	if err == nil {
+		t.Fatal("expected an error for a stream without [DONE]")
+	}
+	if completion == nil || completion.Content != "Store " {
+		t.Errorf("completion = %+v, want the content received before the stream ended", completion)
+	}
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Doc comment annotation mapping an interface method to an HTTP endpoint,
+// e.g. "@route GET /users/{id}"; route_annotation overrides it
+const defaultRouteAnnotation = "@route"
+
+// HTTP methods in the order OpenAPI lists operations
+var openAPIMethods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+var pathParamPattern = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// An interface method annotated with a route
+type route struct {
+	Method    string // upper case, e.g. "GET"
+	Path      string // e.g. "/users/{id}"
+	Interface string
+	Name      string // method name
+	Doc       string // doc comment without the annotations
+	pos       token.Position
+}
+
+// Function to collect the routes annotated on interface methods. Methods
+// without an annotation are skipped; malformed annotations and routes
+// another method already claimed are returned as warnings.
+func collectRoutes(results []InterfaceDetails, annotation string) ([]route, []string) {
+	var routes []route
+	var warnings []string
+	claimed := make(map[string]route)
+	for _, result := range results {
+		for _, method := range result.Methods {
+			doc, annotations := splitAnnotations(method.Doc, annotation)
+			for _, value := range annotations {
+				r := route{Interface: result.InterfaceName, Name: method.Name, Doc: doc, pos: method.pos}
+				fields := strings.Fields(value)
+				if len(fields) != 2 || !validHTTPMethod(fields[0]) || !strings.HasPrefix(fields[1], "/") {
+					warnings = append(warnings, fmt.Sprintf("%s.%s at %s: malformed %s %q (want e.g. %s GET /users/{id})",
+						r.Interface, r.Name, formatPosition(r.pos), annotation, value, annotation))
+					continue
+				}
+				r.Method, r.Path = strings.ToUpper(fields[0]), fields[1]
+
+				// /users/{id} and /users/{userID} are the same route
+				key := r.Method + " " + pathParamPattern.ReplaceAllString(r.Path, "{}")
+				if first, ok := claimed[key]; ok {
+					warnings = append(warnings, fmt.Sprintf("%s.%s at %s: route %s %s conflicts with %s.%s at %s, which is kept",
+						r.Interface, r.Name, formatPosition(r.pos), r.Method, r.Path, first.Interface, first.Name, formatPosition(first.pos)))
+					continue
+				}
+				claimed[key] = r
+				routes = append(routes, r)
+			}
+		}
+	}
+	return routes, warnings
+}
+
+// Function to separate the annotation values from the rest of a doc comment
+func splitAnnotations(doc, annotation string) (string, []string) {
+	var lines, values []string
+	for _, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if value, ok := strings.CutPrefix(trimmed, annotation); ok && (value == "" || value[0] == ' ' || value[0] == '\t') {
+			values = append(values, strings.TrimSpace(value))
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), values
+}
+
+func validHTTPMethod(method string) bool {
+	for _, known := range openAPIMethods {
+		if strings.EqualFold(method, known) {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to get the first sentence of a doc comment as an operation summary
+func docSummary(doc string) string {
+	paragraph, _, _ := strings.Cut(doc, "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+	if i := strings.Index(paragraph, ". "); i >= 0 {
+		paragraph = paragraph[:i+1]
+	}
+	return paragraph
+}
+
+// Function to build an OpenAPI 3 document with a paths skeleton for the
+// annotated routes. Operations take their summary and description from the
+// method's doc comment, or else from the generated docs, keyed like
+// "Store.Get", when given.
+func buildOpenAPI(routes []route, title string, docs map[string]string, annotation string) yaml.MapSlice {
+	byPath := make(map[string][]route)
+	for _, r := range routes {
+		byPath[r.Path] = append(byPath[r.Path], r)
+	}
+	var paths []string
+	for path := range byPath {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	pathItems := yaml.MapSlice{}
+	for _, path := range paths {
+		var operations yaml.MapSlice
+		for _, method := range openAPIMethods {
+			for _, r := range byPath[path] {
+				if r.Method == method {
+					operations = append(operations, yaml.MapItem{Key: strings.ToLower(method), Value: openAPIOperation(r, docs, annotation)})
+				}
+			}
+		}
+		pathItems = append(pathItems, yaml.MapItem{Key: path, Value: operations})
+	}
+
+	return yaml.MapSlice{
+		{Key: "openapi", Value: "3.0.3"},
+		{Key: "info", Value: yaml.MapSlice{{Key: "title", Value: title}, {Key: "version", Value: "0.0.0"}}},
+		{Key: "paths", Value: pathItems},
+	}
+}
+
+// Function to describe one route as an OpenAPI operation
+func openAPIOperation(r route, docs map[string]string, annotation string) yaml.MapSlice {
+	doc := r.Doc
+	if doc == "" {
+		doc, _ = splitAnnotations(docs[r.Interface+"."+r.Name], annotation)
+	}
+
+	operation := yaml.MapSlice{
+		{Key: "operationId", Value: r.Interface + "_" + r.Name},
+		{Key: "tags", Value: []string{r.Interface}},
+	}
+	if doc != "" {
+		summary := docSummary(doc)
+		operation = append(operation, yaml.MapItem{Key: "summary", Value: summary})
+		if summary != strings.Join(strings.Fields(doc), " ") {
+			operation = append(operation, yaml.MapItem{Key: "description", Value: doc})
+		}
+	}
+
+	var parameters []yaml.MapSlice
+	for _, match := range pathParamPattern.FindAllStringSubmatch(r.Path, -1) {
+		parameters = append(parameters, yaml.MapSlice{
+			{Key: "name", Value: match[1]},
+			{Key: "in", Value: "path"},
+			{Key: "required", Value: true},
+			{Key: "schema", Value: yaml.MapSlice{{Key: "type", Value: "string"}}},
+		})
+	}
+	if len(parameters) > 0 {
+		operation = append(operation, yaml.MapItem{Key: "parameters", Value: parameters})
+	}
+	operation = append(operation, yaml.MapItem{Key: "responses", Value: yaml.MapSlice{
+		{Key: "200", Value: yaml.MapSlice{{Key: "description", Value: "OK"}}},
+	}})
+	return operation
+}
+
+// Function to write the OpenAPI skeleton for the routes annotated in the
+// analysis results, logging a warning for each conflicting or malformed
+// route. When docsPath names documentation written by send, its doc
+// comments describe methods that have none.
+func writeOpenAPI(w io.Writer, results []InterfaceDetails, config *Config, docsPath string) (int, error) {
+	var docs map[string]string
+	if docsPath != "" {
+		data, err := os.ReadFile(docsPath)
+		if err != nil {
+			return 0, err
+		}
+		docs = extractDocComments(string(data))
+	}
+
+	annotation := config.RouteAnnotation
+	if annotation == "" {
+		annotation = defaultRouteAnnotation
+	}
+	routes, warnings := collectRoutes(results, annotation)
+	for _, warning := range warnings {
+		log.Printf("Warning: %s", warning)
+	}
+
+	// Titled after the package declaring the interfaces when in a module
+	title := packageImportPath(filepath.Dir(config.GoFilePath))
+	if title == "" {
+		title = "API"
+	}
+	data, err := yaml.Marshal(buildOpenAPI(routes, title, docs, annotation))
+	if err != nil {
+		return 0, err
+	}
+	_, err = w.Write(data)
+	return len(routes), err
+}
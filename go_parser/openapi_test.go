@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const routesSource = `package api
+
+type UserHandler interface {
+	// GetUser returns the user with the given ID. Deleted users are not found.
+	//
+	// @route GET /users/{id}
+	GetUser(id string) (User, error)
+
+	// @route POST /users
+	CreateUser(u User) error
+
+	// Ping is not an endpoint.
+	Ping() error
+}
+
+type AdminHandler interface {
+	// @route get /users/{userID}
+	FindUser(id string) (User, error)
+
+	// @route /users
+	ListUsers() ([]User, error)
+}
+
+type User struct{}
+`
+
+// Function to parse source declaring interfaces into analysis results
+func parseResults(t *testing.T, src string) []InterfaceDetails {
+	t.Helper()
+	interfaces, _, err := findInterfacesInSource("api.go", []byte(src), &Config{}, map[interfaceRef]*declaredInterface{})
+	if err != nil {
+		t.Fatalf("findInterfacesInSource: %v", err)
+	}
+	var results []InterfaceDetails
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		results = append(results, *interfaces[ref])
+	}
+	return results
+}
+
+func TestCollectRoutes(t *testing.T) {
+	routes, warnings := collectRoutes(parseResults(t, routesSource), defaultRouteAnnotation)
+
+	var got []string
+	for _, r := range routes {
+		got = append(got, r.Method+" "+r.Path+" "+r.Interface+"."+r.Name)
+	}
+	// AdminHandler sorts first, so its FindUser claims GET /users/{id}
+	want := []string{"GET /users/{userID} AdminHandler.FindUser", "POST /users UserHandler.CreateUser"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("routes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if len(warnings) != 2 {
+		t.Fatalf("warnings = %q, want 2", warnings)
+	}
+	if !strings.Contains(warnings[0], `malformed @route "/users"`) {
+		t.Errorf("warning = %q, want a malformed annotation", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "UserHandler.GetUser at api.go:7: route GET /users/{id} conflicts with AdminHandler.FindUser at api.go:18") {
+		t.Errorf("warning = %q, want a conflict", warnings[1])
+	}
+}
+
+func TestWriteOpenAPI(t *testing.T) {
+	results := parseResults(t, routesSource)[1:] // only UserHandler
+
+	// Outside a module the document is titled API
+	var buf bytes.Buffer
+	config := &Config{GoFilePath: filepath.Join(t.TempDir(), "api.go")}
+	routes, err := writeOpenAPI(&buf, results, config, "")
+	if err != nil {
+		t.Fatalf("writeOpenAPI: %v", err)
+	}
+	if routes != 2 {
+		t.Errorf("routes = %d, want 2", routes)
+	}
+	want := `openapi: 3.0.3
+info:
+  title: API
+  version: 0.0.0
+paths:
+  /users:
+    post:
+      operationId: UserHandler_CreateUser
+      tags:
+      - UserHandler
+      responses:
+        "200":
+          description: OK
+  /users/{id}:
+    get:
+      operationId: UserHandler_GetUser
+      tags:
+      - UserHandler
+      summary: GetUser returns the user with the given ID.
+      description: GetUser returns the user with the given ID. Deleted users are not
+        found.
+      parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+      responses:
+        "200":
+          description: OK
+`
+	if buf.String() != want {
+		t.Errorf("document:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestOpenAPISummaryFromGeneratedDocs(t *testing.T) {
+	routes, _ := collectRoutes(parseResults(t, routesSource), defaultRouteAnnotation)
+	docs := map[string]string{"UserHandler.CreateUser": "CreateUser registers a new user.\n@route POST /users"}
+
+	operation := openAPIOperation(routes[1], docs, defaultRouteAnnotation)
+	summary := false
+	for _, item := range operation {
+		switch item.Key {
+		case "summary":
+			summary = true
+			if item.Value != "CreateUser registers a new user." {
+				t.Errorf("summary = %q", item.Value)
+			}
+		case "description":
+			t.Errorf("unexpected description %q", item.Value)
+		}
+	}
+	if !summary {
+		t.Errorf("no summary in %v", operation)
+	}
+}
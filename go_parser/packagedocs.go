@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Markers around the generated part of a package doc; text outside them is
+// kept when the doc is regenerated
+const (
+	packageDocsBegin = "<!-- godocumentator:begin -->"
+	packageDocsEnd   = "<!-- godocumentator:end -->"
+)
+
+// File written in each package directory unless package_docs.file_name is set
+const defaultPackageDocsFile = "doc.md"
+
+// PackageDocsConfig sets where the package-docs command writes
+type PackageDocsConfig struct {
+	// Name of the doc written in each package directory; defaults to doc.md
+	// so an existing README.md is left alone
+	FileName string `yaml:"file_name" json:"file_name" toml:"file_name"`
+	// When set, docs go to <mirror_dir>/<package-path>.md instead, with the
+	// package path relative to the working directory
+	MirrorDir string `yaml:"mirror_dir" json:"mirror_dir" toml:"mirror_dir"`
+}
+
+// packageDoc is what is known about one package directory
+type packageDoc struct {
+	Dir        string
+	Name       string
+	Interfaces []InterfaceDetails
+	Types      []typeDeclaration
+	Values     []ValueDeclaration
+	// Interfaces each type implements, e.g. "api.Store" or "io.Closer"
+	implements map[string][]string
+	// Example functions of the package's tests, see readExamples
+	examples map[string][]Example
+	overview string
+	// Static text of the doc, in doc_language
+	msgs messages
+}
+
+// Function to group the interfaces and exported types of a report by the
+// directory declaring them, ordered by directory
+func groupPackages(report *Report) []*packageDoc {
+	packages := make(map[string]*packageDoc)
+	msgs := docMessages(report.config)
+	get := func(filename, name string) *packageDoc {
+		dir := filepath.Dir(filename)
+		if packages[dir] == nil {
+			packages[dir] = &packageDoc{Dir: dir, Name: name, implements: make(map[string][]string), examples: readExamples(dir), msgs: msgs}
+		}
+		return packages[dir]
+	}
+
+	declared := make(map[string]bool)
+	for _, result := range report.Interfaces {
+		pkg := get(result.pos.Filename, result.Package)
+		pkg.Interfaces = append(pkg.Interfaces, result)
+		declared[pkg.Dir+"\x00"+result.InterfaceName] = true
+
+		for _, impl := range result.Implementations {
+			implPkg := get(impl.pos.Filename, impl.Package)
+			implemented := append([]string{result.Package + "." + result.InterfaceName}, impl.StdlibInterfaces...)
+			for _, name := range implemented {
+				if !containsString(implPkg.implements[impl.TypeName], name) {
+					implPkg.implements[impl.TypeName] = append(implPkg.implements[impl.TypeName], name)
+				}
+			}
+		}
+	}
+	for _, decl := range report.types {
+		if !ast.IsExported(decl.Name) || declared[filepath.Dir(decl.pos.Filename)+"\x00"+decl.Name] {
+			continue
+		}
+		pkg := get(decl.pos.Filename, decl.Package)
+		pkg.Types = append(pkg.Types, decl)
+	}
+
+	for _, value := range report.Values {
+		pkg := get(value.pos.Filename, value.Package)
+		pkg.Values = append(pkg.Values, value)
+	}
+
+	// Summaries made with package_summaries become the overviews
+	for _, summary := range report.Packages {
+		if pkg := packages[summary.dir]; pkg != nil {
+			pkg.overview = summary.Summary
+		}
+	}
+
+	var dirs []string
+	for dir := range packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	var docs []*packageDoc
+	for _, dir := range dirs {
+		sort.Slice(packages[dir].Types, func(i, j int) bool { return packages[dir].Types[i].Name < packages[dir].Types[j].Name })
+		docs = append(docs, packages[dir])
+	}
+	return docs
+}
+
+// Function to list the examples of the package's types other than
+// interfaces, by type: those of the type, of its methods and of its New
+// constructors, e.g. ExampleStore, ExampleStore_Get and ExampleNewStore
+func (pkg *packageDoc) typeExamples() []Example {
+	var examples []Example
+	for _, decl := range pkg.Types {
+		examples = append(examples, pkg.examples[decl.Name]...)
+		for _, target := range sortedKeys(pkg.examples) {
+			if strings.HasPrefix(target, decl.Name+"_") {
+				examples = append(examples, pkg.examples[target]...)
+			}
+		}
+		for _, constructor := range decl.Constructors {
+			name, _, _ := strings.Cut(constructor, "(")
+			examples = append(examples, pkg.examples[name]...)
+		}
+	}
+	return examples
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to render the generated part of a package doc: the interfaces
+// with their implementations, the types with the interfaces they implement
+// and their constructors, then the constants and variables
+func renderPackageDoc(pkg *packageDoc) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", pkg.msgs.text("package_heading", pkg.Name))
+	if importPath := packageImportPath(pkg.Dir); importPath != "" {
+		fmt.Fprintf(&b, "`%s`\n\n", importPath)
+	}
+	if pkg.overview != "" {
+		fmt.Fprintf(&b, "## %s\n\n%s\n\n", pkg.msgs.text("overview"), strings.TrimSpace(pkg.overview))
+	}
+
+	if len(pkg.Interfaces) > 0 {
+		fmt.Fprintf(&b, "## %s\n", pkg.msgs.text("interfaces"))
+		for _, result := range pkg.Interfaces {
+			fmt.Fprintf(&b, "\n### %s%s\n\n", result.InterfaceName, result.TypeParams)
+			if result.Deprecated != "" {
+				fmt.Fprintf(&b, "> %s\n\n", pkg.msgs.text("deprecated_notice", result.Deprecated))
+			}
+			if result.Doc != "" {
+				fmt.Fprintf(&b, "%s\n\n", result.Doc)
+			}
+			for _, method := range methodsInOrder(result.Methods) {
+				fmt.Fprintf(&b, "- `%s`\n", method.Signature)
+			}
+			if len(result.Methods) > 0 {
+				b.WriteString("\n")
+			}
+			var impls []string
+			for _, impl := range result.Implementations {
+				impls = append(impls, "`"+impl.Package+"."+impl.TypeName+"`")
+			}
+			if len(impls) > 0 {
+				fmt.Fprintf(&b, "%s\n", pkg.msgs.text("implemented_by", strings.Join(impls, ", ")))
+			} else {
+				b.WriteString(pkg.msgs.text("no_implementations") + "\n")
+			}
+			for _, example := range interfaceExamples(result) {
+				renderExample(&b, example, "####", pkg.msgs)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(pkg.Types) > 0 {
+		fmt.Fprintf(&b, "## %s\n\n", pkg.msgs.text("types"))
+		for _, decl := range pkg.Types {
+			var notes []string
+			if notice := deprecationNotice(decl.Doc); notice != "" {
+				notes = append(notes, pkg.msgs.text("deprecated_notice", notice))
+			}
+			if summary := docSummary(decl.Doc); summary != "" {
+				notes = append(notes, summary)
+			}
+			if implements := pkg.implements[decl.Name]; len(implements) > 0 {
+				notes = append(notes, pkg.msgs.text("implements", strings.Join(implements, "`, `")))
+			}
+			if len(decl.Constructors) > 0 {
+				notes = append(notes, pkg.msgs.text("constructed_by", strings.Join(decl.Constructors, "`, `")))
+			}
+			fmt.Fprintf(&b, "- `%s` (%s)", decl.Name, decl.Kind)
+			if len(notes) > 0 {
+				fmt.Fprintf(&b, ": %s", strings.Join(notes, " "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+
+		if examples := pkg.typeExamples(); len(examples) > 0 {
+			fmt.Fprintf(&b, "## %s\n", pkg.msgs.text("examples"))
+			for _, example := range examples {
+				renderExample(&b, example, "###", pkg.msgs)
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	for _, section := range valueSections {
+		if items := valuesOfKind(pkg.Values, section.kind); len(items) > 0 {
+			fmt.Fprintf(&b, "## %s\n\n", pkg.msgs.text(section.heading))
+			for _, value := range items {
+				renderValue(&b, value, false)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// Function to put generated content between the markers of an existing
+// doc. A doc without markers gets the generated part appended, so nothing
+// hand-written is lost; a doc with only one marker is an error.
+func mergeGenerated(existing, generated string) (string, error) {
+	block := packageDocsBegin + "\n" + generated + packageDocsEnd + "\n"
+	begin := strings.Index(existing, packageDocsBegin)
+	end := strings.Index(existing, packageDocsEnd)
+	switch {
+	case strings.TrimSpace(existing) == "":
+		return block, nil
+	case begin < 0 && end < 0:
+		return strings.TrimRight(existing, "\n") + "\n\n" + block, nil
+	case begin < 0 || end < begin:
+		return "", fmt.Errorf("%s and %s markers don't match", packageDocsBegin, packageDocsEnd)
+	}
+	rest := strings.TrimPrefix(existing[end+len(packageDocsEnd):], "\n")
+	return existing[:begin] + block + rest, nil
+}
+
+// Function to work out where the doc of a package directory goes
+func packageDocPath(pkg *packageDoc, config PackageDocsConfig) string {
+	if config.MirrorDir == "" {
+		name := config.FileName
+		if name == "" {
+			name = defaultPackageDocsFile
+		}
+		return filepath.Join(pkg.Dir, name)
+	}
+
+	// Packages outside the working directory are named after the package
+	return filepath.Join(config.MirrorDir, packageRelPath(pkg.Dir, pkg.Name)+".md")
+}
+
+// Function to ask the LLM for a short overview of each package, see
+// packageSummaryPrompt. A failed request leaves that package without one.
+func addPackageOverviews(ctx context.Context, config *Config, packages []*packageDoc, tracker *usageTracker) error {
+	client, err := newLLMClient(config)
+	if err != nil {
+		return err
+	}
+	for _, pkg := range packages {
+		prompt, findings, err := redactPrompt(packageSummaryPrompt(pkg), config)
+		if err != nil {
+			return err
+		}
+		if config.BlockOnSecrets && len(findings) > 0 {
+			log.Printf("Skipping the overview of %s: its prompt contained %d possible secrets and block_on_secrets is set", pkg.Dir, len(findings))
+			continue
+		}
+		completion, err := client.Complete(ctx, prompt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Printf("Error getting the overview of %s: %v", pkg.Dir, err)
+			continue
+		}
+		tracker.record(completion.Usage)
+		pkg.overview = completion.Content
+	}
+	return nil
+}
+
+// Function to write the doc of every package in the report, keeping what is
+// outside the markers of existing docs. Returns the paths written.
+func writePackageDocs(packages []*packageDoc, config PackageDocsConfig) ([]string, error) {
+	var written []string
+	for _, pkg := range packages {
+		path := packageDocPath(pkg, config)
+		existing, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return written, err
+		}
+		content, err := mergeGenerated(string(existing), renderPackageDoc(pkg))
+		if err != nil {
+			log.Printf("Skipping %s: %v", path, err)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
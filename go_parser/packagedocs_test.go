@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWritePackageDocs(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\n// Store persists items.\ntype Store interface{ Get(id string) string }\n")
+	writeFile(t, filepath.Join(root, "memory", "store.go"), `package memory
+
+// Store keeps items in a map.
+type Store struct{}
+
+func NewStore() *Store { return &Store{} }
+
+func (*Store) Get(id string) string { return id }
+
+func (*Store) Close() error { return nil }
+
+type Option func(*Store)
+
+func Newline() string { return "\n" }
+`)
+	// Hand-written prose around the markers survives
+	memoryDoc := filepath.Join(root, "memory", "doc.md")
+	writeFile(t, memoryDoc, "Intro.\n\n"+packageDocsBegin+"\nstale\n"+packageDocsEnd+"\n\nOutro.\n")
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	report := analyze(context.Background(), config)
+	written, err := writePackageDocs(groupPackages(report), config.PackageDocs)
+	if err != nil {
+		t.Fatalf("writePackageDocs: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("written = %v, want the api and memory docs", written)
+	}
+
+	data, err := os.ReadFile(memoryDoc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Intro.\n\n" + packageDocsBegin + `
+# Package memory
+
+## Types
+
+- ` + "`Option` (func(*Store))\n- `Store` (struct): Store keeps items in a map. Implements `api.Store`, `io.Closer`. Constructed by `NewStore() *Store`." + `
+` + packageDocsEnd + "\n\nOutro.\n"
+	if string(data) != want {
+		t.Errorf("memory doc:\n%s\nwant:\n%s", data, want)
+	}
+
+	data, err = os.ReadFile(filepath.Join(root, "api", "doc.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "### Store\n\nStore persists items.\n\n- `Get(id string) string`\n\nImplemented by `memory.Store`.") {
+		t.Errorf("api doc:\n%s", data)
+	}
+}
+
+func TestMergeGenerated(t *testing.T) {
+	block := packageDocsBegin + "\nnew\n" + packageDocsEnd + "\n"
+	for _, tc := range []struct{ existing, want string }{
+		{"", block},
+		{"# Hand-written\n", "# Hand-written\n\n" + block},
+		{"a\n" + packageDocsBegin + "\nold\n" + packageDocsEnd + "\nb\n", "a\n" + block + "b\n"},
+	} {
+		got, err := mergeGenerated(tc.existing, "new\n")
+		if err != nil || got != tc.want {
+			t.Errorf("mergeGenerated(%q) = %q, %v, want %q", tc.existing, got, err, tc.want)
+		}
+	}
+	if _, err := mergeGenerated(packageDocsBegin+"\nonly the start\n", "new\n"); err == nil {
+		t.Error("mergeGenerated accepted a doc without an end marker")
+	}
+}
+
+func TestPackageDocPath(t *testing.T) {
+	pkg := &packageDoc{Dir: filepath.Join("services", "memory"), Name: "memory"}
+	if got := packageDocPath(pkg, PackageDocsConfig{}); got != filepath.Join("services", "memory", "doc.md") {
+		t.Errorf("in-tree path = %s", got)
+	}
+	if got := packageDocPath(pkg, PackageDocsConfig{FileName: "README.md"}); got != filepath.Join("services", "memory", "README.md") {
+		t.Errorf("README path = %s", got)
+	}
+	if got := packageDocPath(pkg, PackageDocsConfig{MirrorDir: "docs"}); got != filepath.Join("docs", "services", "memory.md") {
+		t.Errorf("mirror path = %s", got)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"log"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Function to load the packages matching config.Packages, e.g. "./..." or
+// an import path, through the go tool and call visit for each of their Go
+// files. Patterns are resolved in dir, or the working directory when dir is
+// empty. Unlike walking a directory this honours GOFLAGS, replace
+// directives and nested modules, and leaves out vendored copies and files
+// outside the module. Packages that don't type-check are still scanned.
+func loadPackageFiles(ctx context.Context, dir string, config *Config, visit func(fset *token.FileSet, node *ast.File, src []byte)) error {
+	loadConfig := &packages.Config{
+		Context: ctx,
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedTypes,
+		Dir:     dir,
+		Tests:   config.IncludeTests,
+		Fset:    token.NewFileSet(),
+	}
+	if len(config.BuildTags) > 0 {
+		loadConfig.BuildFlags = []string{"-tags=" + strings.Join(config.BuildTags, ",")}
+	}
+
+	pkgs, err := packages.Load(loadConfig, config.Packages...)
+	if err != nil {
+		return fmt.Errorf("loading packages %s: %w", strings.Join(config.Packages, " "), err)
+	}
+	if len(pkgs) == 0 {
+		return fmt.Errorf("no packages match %s", strings.Join(config.Packages, " "))
+	}
+
+	// With tests, a package is loaded again as its test variant; scan each
+	// file once
+	seen := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			log.Printf("Error in package %s: %v", pkg.PkgPath, pkgErr)
+			file := pkgErr.Pos
+			if file == "" {
+				file = pkg.PkgPath
+			}
+			config.skipFile(file, errors.New(pkgErr.Msg))
+		}
+		for _, node := range pkg.Syntax {
+			path := loadConfig.Fset.File(node.Pos()).Name()
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			src, err := os.ReadFile(path)
+			if err != nil {
+				log.Printf("Error reading Go file %s: %v", path, err)
+				config.skipFile(path, err)
+				continue
+			}
+			visit(loadConfig.Fset, node, src)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func TestPackageModeHonoursModules(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "app")
+	writeFile(t, filepath.Join(app, "go.mod"), "module example.com/app\n\ngo 1.22\n\nrequire example.com/lib v0.0.0\n\nreplace example.com/lib => ../lib\n")
+	ifacePath := filepath.Join(app, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface{ Get(id string) string }\n")
+	writeFile(t, filepath.Join(app, "mem", "mem.go"), "package mem\n\ntype MemStore struct{}\n\nfunc (MemStore) Get(id string) string { return id }\n")
+	// A nested module is not part of ./...
+	writeFile(t, filepath.Join(app, "tools", "go.mod"), "module example.com/tools\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(app, "tools", "fake.go"), "package tools\n\ntype FakeStore struct{}\n\nfunc (FakeStore) Get(id string) string { return id }\n")
+	// Reached only through the replace directive
+	writeFile(t, filepath.Join(root, "lib", "go.mod"), "module example.com/lib\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(root, "lib", "disk.go"), "package lib\n\ntype DiskStore struct{}\n\nfunc (DiskStore) Get(id string) string { return id }\n")
+
+	implementations := func(config *Config) []string {
+		interfaces, _ := findInterfaces(ifacePath, config)
+		results, _, _ := findImplementations(context.Background(), []string{config.GoDirectory}, interfaces, config)
+		var names []string
+		for _, impl := range results[0].Implementations {
+			names = append(names, impl.TypeName)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: app, Packages: []string{"./...", "example.com/lib"}}
+	if got := implementations(config); len(got) != 2 || got[0] != "DiskStore" || got[1] != "MemStore" {
+		t.Errorf("package mode found %v, want DiskStore and MemStore", got)
+	}
+
+	// The directory walk still picks up the nested module
+	config = &Config{GoFilePath: ifacePath, GoDirectory: app}
+	if got := implementations(config); len(got) != 2 || got[0] != "FakeStore" || got[1] != "MemStore" {
+		t.Errorf("directory mode found %v, want FakeStore and MemStore", got)
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File a package summary is written to with -write-package-docs
+const packageCommentFile = "doc.go"
+
+// PackageSummary is the overview the LLM wrote of a scanned package, with
+// package_summaries set
+type PackageSummary struct {
+	// Import path, or directory outside a module, as in the package graph
+	Package string `json:"package"`
+	// Name in the package clause
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+
+	dir string
+}
+
+// Function to ask the LLM for an overview of each package of the report,
+// with package_summaries set, keeping them in report.Packages and, with
+// -write-package-docs, writing them as the package comment of packages that
+// have none. A failed request leaves that package out; an interrupted run
+// keeps the summaries it got. The error is marked with exitProvider when
+// the provider fails and exitOutput when a package comment can't be
+// written, for the caller to exit with or, in watch mode, to log.
+func summarizePackages(ctx context.Context, config *Config, report *Report) error {
+	if !config.PackageSummaries {
+		return nil
+	}
+	tracker := newUsageTracker(config)
+	packages := groupPackages(report)
+	if err := addPackageOverviews(ctx, config, packages, tracker); err != nil && ctx.Err() == nil {
+		return withExitCode(exitProvider, fmt.Errorf("getting package summaries: %w", err))
+	}
+
+	report.Packages = nil
+	for _, pkg := range packages {
+		if pkg.overview == "" {
+			continue
+		}
+		report.Packages = append(report.Packages, PackageSummary{
+			Package: packageID(pkg.Dir, pkg.Name),
+			Name:    pkg.Name,
+			Summary: strings.TrimSpace(pkg.overview),
+			dir:     pkg.Dir,
+		})
+	}
+	if config.WritePackageDocs {
+		for _, summary := range report.Packages {
+			path, err := writePackageComment(summary)
+			if err != nil {
+				return withExitCode(exitOutput, fmt.Errorf("writing the package comment of %s: %w", summary.Package, err))
+			}
+			if path != "" {
+				fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+			}
+		}
+	}
+
+	tracker.printSummary(os.Stderr)
+	if err := tracker.appendLog(); err != nil {
+		log.Printf("Error writing usage log: %v", err)
+	}
+	return nil
+}
+
+// Function to render the package summaries as a markdown section, one
+// subsection per package headed by its name and import path; empty without
+// summaries
+func renderPackageSummaries(summaries []PackageSummary, msgs messages) string {
+	if len(summaries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n# %s\n", msgs.text("packages"))
+	for _, summary := range summaries {
+		fmt.Fprintf(&b, "\n## %s\n\n`%s`\n\n%s\n", msgs.text("package_heading", summary.Name), summary.Package, summary.Summary)
+	}
+	return b.String()
+}
+
+// Function to build the prompt asking for the overview of a package, from
+// its package comment, its exported symbols and its generated doc with the
+// interfaces and implementations
+func packageSummaryPrompt(pkg *packageDoc) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write an overview of the Go package %s in 2 to 4 sentences: what it is for and how its interfaces and types relate. "+
+		"Start with \"Package %s\", as a Go package comment does, and reply with plain sentences, without headings, lists or markdown.\n", pkg.Name, pkg.Name)
+	if comment := readPackageComment(pkg.Dir, pkg.Name); comment != "" {
+		fmt.Fprintf(&b, "\nIts current package comment:\n%s", comment)
+	}
+	if symbols := exportedSymbols(pkg); len(symbols) > 0 {
+		fmt.Fprintf(&b, "\nExported symbols: %s\n", strings.Join(symbols, ", "))
+	}
+	fmt.Fprintf(&b, "\n%s", renderPackageDoc(pkg))
+	return b.String()
+}
+
+// Function to list the exported symbols known of a package: its
+// interfaces, other types, their New constructors, then its constants and
+// variables
+func exportedSymbols(pkg *packageDoc) []string {
+	var symbols []string
+	for _, result := range pkg.Interfaces {
+		if result.Anonymous == "" {
+			symbols = append(symbols, result.InterfaceName)
+		}
+	}
+	for _, decl := range pkg.Types {
+		symbols = append(symbols, decl.Name)
+	}
+	for _, decl := range pkg.Types {
+		for _, constructor := range decl.Constructors {
+			name, _, _ := strings.Cut(constructor, "(")
+			symbols = append(symbols, name)
+		}
+	}
+	for _, value := range pkg.Values {
+		symbols = append(symbols, value.Name)
+	}
+	return symbols
+}
+
+// Function to read the package comment of the package in a directory, from
+// whichever of its non-test files has one; empty when none does
+func readPackageComment(dir, name string) string {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return ""
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		parsed, err := parser.ParseFile(fset, file, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil || parsed.Name.Name != name || parsed.Doc == nil {
+			continue
+		}
+		return parsed.Doc.Text()
+	}
+	return ""
+}
+
+// Function to write a summary as the package comment of a new doc.go in the
+// package's directory, unless the package already has a comment or a
+// doc.go. Returns the path written, or "" when it was left alone.
+func writePackageComment(summary PackageSummary) (string, error) {
+	if readPackageComment(summary.dir, summary.Name) != "" {
+		return "", nil
+	}
+	path := filepath.Join(summary.dir, packageCommentFile)
+	if _, err := os.Stat(path); err == nil {
+		log.Printf("Warning: not writing the summary of %s, as %s exists without a package comment", summary.Package, path)
+		return "", nil
+	}
+	return path, os.WriteFile(path, []byte(packageCommentSource(summary.Name, summary.Summary)), 0o644)
+}
+
+// Function to make the source of a doc.go holding only a package comment,
+// with the text wrapped at 80 columns
+func packageCommentSource(name, text string) string {
+	var b strings.Builder
+	for i, paragraph := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			b.WriteString("//\n")
+		}
+		line := "//"
+		for _, word := range strings.Fields(paragraph) {
+			if len(line) > 2 && len(line)+1+len(word) > 80 {
+				b.WriteString(line + "\n")
+				line = "//"
+			}
+			line += " " + word
+		}
+		b.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&b, "package %s\n", name)
+	return b.String()
+}
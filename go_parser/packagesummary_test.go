@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizePackages(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "// Package api declares the storage ports.\npackage api\n\n// Store persists items.\ntype Store interface{ Get(id string) string }\n")
+	writeFile(t, filepath.Join(root, "memory", "store.go"), `package memory
+
+type Store struct{}
+
+func NewStore() *Store { return &Store{} }
+
+func (*Store) Get(id string) string { return id }
+`)
+
+	server, calls := fakeOpenAI(t)
+	config := &Config{
+		GoFilePath: ifacePath, GoDirectory: root,
+		APIKey: "test-key", BaseURL: server.URL, NoStream: true,
+		PackageSummaries: true, WritePackageDocs: true,
+	}
+	ctx := context.Background()
+	report := analyze(ctx, config)
+
+	packages := groupPackages(report)
+	prompt := packageSummaryPrompt(packages[1])
+	for _, want := range []string{"Go package memory in 2 to 4 sentences", "Exported symbols: Store, NewStore", "`api.Store`"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt lacks %q:\n%s", want, prompt)
+		}
+	}
+	if prompt := packageSummaryPrompt(packages[0]); !strings.Contains(prompt, "Its current package comment:\nPackage api declares the storage ports.\n") {
+		t.Errorf("prompt lacks the package comment:\n%s", prompt)
+	}
+
+	if err := summarizePackages(ctx, config, report); err != nil {
+		t.Fatalf("summarizePackages: %v", err)
+	}
+	if *calls != 2 || len(report.Packages) != 2 {
+		t.Fatalf("requests = %d, summaries = %+v, want one per package", *calls, report.Packages)
+	}
+	if memory := report.Packages[1]; memory.Package != "memory" || memory.Name != "memory" || memory.Summary != "Store persists items." {
+		t.Errorf("memory summary = %+v", memory)
+	}
+
+	// Only the package without a comment gets a doc.go
+	data, err := os.ReadFile(filepath.Join(root, "memory", "doc.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "// Store persists items.\npackage memory\n" {
+		t.Errorf("doc.go = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "api", "doc.go")); !os.IsNotExist(err) {
+		t.Errorf("api got a doc.go next to its package comment: %v", err)
+	}
+
+	markdown := report.Markdown()
+	if !strings.Contains(markdown, "\n# Packages\n\n## Package api\n\n`api`\n\nStore persists items.\n\n## Package memory\n\n`memory`\n\nStore persists items.\n") {
+		t.Errorf("markdown lacks the summaries:\n%s", markdown)
+	}
+	if overview := groupPackages(report)[1].overview; overview != "Store persists items." {
+		t.Errorf("package doc overview = %q", overview)
+	}
+}
+
+// A provider that can't be set up is returned as an error, so render -watch
+// keeps running, marked with the provider exit code for one-shot runs
+func TestSummarizePackagesProviderError(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface{ Get(id string) string }\n")
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root, APIKey: "test-key", Provider: "bogus", PackageSummaries: true}
+	ctx := context.Background()
+	report := analyze(ctx, config)
+
+	err := summarizePackages(ctx, config, report)
+	if err == nil || !strings.Contains(err.Error(), "getting package summaries") {
+		t.Fatalf("err = %v, want the provider error", err)
+	}
+	if code := exitCode(err); code != exitProvider {
+		t.Errorf("exit code = %d, want %d", code, exitProvider)
+	}
+}
+
+func TestPackageCommentSource(t *testing.T) {
+	text := "Package cache keeps recently used items in memory so that repeated lookups skip the backing store.\n\nIt is safe for concurrent use."
+	want := `// Package cache keeps recently used items in memory so that repeated lookups
+// skip the backing store.
+//
+// It is safe for concurrent use.
+package cache
+`
+	if got := packageCommentSource("cache", text); got != want {
+		t.Errorf("source:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Longest a post_process_command may run
+const postProcessTimeout = time.Minute
+
+// Function to wrap generated documentation in doc_header and doc_footer and
+// pipe it through post_process_command, which reads the markdown on stdin
+// and writes the result to stdout. When the command fails, the document is
+// returned with only the header and footer, along with the error.
+func postProcess(ctx context.Context, config *Config, doc string) (string, error) {
+	if config == nil {
+		return doc, nil
+	}
+	if config.DocHeader != "" {
+		doc = strings.TrimRight(config.DocHeader, "\n") + "\n\n" + doc
+	}
+	if config.DocFooter != "" {
+		doc = strings.TrimRight(doc, "\n") + "\n\n" + config.DocFooter
+	}
+	if len(config.PostProcessCommand) == 0 {
+		return doc, nil
+	}
+
+	// An interrupted run still gets its partial documentation processed
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), postProcessTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, config.PostProcessCommand[0], config.PostProcessCommand[1:]...)
+	cmd.Stdin = strings.NewReader(doc)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return doc, fmt.Errorf("post_process_command %s: %w", config.PostProcessCommand[0], err)
+	}
+	// Rather keep the document than replace it with nothing
+	if strings.TrimSpace(stdout.String()) == "" {
+		return doc, fmt.Errorf("post_process_command %s printed nothing", config.PostProcessCommand[0])
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPostProcess(t *testing.T) {
+	config := &Config{DocHeader: "# Docs\n", DocFooter: "_generated_"}
+	doc, err := postProcess(context.Background(), config, "body\n")
+	if err != nil || doc != "# Docs\n\nbody\n\n_generated_" {
+		t.Errorf("header and footer: %q, %v", doc, err)
+	}
+
+	config.PostProcessCommand = []string{"tr", "a-z", "A-Z"}
+	if doc, err = postProcess(context.Background(), config, "body\n"); err != nil || doc != "# DOCS\n\nBODY\n\n_GENERATED_" {
+		t.Errorf("command: %q, %v", doc, err)
+	}
+
+	// A failing or silent command leaves the wrapped document as it was
+	for _, command := range [][]string{{"sh", "-c", "echo boom >&2; exit 3"}, {"true"}} {
+		config.PostProcessCommand = command
+		doc, err = postProcess(context.Background(), config, "body\n")
+		if err == nil || doc != "# Docs\n\nbody\n\n_generated_" {
+			t.Errorf("%v: %q, %v", command, doc, err)
+		}
+	}
+	if !strings.Contains(err.Error(), "printed nothing") {
+		t.Errorf("silent command error = %v", err)
+	}
+}
+
+func TestLLMSinkPostProcesses(t *testing.T) {
+	server, _ := fakeOpenAI(t)
+	output := filepath.Join(t.TempDir(), "docs.md")
+	config := &Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true, DocFooter: "_generated_", PostProcessCommand: []string{"tr", "a-z", "A-Z"}}
+
+	sink := &LLMSink{Config: config, Tracker: newUsageTracker(config), Output: output}
+	if err := sink.Send(context.Background(), testResults()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "STORE PERSISTS ITEMS.\n\n_GENERATED_\n" {
+		t.Errorf("output = %q", data)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// progressReporter periodically prints how many files have been processed
+// so long directory walks don't look like the tool has hung.
+type progressReporter struct {
+	processed atomic.Int64
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// Function to start a reporter that prints to stderr on every tick
+func startProgress(interval time.Duration) *progressReporter {
+	p := &progressReporter{
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fmt.Fprintf(os.Stderr, "\rProcessed %d files...", p.processed.Load())
+			case <-p.done:
+				fmt.Fprintf(os.Stderr, "\rProcessed %d files.   \n", p.processed.Load())
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+// Function to record one more processed file; safe on a nil reporter
+func (p *progressReporter) increment() {
+	if p != nil {
+		p.processed.Add(1)
+	}
+}
+
+// Function to stop the reporter and print the final count
+func (p *progressReporter) stop() {
+	if p != nil {
+		close(p.done)
+		<-p.stopped
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+)
+
+// Placeholder in configured messages that is replaced with the analysis
+const resultsPlaceholder = "{{results}}"
+
+// Placeholder in configured messages that is replaced with the name of the
+// doc_language, e.g. "Japanese"
+const languagePlaceholder = "{{language}}"
+
+// Message roles accepted in the messages config
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// PromptMessage is one turn of the conversation sent to the model
+type PromptMessage struct {
+	Role    string `yaml:"role" json:"role" toml:"role"`
+	Content string `yaml:"content" json:"content" toml:"content"`
+}
+
+// Function to build the conversation for one request: the configured
+// messages with the results put in place of the placeholder, or a single
+// user message with the results, followed by the assistant seed if any. A
+// doc_language other than English adds a system message first, unless the
+// configured messages place the language themselves.
+func buildMessages(config *Config, results string) []PromptMessage {
+	messages := []PromptMessage{{Role: RoleUser, Content: results}}
+	placed := false
+	if len(config.Messages) > 0 {
+		language := docMessages(config).text("language")
+		messages = make([]PromptMessage, len(config.Messages))
+		for i, message := range config.Messages {
+			placed = placed || strings.Contains(message.Content, languagePlaceholder)
+			content := strings.ReplaceAll(message.Content, languagePlaceholder, language)
+			messages[i] = PromptMessage{Role: message.Role, Content: strings.ReplaceAll(content, resultsPlaceholder, results)}
+		}
+	}
+	if instruction := languageInstruction(config); instruction != "" && !placed {
+		messages = append([]PromptMessage{{Role: RoleSystem, Content: instruction}}, messages...)
+	}
+	if config.AssistantSeed != "" {
+		messages = append(messages, PromptMessage{Role: RoleAssistant, Content: config.AssistantSeed})
+	}
+	return messages
+}
+
+// Function to check the configured messages: known roles, and the results
+// placeholder somewhere so the analysis is actually sent
+func validateMessages(messages []PromptMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	placeholder := false
+	for _, message := range messages {
+		switch message.Role {
+		case RoleSystem, RoleUser, RoleAssistant:
+		default:
+			return configError("messages", "role %q (use system, user or assistant)", message.Role)
+		}
+		placeholder = placeholder || strings.Contains(message.Content, resultsPlaceholder)
+	}
+	if !placeholder {
+		return configError("messages", "none of them contains the %s placeholder", resultsPlaceholder)
+	}
+	return nil
+}
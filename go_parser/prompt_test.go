@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBuildMessages(t *testing.T) {
+	if got, want := buildMessages(&Config{}, "RESULTS"), []PromptMessage{{Role: RoleUser, Content: "RESULTS"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("default messages = %+v, want %+v", got, want)
+	}
+
+	config := &Config{
+		Messages: []PromptMessage{
+			{Role: RoleSystem, Content: "You write Go docs."},
+			{Role: RoleUser, Content: "Document:\n{{results}}"},
+		},
+		AssistantSeed: "# Interfaces\n",
+	}
+	want := []PromptMessage{
+		{Role: RoleSystem, Content: "You write Go docs."},
+		{Role: RoleUser, Content: "Document:\nRESULTS"},
+		{Role: RoleAssistant, Content: "# Interfaces\n"},
+	}
+	if got := buildMessages(config, "RESULTS"); !reflect.DeepEqual(got, want) {
+		t.Errorf("messages = %+v, want %+v", got, want)
+	}
+}
+
+func TestValidateMessages(t *testing.T) {
+	if err := validateMessages([]PromptMessage{{Role: "tool", Content: "{{results}}"}}); err == nil {
+		t.Error("unknown role should be rejected")
+	}
+	if err := validateMessages([]PromptMessage{{Role: RoleUser, Content: "Document these"}}); err == nil {
+		t.Error("messages without the placeholder should be rejected")
+	}
+}
+
+func TestAnthropicMessages(t *testing.T) {
+	var payload struct {
+		System   string          `json:"system"`
+		Messages []PromptMessage `json:"messages"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		fmt.Fprint(w, `{"content": [{"type": "text", "text": " Store persists items."}], "usage": {"input_tokens": 10, "output_tokens": 5}}`)
+	}))
+	defer server.Close()
+
+	client, err := newLLMClient(&Config{
+		Provider: "anthropic",
+		APIKey:   "test-key",
+		BaseURL:  server.URL,
+		Messages: []PromptMessage{
+			{Role: RoleSystem, Content: "You write Go docs."},
+			{Role: RoleUser, Content: "{{results}}"},
+		},
+		AssistantSeed: "# Store\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	completion, err := client.Complete(context.Background(), "RESULTS")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.System != "You write Go docs." {
+		t.Errorf("system = %q", payload.System)
+	}
+	want := []PromptMessage{{Role: RoleUser, Content: "RESULTS"}, {Role: RoleAssistant, Content: "# Store"}}
+	if !reflect.DeepEqual(payload.Messages, want) {
+		t.Errorf("messages = %+v, want %+v", payload.Messages, want)
+	}
+	// The reply continues the seed
+	if completion.Content != "# Store Store persists items." {
+		t.Errorf("content = %q", completion.Content)
+	}
+}
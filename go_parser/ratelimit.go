@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Window the requests_per_minute and tokens_per_minute budgets apply to
+const rateWindow = time.Minute
+
+// A request started within the last rateWindow, with its token budget
+type rateEntry struct {
+	at     time.Time
+	tokens int
+}
+
+// rateLimiter paces requests to stay within a requests and a tokens per
+// minute budget over a sliding window, making requests queue in order
+// rather than fail with 429s
+type rateLimiter struct {
+	requestsPerMinute int
+	tokensPerMinute   int
+	// Held by the request at the front of the queue while it waits
+	turn   chan struct{}
+	window []rateEntry
+	now    func() time.Time
+	sleep  func(ctx context.Context, d time.Duration) error
+	debug  bool
+}
+
+// Function to build the limiter for the configured budgets; nil when
+// neither is set
+func newRateLimiter(config *Config) *rateLimiter {
+	if config.RequestsPerMinute <= 0 && config.TokensPerMinute <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		requestsPerMinute: config.RequestsPerMinute,
+		tokensPerMinute:   config.TokensPerMinute,
+		turn:              make(chan struct{}, 1),
+		now:               time.Now,
+		sleep:             sleepContext,
+		debug:             config.Debug,
+	}
+}
+
+// Function to sleep for d unless ctx is done first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Function to wait until a request with the given token budget fits in
+// both budgets, then count it. Requests are let through in the order they
+// call wait.
+func (l *rateLimiter) wait(ctx context.Context, tokens int) error {
+	select {
+	case l.turn <- struct{}{}:
+		defer func() { <-l.turn }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for {
+		now := l.now()
+		for len(l.window) > 0 && now.Sub(l.window[0].at) >= rateWindow {
+			l.window = l.window[1:]
+		}
+		used := 0
+		for _, entry := range l.window {
+			used += entry.tokens
+		}
+
+		delay := l.delay(now, used, tokens)
+		if delay <= 0 {
+			l.window = append(l.window, rateEntry{at: now, tokens: tokens})
+			l.debugf("sending a request of ~%d tokens (%d requests and ~%d tokens in the last minute)", tokens, len(l.window)-1, used)
+			return nil
+		}
+		l.debugf("waiting %s before a request of ~%d tokens (%d requests and ~%d tokens in the last minute)",
+			delay.Round(time.Millisecond), tokens, len(l.window), used)
+		if err := l.sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// Function to compute how long a request must wait for both budgets to
+// have room for it; 0 when it can go now. A request larger than the whole
+// tokens budget waits for an empty window instead of forever.
+func (l *rateLimiter) delay(now time.Time, used, tokens int) time.Duration {
+	var until time.Time
+	if l.requestsPerMinute > 0 && len(l.window) >= l.requestsPerMinute {
+		until = l.window[len(l.window)-l.requestsPerMinute].at.Add(rateWindow)
+	}
+	if l.tokensPerMinute > 0 && used+tokens > l.tokensPerMinute && len(l.window) > 0 {
+		// Wait for the oldest requests to leave the window until it fits
+		freed := 0
+		expiry := l.window[len(l.window)-1].at.Add(rateWindow)
+		for _, entry := range l.window {
+			freed += entry.tokens
+			if used-freed+tokens <= l.tokensPerMinute {
+				expiry = entry.at.Add(rateWindow)
+				break
+			}
+		}
+		if expiry.After(until) {
+			until = expiry
+		}
+	}
+	return until.Sub(now)
+}
+
+// Function to log a pacing decision when -debug is set
+func (l *rateLimiter) debugf(format string, args ...interface{}) {
+	if l.debug {
+		log.Printf("Debug: rate limit: "+format, args...)
+	}
+}
+
+// rateLimitedClient queues the requests of another client through a
+// rateLimiter, budgeting each by its estimated prompt tokens plus the
+// completion tokens it may use
+type rateLimitedClient struct {
+	LLMClient
+	limiter         *rateLimiter
+	config          *Config
+	maxOutputTokens int
+}
+
+func (c *rateLimitedClient) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	tokens := estimateTokens(prompt) + c.maxOutputTokens
+	for _, message := range c.config.Messages {
+		tokens += estimateTokens(message.Content)
+	}
+	if err := c.limiter.wait(ctx, tokens); err != nil {
+		return nil, err
+	}
+	return c.LLMClient.Complete(ctx, prompt)
+}
+
+// Function to keep send -check-auth working through the limiter
+func (c *rateLimitedClient) checkAuth(ctx context.Context) error {
+	return checkAuthOf(ctx, c.LLMClient, c.config.Provider)
+}
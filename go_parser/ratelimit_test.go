@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Function to build a limiter on a fake clock that sleeping advances,
+// recording each wait; advance moves the clock on
+func fakeRateLimiter(config *Config) (limiter *rateLimiter, waits *[]time.Duration, advance func(time.Duration)) {
+	limiter = newRateLimiter(config)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	waits = new([]time.Duration)
+	advance = func(d time.Duration) { clock = clock.Add(d) }
+	limiter.now = func() time.Time { return clock }
+	limiter.sleep = func(ctx context.Context, d time.Duration) error {
+		*waits = append(*waits, d)
+		advance(d)
+		return nil
+	}
+	return limiter, waits, advance
+}
+
+func TestRateLimiterRequestsPerMinute(t *testing.T) {
+	limiter, waits, _ := fakeRateLimiter(&Config{RequestsPerMinute: 2})
+	for i := 0; i < 3; i++ {
+		if err := limiter.wait(context.Background(), 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The third request waits for the first to leave the window
+	if len(*waits) != 1 || (*waits)[0] != time.Minute {
+		t.Errorf("waits = %v, want one of a minute", *waits)
+	}
+}
+
+func TestRateLimiterTokensPerMinute(t *testing.T) {
+	limiter, waits, advance := fakeRateLimiter(&Config{TokensPerMinute: 10000})
+	ctx := context.Background()
+	limiter.wait(ctx, 6000)
+	advance(10 * time.Second)
+	limiter.wait(ctx, 3000)
+	if len(*waits) != 0 {
+		t.Fatalf("waits = %v, want none while within the budget", *waits)
+	}
+
+	// 9000 tokens used: 4000 more only fit once the first request expires,
+	// 50 seconds from now
+	limiter.wait(ctx, 4000)
+	if len(*waits) != 1 || (*waits)[0] != 50*time.Second {
+		t.Errorf("waits = %v, want 50s", *waits)
+	}
+
+	// A request larger than the whole budget waits for an empty window
+	// rather than forever
+	*waits = nil
+	limiter.wait(ctx, 20000)
+	if len(*waits) != 1 || (*waits)[0] != time.Minute {
+		t.Errorf("waits = %v, want a minute", *waits)
+	}
+}
+
+func TestRateLimiterCancelled(t *testing.T) {
+	limiter := newRateLimiter(&Config{RequestsPerMinute: 1})
+	limiter.wait(context.Background(), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := limiter.wait(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want the context's", err)
+	}
+}
+
+func TestRateLimitedClientLogsPacing(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	server, calls := fakeOpenAI(t)
+	config := &Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true, RequestsPerMinute: 1, MaxCompletionTokens: 500, Debug: true}
+	client, err := newLLMClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	limited, ok := client.(*rateLimitedClient)
+	if !ok {
+		t.Fatalf("client is %T, want it rate limited", client)
+	}
+	if _, err := client.Complete(context.Background(), strings.Repeat("x", 400)); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if *calls != 1 || limited.limiter.window[0].tokens != 600 {
+		t.Errorf("requests = %d, budget = %d, want 1 of 100 prompt + 500 completion tokens", *calls, limited.limiter.window[0].tokens)
+	}
+	if !strings.Contains(logs.String(), "Debug: rate limit: sending a request of ~600 tokens") {
+		t.Errorf("logs = %q", logs.String())
+	}
+}
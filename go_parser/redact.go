@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const redactedText = "[REDACTED]"
+
+// A named pattern whose matches are masked before a prompt leaves the machine
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// Patterns always applied; redact_patterns adds to these
+var defaultSecretPatterns = []secretPattern{
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"bearer-token", regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]{8,}=*`)},
+	{"password-assignment", regexp.MustCompile(`(?i)\b(?:password|passwd|pwd|secret)\s*[:=]+\s*("[^"]*"|'[^']*'|\S+)`)},
+}
+
+// Candidate tokens for the high-entropy check
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_\-=]{24,}`)
+
+// RedactionFinding records one masked match; the secret itself is never kept.
+// Line is counted within the prompt of request number Request, as prompts
+// that don't fit max_prompt_tokens are split over several requests.
+type RedactionFinding struct {
+	Pattern string `json:"pattern"`
+	Request int    `json:"request"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Length  int    `json:"length"`
+}
+
+// Function to compile the default and configured secret patterns
+func secretPatterns(config *Config) ([]secretPattern, error) {
+	patterns := append([]secretPattern(nil), defaultSecretPatterns...)
+	for i, pattern := range config.RedactPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, secretPattern{fmt.Sprintf("custom-%d", i+1), re})
+	}
+	return patterns, nil
+}
+
+// Function to mask secrets in the final prompt text, returning the redacted
+// text and where each masked match was
+func redactPrompt(prompt string, config *Config) (string, []RedactionFinding, error) {
+	patterns, err := secretPatterns(config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var findings []RedactionFinding
+	lines := strings.Split(prompt, "\n")
+	for i, line := range lines {
+		for _, pattern := range patterns {
+			line = maskMatches(line, pattern.re, func(column, length int) {
+				findings = append(findings, RedactionFinding{Pattern: pattern.name, Line: i + 1, Column: column, Length: length})
+			})
+		}
+
+		// Finally mask long random-looking tokens no pattern caught
+		line = maskMatches(line, entropyCandidate, func(column, length int) {
+			findings = append(findings, RedactionFinding{Pattern: "high-entropy", Line: i + 1, Column: column, Length: length})
+		}, isHighEntropy)
+
+		lines[i] = line
+	}
+
+	return strings.Join(lines, "\n"), findings, nil
+}
+
+// Function to replace the matches of re in line, reporting each one. Optional
+// filters decide whether a match really is a secret.
+func maskMatches(line string, re *regexp.Regexp, report func(column, length int), filters ...func(string) bool) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range re.FindAllStringIndex(line, -1) {
+		match := line[loc[0]:loc[1]]
+		if strings.Contains(match, redactedText) || !allow(match, filters) {
+			continue
+		}
+		b.WriteString(line[last:loc[0]])
+		b.WriteString(redactedText)
+		last = loc[1]
+		report(loc[0]+1, len(match))
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+func allow(match string, filters []func(string) bool) bool {
+	for _, filter := range filters {
+		if !filter(match) {
+			return false
+		}
+	}
+	return true
+}
+
+// Function to flag tokens that mix letters and digits and look random
+// enough to be keys rather than identifiers
+func isHighEntropy(token string) bool {
+	if !strings.ContainsAny(token, "0123456789") || strings.IndexFunc(token, isLetter) < 0 {
+		return false
+	}
+	return shannonEntropy(token) >= 4.0
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// Function to compute the Shannon entropy of a string in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(len(s))
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Function to write the redaction report to the redact_report file
+func writeRedactReport(path string, findings []RedactionFinding) error {
+	data, err := json.MarshalIndent(findings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
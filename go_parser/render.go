@@ -0,0 +1,308 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"strings"
+	"unicode"
+)
+
+// Function to render the analysis results as a markdown document, with the
+// static text from msgs. With more than one interface, a table of contents
+// under the title links to their sections.
+func renderMarkdown(results []InterfaceDetails, msgs messages) string {
+	var body strings.Builder
+	starts := make([]int, len(results))
+	for i, result := range results {
+		body.WriteString("\n")
+		starts[i] = body.Len()
+		renderInterface(&body, result, 2, nil, msgs)
+	}
+
+	var b strings.Builder
+	title := "# " + msgs.text("interfaces") + "\n"
+	b.WriteString(title)
+	if len(results) > 1 {
+		b.WriteString("\n")
+		anchors := headingAnchors(title + body.String())
+		for i, result := range results {
+			fmt.Fprintf(&b, "- [%s%s](#%s)\n", result.InterfaceName, result.TypeParams, anchors[len(title)+starts[i]])
+		}
+	}
+	b.WriteString(body.String())
+
+	return b.String()
+}
+
+// Function to find the anchors GitHub gives the headings of a markdown
+// document, by the offset of their line. Every heading counts, so that of
+// an interface named like a subsection, e.g. Usages, gets the -1 suffix of a
+// repeat as it does there. Lines in fenced code blocks aren't headings.
+func headingAnchors(markdown string) map[int]string {
+	anchors := make(map[int]string)
+	seen := make(map[string]int)
+	fenced := false
+	offset := 0
+	for _, line := range strings.SplitAfter(markdown, "\n") {
+		start := offset
+		offset += len(line)
+		line = strings.TrimRight(line, "\n")
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+			continue
+		}
+		text := strings.TrimLeft(line, "#")
+		if fenced || len(text) == len(line) || len(line)-len(text) > 6 || !strings.HasPrefix(text, " ") {
+			continue
+		}
+		anchor := headingSlug(text)
+		if n := seen[anchor]; n > 0 {
+			seen[anchor]++
+			anchor = fmt.Sprintf("%s-%d", anchor, n)
+		} else {
+			seen[anchor] = 1
+		}
+		anchors[start] = anchor
+	}
+	return anchors
+}
+
+// Function to slugify a heading as GitHub does: lower case, with spaces as
+// dashes and punctuation other than dashes and underscores left out, e.g.
+// "Cache[K comparable]" becomes "cachek-comparable"
+func headingSlug(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(heading)) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Function to render one interface as a markdown section whose heading is
+// at the given level. With links, the interfaces its methods and embeds
+// refer to are linked.
+func renderInterface(b *strings.Builder, result InterfaceDetails, level int, links *interfaceLinks, msgs messages) {
+	heading := strings.Repeat("#", level)
+	sub := heading + "#"
+
+	fmt.Fprintf(b, "%s %s%s\n\n", heading, result.InterfaceName, result.TypeParams)
+	if result.Package != "" {
+		fmt.Fprintf(b, "%s\n\n", msgs.text("package", result.Package))
+	}
+	if result.Anonymous != "" {
+		fmt.Fprintf(b, "%s\n\n", msgs.text("anonymous", result.Anonymous))
+	}
+	if result.Deprecated != "" {
+		fmt.Fprintf(b, "> %s\n\n", msgs.text("deprecated_notice", result.Deprecated))
+	}
+	methodDocs := make(map[string]string)
+	if doc := result.generatedDoc; doc != nil {
+		fmt.Fprintf(b, "%s\n\n", strings.TrimSpace(doc.Summary))
+		for _, method := range doc.MethodDocs {
+			methodDocs[method.Name] = strings.TrimSpace(method.Doc)
+		}
+	}
+	fmt.Fprintf(b, "%s %s\n\n", sub, msgs.text("methods"))
+	for _, method := range methodsInOrder(result.Methods) {
+		fmt.Fprintf(b, "- `%s`", method.Signature)
+		if refs := links.refs(result, signatureExpr(method)); len(refs) > 0 {
+			fmt.Fprintf(b, " (%s)", msgs.text("see", strings.Join(refs, ", ")))
+		}
+		if method.Deprecated != "" {
+			fmt.Fprintf(b, " (%s)", msgs.text("deprecated_notice", method.Deprecated))
+		}
+		if doc := methodDocs[method.Name]; doc != "" {
+			fmt.Fprintf(b, ": %s", doc)
+		}
+		b.WriteString("\n")
+	}
+	if len(result.Embeds) > 0 {
+		var embeds []string
+		for _, embed := range result.Embeds {
+			expr, _ := parser.ParseExpr(embed)
+			if refs := links.refs(result, expr); len(refs) == 1 {
+				embeds = append(embeds, refs[0])
+			} else {
+				embeds = append(embeds, "`"+embed+"`")
+			}
+		}
+		fmt.Fprintf(b, "\n%s\n", msgs.text("embeds", strings.Join(embeds, ", ")))
+	}
+	if len(result.UnresolvedEmbeds) > 0 {
+		fmt.Fprintf(b, "%s\n", msgs.text("unresolved_embeds", strings.Join(result.UnresolvedEmbeds, "`, `")))
+	}
+
+	if result.Sealed {
+		fmt.Fprintf(b, "\n> %s\n", msgs.text("sealed", result.Package))
+	}
+
+	if doc := result.generatedDoc; doc != nil && len(doc.Caveats) > 0 {
+		fmt.Fprintf(b, "\n%s %s\n\n", sub, msgs.text("caveats"))
+		for _, caveat := range doc.Caveats {
+			fmt.Fprintf(b, "- %s\n", strings.TrimSpace(caveat))
+		}
+	}
+
+	fmt.Fprintf(b, "\n%s %s\n\n", sub, msgs.text("implementations"))
+	if implementationCount(result) == 0 {
+		b.WriteString(msgs.text("none_found") + "\n")
+	}
+	for _, impl := range result.Implementations {
+		satisfaction := msgs.text("satisfied_by", impl.ReceiverSatisfaction)
+		if impl.ReceiverSatisfaction == SatisfiedByPointer {
+			satisfaction = msgs.text("pointer_only")
+		}
+		fmt.Fprintf(b, "- `%s` (%s)", impl.displayName(), satisfaction)
+		if impl.Kind == KindTestDouble {
+			b.WriteString(", " + msgs.text("test_double"))
+		}
+		if len(impl.StdlibInterfaces) > 0 {
+			fmt.Fprintf(b, ", %s", msgs.text("also_implements", strings.Join(impl.StdlibInterfaces, "`, `")))
+		}
+		if impl.Delegated {
+			fmt.Fprintf(b, ", %s", msgs.text("delegates_to", strings.Join(impl.DelegateFields, "`, `")))
+		}
+		if impl.Partial {
+			fmt.Fprintf(b, " (%s)", msgs.text("partial_match"))
+		}
+		if impl.Deprecated != "" {
+			fmt.Fprintf(b, ", %s", msgs.text("deprecated_notice", impl.Deprecated))
+		}
+		b.WriteString("\n")
+	}
+	if result.hiddenTestDoubles > 0 {
+		fmt.Fprintf(b, "- %s\n", msgs.text("hidden_test_doubles", result.hiddenTestDoubles))
+	}
+
+	if len(result.Implementations) > 0 {
+		fmt.Fprintf(b, "\n%s %s\n\n", sub, msgs.text("method_matrix"))
+		renderMethodMatrix(b, result, sub+"#", msgs)
+	}
+
+	if len(result.NearMisses) > 0 {
+		fmt.Fprintf(b, "\n%s %s\n\n", sub, msgs.text("near_misses"))
+		fmt.Fprintf(b, "%s\n\n", msgs.text("near_misses_intro"))
+		for _, miss := range result.NearMisses {
+			fmt.Fprintf(b, "- `%s.%s` (%s)\n", miss.Package, miss.TypeName, miss.Position)
+			for _, mismatch := range miss.Mismatches {
+				fmt.Fprintf(b, "  - %s\n", msgs.text("mismatch", mismatch.Method, mismatch.TypeSignature, mismatch.InterfaceSignature))
+			}
+		}
+	}
+
+	if examples := interfaceExamples(result); len(examples) > 0 {
+		fmt.Fprintf(b, "\n%s %s\n", sub, msgs.text("examples"))
+		for _, example := range examples {
+			renderExample(b, example, sub+"#", msgs)
+		}
+	}
+
+	fmt.Fprintf(b, "\n%s %s\n\n", sub, msgs.text("usages"))
+	functions, packages := usageCounts(result.Usages)
+	fmt.Fprintf(b, "%s\n\n", msgs.text("usage_summary", implementationCount(result), functions, packages, len(result.Usages)))
+	for _, usage := range result.Usages {
+		fmt.Fprintf(b, "- %s", msgs.text("usage", usage.Kind, usage.Symbol, usage.Package, usage.Position))
+		if usage.Deprecated != "" {
+			fmt.Fprintf(b, ", %s", msgs.text("deprecated_notice", usage.Deprecated))
+		}
+		b.WriteString("\n")
+	}
+}
+
+// Function to list the examples of an interface, of its methods in order,
+// then of its implementations
+func interfaceExamples(result InterfaceDetails) []Example {
+	examples := result.Examples
+	for _, method := range methodsInOrder(result.Methods) {
+		examples = append(examples, method.Examples...)
+	}
+	for _, impl := range result.Implementations {
+		examples = append(examples, impl.Examples...)
+	}
+	return examples
+}
+
+// Function to render an example under a heading naming it: its doc, its
+// code in a fenced Go block and its expected output, if any
+func renderExample(b *strings.Builder, example Example, heading string, msgs messages) {
+	fmt.Fprintf(b, "\n%s %s\n\n", heading, example.Name)
+	if example.Doc != "" {
+		fmt.Fprintf(b, "%s\n\n", example.Doc)
+	}
+	fmt.Fprintf(b, "```go\n%s```\n", example.Code)
+	if example.Output != "" {
+		key := "example_output"
+		if example.Unordered {
+			key = "example_unordered_output"
+		}
+		fmt.Fprintf(b, "\n%s\n\n```\n%s\n```\n", msgs.text(key), example.Output)
+	}
+}
+
+// Implementations above which the method matrix is split into one table per
+// implementation, as a column per implementation gets too wide to read
+const maxMatrixImplementations = 4
+
+// Function to render where each implementation declares each interface
+// method and whether that method is documented (✔) or not (✘). A method the
+// implementation doesn't declare itself, e.g. one of an unresolved embed, is
+// shown as "-". A method delegated to an embedded interface field is shown
+// at the field, via the field, and one overriding the field's says so.
+func renderMethodMatrix(b *strings.Builder, result InterfaceDetails, heading string, msgs messages) {
+	cell := func(impl Implementation, name string) (string, string) {
+		for _, method := range impl.Methods {
+			if method.Name == name {
+				if method.DelegatedTo != "" {
+					return method.Position, msgs.text("via", method.DelegatedTo)
+				}
+				documented := "✘"
+				if method.Documented {
+					documented = "✔"
+				}
+				if method.Overrides != "" {
+					documented += " " + msgs.text("overrides", method.Overrides)
+				}
+				return method.Position, documented
+			}
+		}
+		return "-", "-"
+	}
+
+	if len(result.Implementations) > maxMatrixImplementations {
+		for i, impl := range result.Implementations {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(b, "%s `%s.%s`\n\n| %s | %s | %s |\n| --- | --- | --- |\n", heading, impl.Package, impl.TypeName,
+				msgs.text("method"), msgs.text("position"), msgs.text("documented"))
+			for _, method := range methodsInOrder(result.Methods) {
+				position, documented := cell(impl, method.Name)
+				fmt.Fprintf(b, "| `%s` | %s | %s |\n", method.Name, position, documented)
+			}
+		}
+		return
+	}
+
+	fmt.Fprintf(b, "| %s |", msgs.text("method"))
+	for _, impl := range result.Implementations {
+		fmt.Fprintf(b, " `%s.%s` |", impl.Package, impl.TypeName)
+	}
+	b.WriteString("\n| --- |" + strings.Repeat(" --- |", len(result.Implementations)) + "\n")
+	for _, method := range methodsInOrder(result.Methods) {
+		fmt.Fprintf(b, "| `%s` |", method.Name)
+		for _, impl := range result.Implementations {
+			position, documented := cell(impl, method.Name)
+			if position == "-" {
+				b.WriteString(" - |")
+				continue
+			}
+			fmt.Fprintf(b, " %s %s |", position, documented)
+		}
+		b.WriteString("\n")
+	}
+}
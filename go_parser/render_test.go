@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMethodMatrix(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api", "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface {\n\tGet(id string) string\n\tPut(id string)\n}\n")
+	writeFile(t, filepath.Join(root, "mem", "mem.go"), `package mem
+
+type Store struct{}
+
+// Get looks the id up.
+func (Store) Get(id string) string { return id }
+
+func (*Store) Put(id string) {}
+`)
+
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{root}, interfaces, config)
+
+	markdown := renderMarkdown(results, nil)
+	memPath := filepath.ToSlash(filepath.Join(root, "mem", "mem.go"))
+	for _, want := range []string{
+		"| Method | `mem.Store` |",
+		fmt.Sprintf("| `Get` | %s:6 ✔ |", memPath),
+		fmt.Sprintf("| `Put` | %s:8 ✘ |", memPath),
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+}
+
+func TestPointerOnlyAnnotation(t *testing.T) {
+	results := []InterfaceDetails{{
+		InterfaceName: "Saver",
+		Methods:       []MethodDetails{{Name: "Save", Signature: "Save() error"}},
+		Implementations: []Implementation{
+			{TypeName: "User", Package: "model", ReceiverSatisfaction: SatisfiedByPointer},
+			{TypeName: "Post", Package: "model", ReceiverSatisfaction: SatisfiedByBoth},
+		},
+	}}
+
+	markdown := renderMarkdown(results, nil)
+	for _, want := range []string{"- `*model.User` (pointer only)", "- `model.Post` (satisfied by both)"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+	if message := formatResultsForMessage(results); !strings.Contains(message, "*model.User (pointer only") || !strings.Contains(message, "model.Post (satisfied by both)") {
+		t.Errorf("message lacks the annotations:\n%s", message)
+	}
+}
+
+func TestMethodMatrixSplitsWideTables(t *testing.T) {
+	result := InterfaceDetails{
+		InterfaceName: "Store",
+		Methods:       []MethodDetails{{Name: "Get"}, {Name: "Put"}},
+	}
+	for i := 0; i <= maxMatrixImplementations; i++ {
+		result.Implementations = append(result.Implementations, Implementation{
+			TypeName: fmt.Sprintf("Store%d", i),
+			Package:  "impl",
+			Methods:  []ImplementedMethod{{Name: "Get", Position: "impl/store.go:3", Documented: true}},
+		})
+	}
+
+	var b strings.Builder
+	renderMethodMatrix(&b, result, "###", nil)
+	markdown := b.String()
+	if got := strings.Count(markdown, "| Method | Position | Documented |"); got != len(result.Implementations) {
+		t.Errorf("got %d tables, want one per implementation:\n%s", got, markdown)
+	}
+	if !strings.Contains(markdown, "### `impl.Store0`") || !strings.Contains(markdown, "| `Put` | - | - |") {
+		t.Errorf("unexpected tables:\n%s", markdown)
+	}
+}
+
+func TestTableOfContents(t *testing.T) {
+	results := []InterfaceDetails{
+		{InterfaceName: "Store", Methods: []MethodDetails{{Name: "Get", Signature: "Get() string"}}},
+		{InterfaceName: "Cache", TypeParams: "[K comparable]"},
+		{InterfaceName: "Usages"},
+	}
+	markdown := renderMarkdown(results, nil)
+	want := "# Interfaces\n\n- [Store](#store)\n- [Cache[K comparable]](#cachek-comparable)\n- [Usages](#usages-2)\n\n## Store\n"
+	if !strings.HasPrefix(markdown, want) {
+		t.Errorf("markdown starts with:\n%s\nwant:\n%s", markdown[:min(len(markdown), len(want))], want)
+	}
+
+	// A single interface needs no table
+	if markdown := renderMarkdown(results[:1], nil); !strings.HasPrefix(markdown, "# Interfaces\n\n## Store\n") {
+		t.Errorf("markdown:\n%s", markdown)
+	}
+
+	if got := headingSlug(" Séquence_1 – v2.0 "); got != "séquence_1--v20" {
+		t.Errorf("slug = %q", got)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime/debug"
+	"time"
+)
+
+// Report is the full result of an analysis run
+type Report struct {
+	// Version of this JSON's schema, see schemaVersion
+	SchemaVersion int `json:"schema_version"`
+	// When, by which build of the tool and from which paths the report was
+	// generated, to trace where a report came from
+	GeneratedAt time.Time   `json:"generated_at"`
+	ToolVersion string      `json:"tool_version"`
+	Paths       ReportPaths `json:"paths"`
+	// What the run found, nested under results in the JSON
+	ReportResults `json:"results"`
+
+	// Package-level types of the scanned files, for the undocumented-symbol
+	// report
+	types []typeDeclaration
+	// Config the report was analyzed with, for Markdown and SendTo
+	config *Config
+}
+
+// ReportResults are the findings of a run, which follow the metadata of the
+// report. Before schema version 13 they were at the top level of the JSON.
+type ReportResults struct {
+	Interfaces []InterfaceDetails `json:"interfaces"`
+	Summary    Summary            `json:"summary"`
+	// Set when the run was interrupted, so only part of the code was scanned
+	Partial bool `json:"partial,omitempty"`
+	// Interface names declared more than once
+	Conflicts []InterfaceConflict `json:"conflicts,omitempty"`
+	// Exported constants and variables of the scanned files
+	Values []ValueDeclaration `json:"values,omitempty"`
+	// Exported interface and implementation methods without a doc comment
+	Undocumented []UndocumentedMethod `json:"undocumented,omitempty"`
+	// Scanned files skipped as they couldn't be read or parsed
+	ParseErrors []FileError `json:"parse_errors,omitempty"`
+	// Scanned files and directories skipped by max_file_size_bytes or
+	// max_depth
+	SkippedFiles []FileError `json:"skipped_files,omitempty"`
+	// Which packages implement or consume the interfaces of which others
+	Graph *PackageGraph `json:"graph,omitempty"`
+	// Overviews of the scanned packages, with package_summaries set
+	Packages []PackageSummary `json:"packages,omitempty"`
+}
+
+// ReportPaths are the paths a report was analyzed from, as configured
+type ReportPaths struct {
+	// Files and directories interfaces were read from
+	InterfaceSources []string `json:"interface_sources"`
+	// Directories scanned for implementations
+	Roots []string `json:"roots"`
+}
+
+// Function to name the build of the tool: the module version when it was
+// installed with go install, else the VCS revision it was built from, with
+// -dirty for uncommitted changes, else "devel"
+func toolVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "devel"
+	}
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	var revision, modified string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			modified = setting.Value
+		}
+	}
+	if revision == "" {
+		return "devel"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if modified == "true" {
+		revision += "-dirty"
+	}
+	return "devel-" + revision
+}
+
+// FileError is a Go file the scan skipped, with the reason
+type FileError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// Summary gives a birds-eye view of the abstraction usage in a codebase
+type Summary struct {
+	TotalInterfaces        int     `json:"total_interfaces"`
+	WithImplementations    int     `json:"with_implementations"`
+	WithoutImplementations int     `json:"without_implementations"`
+	AverageImplementations float64 `json:"average_implementations"`
+	FilteredOutInterfaces  int     `json:"filtered_out_interfaces"`
+	// Types that have the method names of an interface but not its signatures
+	NearMisses int `json:"near_misses,omitempty"`
+	// Counts per interface source and root when there are several
+	Roots []RootSummary `json:"roots,omitempty"`
+}
+
+// Function to compute the summary statistics for the analyzed interfaces
+func summarize(interfaces []InterfaceDetails, filtered int) Summary {
+	summary := Summary{
+		TotalInterfaces:       len(interfaces),
+		FilteredOutInterfaces: filtered,
+	}
+
+	implementations := 0
+	for _, iface := range interfaces {
+		if len(iface.Implementations) > 0 {
+			summary.WithImplementations++
+		} else {
+			summary.WithoutImplementations++
+		}
+		implementations += len(iface.Implementations)
+		summary.NearMisses += len(iface.NearMisses)
+	}
+	if len(interfaces) > 0 {
+		summary.AverageImplementations = float64(implementations) / float64(len(interfaces))
+	}
+	return summary
+}
+
+// Function to print the summary at the end of a run
+func printSummary(w io.Writer, summary Summary) {
+	fmt.Fprintf(w, "Interfaces: %d (%d with implementations, %d without, %d filtered out), %.2f implementations per interface on average\n",
+		summary.TotalInterfaces, summary.WithImplementations, summary.WithoutImplementations,
+		summary.FilteredOutInterfaces, summary.AverageImplementations)
+	if summary.NearMisses > 0 {
+		fmt.Fprintf(w, "Near misses: %d types have the method names of an interface but other signatures; see near_misses\n", summary.NearMisses)
+	}
+	for _, root := range summary.Roots {
+		fmt.Fprintf(w, "  %s: %d interfaces, %d implementations\n", root.Path, root.Interfaces, root.Implementations)
+	}
+}
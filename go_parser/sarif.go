@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// A check for a missing doc comment, reported as a SARIF rule
+type sarifRule struct {
+	ID               string `json:"id"`
+	Name             string `json:"name"`
+	ShortDescription struct {
+		Text string `json:"text"`
+	} `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+// Output formats of analyze
+const (
+	FormatJSON  = "json"
+	FormatSarif = "sarif"
+	FormatDot   = "dot"
+	// Graphviz graph of the packages, see PackageGraph
+	FormatPackageDot = "package-dot"
+	// GitHub Actions annotations for the undocumented methods
+	FormatGitHub = "github"
+)
+
+// Rule IDs of the undocumented-symbol checks
+const (
+	RuleUndocumentedInterface = "GODOC001"
+	RuleUndocumentedMethod    = "GODOC002"
+	RuleUndocumentedType      = "GODOC003"
+)
+
+// The undocumented-symbol rules with their default levels; sarif_severities
+// overrides the levels per rule ID
+var undocumentedRules = []struct {
+	id, name, description, level string
+}{
+	{RuleUndocumentedInterface, "undocumented-interface", "Exported interface has no doc comment", "warning"},
+	{RuleUndocumentedMethod, "undocumented-interface-method", "Exported interface method has no doc comment", "note"},
+	{RuleUndocumentedType, "undocumented-type", "Exported type has no doc comment", "warning"},
+}
+
+// SARIF levels accepted in sarif_severities
+var sarifLevels = map[string]bool{"none": true, "note": true, "warning": true, "error": true}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool struct {
+		Driver struct {
+			Name  string      `json:"name"`
+			Rules []sarifRule `json:"rules"`
+		} `json:"driver"`
+	} `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifResult struct {
+	RuleID    string `json:"ruleId"`
+	RuleIndex int    `json:"ruleIndex"`
+	Level     string `json:"level"`
+	Message   struct {
+		Text string `json:"text"`
+	} `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifFix struct {
+	Description struct {
+		Text string `json:"text"`
+	} `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation struct {
+		URI string `json:"uri"`
+	} `json:"artifactLocation"`
+	Replacements []sarifReplacement `json:"replacements"`
+}
+
+// A replacement with an empty deleted region inserts text before it
+type sarifReplacement struct {
+	DeletedRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine"`
+		EndColumn   int `json:"endColumn"`
+	} `json:"deletedRegion"`
+	InsertedContent struct {
+		Text string `json:"text"`
+	} `json:"insertedContent"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine   int `json:"startLine"`
+			StartColumn int `json:"startColumn,omitempty"`
+		} `json:"region"`
+	} `json:"physicalLocation"`
+}
+
+// Function to check the rule IDs and levels of sarif_severities
+func validateSarifSeverities(severities map[string]string) error {
+	for id, level := range severities {
+		if ruleIndex(id) < 0 {
+			return configError("sarif_severities", "unknown rule %q", id)
+		}
+		if !sarifLevels[level] {
+			return configError("sarif_severities", "level %q for %s (use none, note, warning or error)", level, id)
+		}
+	}
+	return nil
+}
+
+func ruleIndex(id string) int {
+	for i, rule := range undocumentedRules {
+		if rule.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Function to build a SARIF 2.1.0 log with one result per exported
+// interface, interface method and package-level type lacking a doc comment.
+// docs maps symbols ("Store", "Store.Get") to generated doc comments, which
+// are offered as fixes.
+func buildSarif(report *Report, config *Config, docs map[string]string) sarifLog {
+	run := sarifRun{Results: []sarifResult{}}
+	run.Tool.Driver.Name = "go_parser"
+	levels := make(map[string]string)
+	for _, rule := range undocumentedRules {
+		levels[rule.id] = rule.level
+		if level, ok := config.SarifSeverities[rule.id]; ok {
+			levels[rule.id] = level
+		}
+
+		sarifRule := sarifRule{ID: rule.id, Name: rule.name}
+		sarifRule.ShortDescription.Text = rule.description
+		sarifRule.DefaultConfiguration.Level = levels[rule.id]
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule)
+	}
+
+	sources := make(map[string][]string)
+	add := func(id, symbol, message string, pos token.Position) {
+		if levels[id] == "none" {
+			return
+		}
+		result := sarifResult{RuleID: id, RuleIndex: ruleIndex(id), Level: levels[id]}
+		result.Message.Text = message
+		var location sarifLocation
+		location.PhysicalLocation.ArtifactLocation.URI = artifactURI(pos.Filename)
+		location.PhysicalLocation.Region.StartLine = pos.Line
+		location.PhysicalLocation.Region.StartColumn = pos.Column
+		result.Locations = []sarifLocation{location}
+		if doc, ok := docs[symbol]; ok {
+			result.Fixes = []sarifFix{docCommentFix(doc, pos, location.PhysicalLocation.ArtifactLocation.URI, sources)}
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	symbols, _ := documentationCoverage(report)
+	for _, symbol := range symbols {
+		add(symbol.rule, symbol.name, symbol.message, symbol.pos)
+	}
+
+	// Order results by location so runs can be compared
+	sort.SliceStable(run.Results, func(i, j int) bool {
+		a, b := run.Results[i].Locations[0].PhysicalLocation, run.Results[j].Locations[0].PhysicalLocation
+		if a.ArtifactLocation.URI != b.ArtifactLocation.URI {
+			return a.ArtifactLocation.URI < b.ArtifactLocation.URI
+		}
+		return a.Region.StartLine < b.Region.StartLine
+	})
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// An exported symbol without a doc comment, with the rule reporting it
+type undocumentedSymbol struct {
+	rule    string
+	pkg     string
+	name    string // e.g. "Store" or "Store.Get"
+	message string
+	pos     token.Position
+}
+
+// Function to list the exported interfaces, interface methods and
+// package-level types of a report that have no doc comment, along with how
+// many exported symbols were checked in all
+func documentationCoverage(report *Report) ([]undocumentedSymbol, int) {
+	var symbols []undocumentedSymbol
+	total := 0
+
+	// Interfaces from the scanned files are reported by GODOC001 only
+	reported := make(map[token.Position]bool)
+	for _, iface := range report.Interfaces {
+		reported[iface.pos] = true
+		if !ast.IsExported(iface.InterfaceName) {
+			continue
+		}
+		total++
+		if iface.Doc == "" {
+			symbols = append(symbols, undocumentedSymbol{RuleUndocumentedInterface, iface.Package, iface.InterfaceName,
+				fmt.Sprintf("Exported interface %s has no doc comment", iface.InterfaceName), iface.pos})
+		}
+		for _, method := range iface.Methods {
+			if !ast.IsExported(method.Name) {
+				continue
+			}
+			total++
+			if method.Doc == "" {
+				symbols = append(symbols, undocumentedSymbol{RuleUndocumentedMethod, iface.Package, iface.InterfaceName + "." + method.Name,
+					fmt.Sprintf("Method %s of interface %s has no doc comment", method.Name, iface.InterfaceName), method.pos})
+			}
+		}
+	}
+	for _, decl := range report.types {
+		if !ast.IsExported(decl.Name) || reported[decl.pos] {
+			continue
+		}
+		reported[decl.pos] = true
+		total++
+		if decl.Doc == "" {
+			symbols = append(symbols, undocumentedSymbol{RuleUndocumentedType, decl.Package, decl.Name,
+				fmt.Sprintf("Exported type %s has no doc comment", decl.Name), decl.pos})
+		}
+	}
+	return symbols, total
+}
+
+// Function to build a fix inserting a doc comment above the declaration at
+// pos, indented like the declaration's line. sources caches file lines.
+func docCommentFix(doc string, pos token.Position, uri string, sources map[string][]string) sarifFix {
+	lines, ok := sources[pos.Filename]
+	if !ok {
+		if data, err := os.ReadFile(pos.Filename); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+		sources[pos.Filename] = lines
+	}
+	indent := ""
+	if pos.Line-1 < len(lines) {
+		line := lines[pos.Line-1]
+		indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	}
+
+	var text strings.Builder
+	for _, line := range strings.Split(doc, "\n") {
+		text.WriteString(strings.TrimRight(indent+"// "+line, " "))
+		text.WriteString("\n")
+	}
+
+	var replacement sarifReplacement
+	replacement.DeletedRegion.StartLine = pos.Line
+	replacement.DeletedRegion.StartColumn = 1
+	replacement.DeletedRegion.EndLine = pos.Line
+	replacement.DeletedRegion.EndColumn = 1
+	replacement.InsertedContent.Text = text.String()
+
+	var change sarifArtifactChange
+	change.ArtifactLocation.URI = uri
+	change.Replacements = []sarifReplacement{replacement}
+
+	var fix sarifFix
+	fix.Description.Text = "Add the generated doc comment"
+	fix.ArtifactChanges = []sarifArtifactChange{change}
+	return fix
+}
+
+// Function to collect the doc comments of the Go code blocks in generated
+// documentation, keyed by symbol: "Type" for types and "Type.Method" for
+// interface methods and methods. Blocks that don't parse are skipped.
+func extractDocComments(markdown string) map[string]string {
+	docs := make(map[string]string)
+	for _, block := range goCodeBlocks(markdown) {
+		if !strings.HasPrefix(strings.TrimSpace(block), "package ") {
+			block = "package p\n\n" + block
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), "", block, parser.ParseComments)
+		if err != nil {
+			continue
+		}
+
+		for typeSpec, doc := range typeDocs(file) {
+			docs[typeSpec.Name.Name] = doc
+			if interfaceType, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				for _, method := range interfaceType.Methods.List {
+					if doc := commentText(method.Doc); doc != "" && len(method.Names) > 0 {
+						docs[typeSpec.Name.Name+"."+method.Names[0].Name] = doc
+					}
+				}
+			}
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && len(fn.Recv.List) > 0 {
+				if recv, _ := receiverTypeName(fn.Recv.List[0].Type); recv != "" {
+					if doc := commentText(fn.Doc); doc != "" {
+						docs[recv+"."+fn.Name.Name] = doc
+					}
+				}
+			}
+		}
+	}
+	return docs
+}
+
+// Function to find the contents of the ```go fenced blocks of a markdown text
+func goCodeBlocks(markdown string) []string {
+	var blocks []string
+	var current []string
+	inBlock := false
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !inBlock && (trimmed == "```go" || trimmed == "```golang"):
+			inBlock = true
+			current = nil
+		case inBlock && trimmed == "```":
+			inBlock = false
+			blocks = append(blocks, strings.Join(current, "\n"))
+		case inBlock:
+			current = append(current, line)
+		}
+	}
+	return blocks
+}
+
+// Function to turn a file path into a URI relative to the working directory,
+// which is what code scanning resolves against the repository root
+func artifactURI(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if abs, err := filepath.Abs(path); err == nil {
+			if rel, err := filepath.Rel(wd, abs); err == nil && !filepath.IsAbs(rel) {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(path)
+}
+
+// Function to write the SARIF log for the analysis results. When docsPath
+// names documentation written by send, its doc comments become fixes.
+func writeSarif(w io.Writer, report *Report, config *Config, docsPath string) error {
+	var docs map[string]string
+	if docsPath != "" {
+		data, err := os.ReadFile(docsPath)
+		if err != nil {
+			return err
+		}
+		docs = extractDocComments(string(data))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(buildSarif(report, config, docs))
+}
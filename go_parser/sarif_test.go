@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSarif(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "svc", "svc.go")
+	writeFile(t, ifacePath, `package svc
+
+// Store persists items.
+type Store interface {
+	// Get returns an item.
+	Get(id string) (string, error)
+	Put(id, v string) error
+}
+
+type Closer interface {
+	Close() error
+}
+
+type (
+	// Options configures a store.
+	Options struct{}
+
+	Config struct{}
+)
+
+type internal struct{}
+`)
+	writeFile(t, filepath.Join(root, "svc", "mem.go"), `package svc
+
+type MemStore struct{}
+
+func (m *MemStore) Get(id string) (string, error) { return "", nil }
+func (m *MemStore) Put(id, v string) error       { return nil }
+`)
+
+	config := &Config{SarifSeverities: map[string]string{RuleUndocumentedMethod: "error"}}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, types, _ := findImplementations(context.Background(), []string{filepath.Join(root, "svc")}, interfaces, config)
+	report := &Report{ReportResults: ReportResults{Interfaces: results}, types: types}
+
+	docs := extractDocComments("Docs:\n\n```go\n// Closer releases resources.\ntype Closer interface {\n\t// Close closes it.\n\tClose() error\n}\n```\n")
+	sarif := buildSarif(report, config, docs)
+
+	type finding struct{ rule, level, message string }
+	var got []finding
+	for _, result := range sarif.Runs[0].Results {
+		got = append(got, finding{result.RuleID, result.Level, result.Message.Text})
+	}
+	want := []finding{
+		{RuleUndocumentedType, "warning", "Exported type MemStore has no doc comment"},
+		{RuleUndocumentedMethod, "error", "Method Put of interface Store has no doc comment"},
+		{RuleUndocumentedInterface, "warning", "Exported interface Closer has no doc comment"},
+		{RuleUndocumentedMethod, "error", "Method Close of interface Closer has no doc comment"},
+		{RuleUndocumentedType, "warning", "Exported type Config has no doc comment"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("results = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("result %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Generated comments become fixes inserted above the declaration
+	found := sarif.Runs[0].Results
+	closer, closeMethod := found[2], found[3]
+	if len(closer.Fixes) != 1 || len(closeMethod.Fixes) != 1 {
+		t.Fatalf("fixes = %+v / %+v, want one each", closer.Fixes, closeMethod.Fixes)
+	}
+	replacement := closeMethod.Fixes[0].ArtifactChanges[0].Replacements[0]
+	if replacement.InsertedContent.Text != "\t// Close closes it.\n" || replacement.DeletedRegion.StartLine != 11 || replacement.DeletedRegion.EndColumn != 1 {
+		t.Errorf("method fix = %+v", replacement)
+	}
+	if text := closer.Fixes[0].ArtifactChanges[0].Replacements[0].InsertedContent.Text; text != "// Closer releases resources.\n" {
+		t.Errorf("interface fix inserts %q", text)
+	}
+	if len(found[0].Fixes) != 0 {
+		t.Errorf("MemStore has no generated comment but got fixes %+v", found[0].Fixes)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Version of the JSON written by analyze, given as schema_version in the
+// output. Bump it whenever a field of Report, or of a type it holds, is
+// added, removed, renamed or changes type, and publish the new schema to
+// schemas/ with "go run . schema"; TestSchemaPublished fails until then.
+// Published schemas are never edited.
+const schemaVersion = 13
+
+// First version with the results nested under results, after the metadata
+const resultsSchemaVersion = 13
+
+// Version asking for the legacy output: a bare array of interfaces, as
+// written before schema_version existed
+const legacySchemaVersion = 1
+
+// Function to check a -schema-version flag
+func checkSchemaVersion(version int) error {
+	switch version {
+	case legacySchemaVersion, schemaVersion:
+		return nil
+	}
+	return fmt.Errorf("unknown schema version %d (use %d or %d)", version, legacySchemaVersion, schemaVersion)
+}
+
+// Function to write a report as JSON in the given schema version. The
+// legacy version has the interfaces alone, in the shape of the current
+// version.
+func writeReportJSON(w io.Writer, report *Report, version int) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if version == legacySchemaVersion {
+		interfaces := report.Interfaces
+		if interfaces == nil {
+			interfaces = []InterfaceDetails{}
+		}
+		return encoder.Encode(interfaces)
+	}
+	return encoder.Encode(report)
+}
+
+// Function to generate the JSON Schema of the output in the given schema
+// version from the Go structs, with a definition per struct type
+func reportSchema(version int) map[string]interface{} {
+	defs := make(map[string]interface{})
+	schema := map[string]interface{}{"$schema": "https://json-schema.org/draft/2020-12/schema"}
+	if version == legacySchemaVersion {
+		schema["title"] = "Go_Documentator analysis, legacy array"
+		schema["type"] = "array"
+		schema["items"] = typeSchema(reflect.TypeOf(InterfaceDetails{}), defs)
+	} else {
+		schema["title"] = fmt.Sprintf("Go_Documentator analysis, schema version %d", schemaVersion)
+		schema["$ref"] = typeSchema(reflect.TypeOf(Report{}), defs)["$ref"]
+		// Pin the version, so a consumer can tell which schema a file follows
+		report := defs["Report"].(map[string]interface{})
+		report["properties"].(map[string]interface{})["schema_version"] = map[string]interface{}{"const": schemaVersion}
+	}
+	schema["$defs"] = defs
+	return schema
+}
+
+// Function to describe a Go type as JSON Schema, adding the structs it
+// refers to to defs
+func typeSchema(t reflect.Type, defs map[string]interface{}) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float64, reflect.Float32:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice:
+		// A nil slice is written as null
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		ref := map[string]interface{}{"$ref": "#/$defs/" + t.Name()}
+		if _, ok := defs[t.Name()]; ok {
+			return ref
+		}
+		// Registered before the fields, for types that refer to themselves
+		def := map[string]interface{}{"type": "object", "additionalProperties": false}
+		defs[t.Name()] = def
+		properties := make(map[string]interface{})
+		required := []string{}
+		addStructFields(t, defs, properties, &required)
+		def["properties"] = properties
+		def["required"] = required
+		return ref
+	}
+	panic(fmt.Sprintf("no JSON Schema for %s", t))
+}
+
+// Function to add the JSON fields of a struct, including those of embedded
+// structs, which encoding/json flattens
+func addStructFields(t reflect.Type, defs, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if field.Anonymous && tag == "" {
+			addStructFields(field.Type, defs, properties, required)
+			continue
+		}
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = typeSchema(field.Type, defs)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+func runSchema(args []string) {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	version := fs.Int("schema-version", schemaVersion, fmt.Sprintf("schema version to print, %d for the legacy array", legacySchemaVersion))
+	parseFlags(fs, args)
+	if err := checkSchemaVersion(*version); err != nil {
+		fatalf(exitUsage, "Error in flags: %v", err)
+	}
+
+	data, err := json.MarshalIndent(reportSchema(*version), "", "  ")
+	if err != nil {
+		fatalf(exitUsage, "Error generating the schema: %v", err)
+	}
+	os.Stdout.Write(append(data, '\n'))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The compatibility policy: the schema generated from the structs must be
+// the one published for schemaVersion. When this fails, a field of the
+// output changed; bump schemaVersion and publish the new schema rather than
+// editing the published one, which consumers rely on.
+func TestSchemaPublished(t *testing.T) {
+	path := filepath.Join("schemas", fmt.Sprintf("report.v%d.json", schemaVersion))
+	published, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("schema version %d is not published: %v; run go run . schema > %s", schemaVersion, err, path)
+	}
+	generated, err := json.MarshalIndent(reportSchema(schemaVersion), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(published) != string(generated)+"\n" {
+		t.Errorf("the JSON output no longer matches %s: bump schemaVersion and publish schemas/report.v%d.json with go run . schema", path, schemaVersion+1)
+	}
+}
+
+// Function to check a decoded JSON value against a schema generated by
+// reportSchema, reporting every mismatch
+func checkSchema(t *testing.T, path string, value interface{}, node, defs map[string]interface{}) {
+	t.Helper()
+	if ref, ok := node["$ref"].(string); ok {
+		node = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+	if want, ok := node["const"]; ok {
+		if fmt.Sprint(value) != fmt.Sprint(want) {
+			t.Errorf("%s = %v, want %v", path, value, want)
+		}
+		return
+	}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		properties, _ := node["properties"].(map[string]interface{})
+		if additional, ok := node["additionalProperties"].(map[string]interface{}); ok {
+			for key, item := range value {
+				checkSchema(t, path+"."+key, item, additional, defs)
+			}
+			return
+		}
+		for key, item := range value {
+			property, ok := properties[key].(map[string]interface{})
+			if !ok {
+				t.Errorf("%s.%s is not in the schema", path, key)
+				continue
+			}
+			checkSchema(t, path+"."+key, item, property, defs)
+		}
+		for _, key := range node["required"].([]interface{}) {
+			if _, ok := value[key.(string)]; !ok {
+				t.Errorf("%s.%s is required but missing", path, key)
+			}
+		}
+	case []interface{}:
+		for i, item := range value {
+			checkSchema(t, fmt.Sprintf("%s[%d]", path, i), item, node["items"].(map[string]interface{}), defs)
+		}
+	case string, bool, float64, nil:
+		// Scalars are checked through the keys that hold them
+	}
+}
+
+func TestReportMatchesSchema(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "store.go")
+	writeFile(t, ifacePath, `package app
+
+// Store persists items.
+type Store interface {
+	Get(id string) (string, error)
+	Put(id, value string) error
+}
+
+// DefaultLimit caps the items listed.
+const DefaultLimit = 100
+
+func Use(s Store) {}
+`)
+	writeFile(t, filepath.Join(root, "mem.go"), `package app
+
+type MemStore struct {
+	Items map[string]string `+"`json:\"items\"`"+`
+}
+
+func (m *MemStore) Get(id string) (string, error) { return "", nil }
+func (m *MemStore) Put(id, value string) error    { return nil }
+
+type BadStore struct{}
+
+func (BadStore) Get() (string, error)       { return "", nil }
+func (BadStore) Put(id, value string) error { return nil }
+`)
+	report, err := Analyze(Config{GoFilePath: ifacePath, GoDirectory: root})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+
+	if report.GeneratedAt.IsZero() || report.ToolVersion == "" ||
+		!reflect.DeepEqual(report.Paths, ReportPaths{InterfaceSources: []string{ifacePath}, Roots: []string{root}}) {
+		t.Errorf("metadata = %v %q %+v, want when, by what and from where the report was generated", report.GeneratedAt, report.ToolVersion, report.Paths)
+	}
+
+	for _, version := range []int{schemaVersion, legacySchemaVersion} {
+		var buf bytes.Buffer
+		if err := writeReportJSON(&buf, report, version); err != nil {
+			t.Fatal(err)
+		}
+		var value interface{}
+		if err := json.Unmarshal(buf.Bytes(), &value); err != nil {
+			t.Fatal(err)
+		}
+		// Round-trip the schema through JSON too, as a consumer reads it
+		data, err := json.Marshal(reportSchema(version))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatal(err)
+		}
+		if version == legacySchemaVersion {
+			if _, ok := value.([]interface{}); !ok {
+				t.Fatalf("legacy output is %T, want an array", value)
+			}
+		}
+		checkSchema(t, fmt.Sprintf("v%d", version), value, schema, schema["$defs"].(map[string]interface{}))
+	}
+}
+
+func TestCheckSchemaVersion(t *testing.T) {
+	for _, version := range []int{legacySchemaVersion, schemaVersion} {
+		if err := checkSchemaVersion(version); err != nil {
+			t.Errorf("version %d: %v", version, err)
+		}
+	}
+	if err := checkSchemaVersion(schemaVersion + 1); err == nil {
+		t.Error("a future version should be rejected")
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Exit code of a run stopped by SIGINT or SIGTERM, the one shells use for
+// SIGINT, so scripts can tell an interrupted run from a failed one
+const exitInterrupted = 130
+
+// Put at the top of documentation written by an interrupted run
+
+// Function to get a context that is cancelled on the first SIGINT or
+// SIGTERM, so the command can flush what it has gathered. A second signal
+// exits immediately. Call stop to restore the default signal behaviour.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		fmt.Fprintln(os.Stderr, "Interrupted, writing partial results (interrupt again to exit immediately)")
+		cancel()
+
+		select {
+		case <-signals:
+			fmt.Fprintln(os.Stderr, "Interrupted again, exiting")
+			os.Exit(exitInterrupted)
+		case <-done:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(done)
+		cancel()
+	}
+}
+
+// Function to get the note put at the top of the documentation of an
+// interrupted run, or "" for a complete one
+func partialMarker(partial bool, msgs messages) string {
+	if partial {
+		return "> " + msgs.text("partial_results") + "\n\n"
+	}
+	return ""
+}
+
+// Function to exit with exitInterrupted if ctx, from interruptContext, was
+// cancelled by a signal. Commands call it once their partial results are
+// written, before the stop function of the context runs.
+func exitIfInterrupted(ctx context.Context) {
+	if ctx.Err() != nil {
+		os.Exit(exitInterrupted)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInterruptedScanReturnsPartialResults(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "store.go"), `package app
+
+type Store interface {
+	Get(id string) (string, error)
+}
+
+type MemStore struct{}
+
+func (m *MemStore) Get(id string) (string, error) { return "", nil }
+`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	config := &Config{GoFilePath: filepath.Join(root, "store.go"), GoDirectory: root, GenerateStubs: filepath.Join(root, "stubs")}
+	report := analyze(ctx, config)
+	if len(report.Interfaces) != 1 || len(report.Interfaces[0].Implementations) != 0 {
+		t.Errorf("interfaces = %+v, want Store without the implementations the scan didn't reach", report.Interfaces)
+	}
+	if _, err := os.Stat(config.GenerateStubs); !os.IsNotExist(err) {
+		t.Errorf("stubs were generated for an interrupted scan")
+	}
+	if !report.Partial {
+		t.Error("report of an interrupted scan not marked partial")
+	}
+
+	// The markdown of a partial run says so
+	sink := &FileSink{Path: filepath.Join(root, "docs.md")}
+	if err := sink.Send(ctx, report.Interfaces); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(sink.Path); !strings.HasPrefix(string(data), partialMarker(true, nil)) {
+		t.Errorf("docs = %q, want the partial note first", data)
+	}
+	if analyze(context.Background(), config).Partial {
+		t.Error("complete scan marked partial")
+	}
+}
+
+func TestExitIfInterrupted(t *testing.T) {
+	if os.Getenv("GO_PARSER_EXIT_HELPER") != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		exitIfInterrupted(ctx)
+		os.Exit(0)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestExitIfInterrupted$")
+	cmd.Env = append(os.Environ(), "GO_PARSER_EXIT_HELPER=1")
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != exitInterrupted {
+		t.Errorf("exit = %v, want code %d", err, exitInterrupted)
+	}
+
+	// A context that wasn't cancelled doesn't exit
+	exitIfInterrupted(context.Background())
+}
+
+func TestInterruptContextCancelsOnSignal(t *testing.T) {
+	ctx, stop := interruptContext()
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := process.Signal(os.Interrupt); err != nil {
+		t.Skipf("can't signal the test process: %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("context was not cancelled by SIGINT")
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Function to print an expression back as Go source
+func exprString(fset *token.FileSet, expr ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// Function to describe a method by name, printed signature and arity
+func methodDetails(fset *token.FileSet, name string, funcType *ast.FuncType) MethodDetails {
+	return MethodDetails{
+		Name:      name,
+		Signature: name + strings.TrimPrefix(exprString(fset, funcType), "func"),
+		Params:    fieldCount(funcType.Params),
+		Results:   fieldCount(funcType.Results),
+		Types:     "(" + fieldTypes(fset, funcType.Params) + ") (" + fieldTypes(fset, funcType.Results) + ")",
+
+		paramTypes:  fieldTypeList(fset, funcType.Params),
+		resultTypes: fieldTypeList(fset, funcType.Results),
+	}
+}
+
+// Function to list the types of a parameter or result list without their
+// names, so "a, b int" becomes "int, int"
+func fieldTypes(fset *token.FileSet, fields *ast.FieldList) string {
+	return strings.Join(fieldTypeList(fset, fields), ", ")
+}
+
+// Function to list the type of each parameter or result, so "a, b int"
+// gives int twice
+func fieldTypeList(fset *token.FileSet, fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var types []string
+	for _, field := range fields.List {
+		typeString := exprString(fset, field.Type)
+		for i := 0; i < max(len(field.Names), 1); i++ {
+			types = append(types, typeString)
+		}
+	}
+	return types
+}
+
+// Function to describe a method declared in an interface, with its doc
+// comment and position and the type parameters of the interface
+func interfaceMethod(fset *token.FileSet, field *ast.Field, funcType *ast.FuncType, typeParams []string) MethodDetails {
+	method := methodDetails(fset, field.Names[0].Name, funcType)
+	method.Doc = commentText(field.Doc, field.Comment)
+	method.pos = fset.Position(field.Pos())
+	method.typeParams = typeParams
+	return method
+}
+
+// Function to tell whether a type's method takes and returns the types of
+// an interface method. Types are compared without package qualifiers, as
+// the interface and the type are often declared in different packages and
+// name them differently, and a type parameter of either matches any type.
+func sameMethodTypes(ifaceMethod, method MethodDetails) bool {
+	if ifaceMethod.Params != method.Params || ifaceMethod.Results != method.Results {
+		return false
+	}
+	wildcards := make(map[string]bool)
+	for _, name := range append(append([]string{}, ifaceMethod.typeParams...), method.typeParams...) {
+		wildcards[name] = true
+	}
+	return sameTypeLists(ifaceMethod.paramTypes, method.paramTypes, wildcards) &&
+		sameTypeLists(ifaceMethod.resultTypes, method.resultTypes, wildcards)
+}
+
+// Function to compare two lists of types, one by one
+func sameTypeLists(want, got []string, wildcards map[string]bool) bool {
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if !matchTypeTokens(typeTokens(want[i]), typeTokens(got[i]), wildcards) {
+			return false
+		}
+	}
+	return true
+}
+
+// Function to split a type into its tokens, dropping package qualifiers and
+// spelling interface{} as any, so api.Item and Item give the same tokens
+func typeTokens(typeString string) []string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(typeString))
+	var s scanner.Scanner
+	s.Init(file, []byte(typeString), nil, 0)
+
+	var tokens []string
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		text := lit
+		if text == "" {
+			text = tok.String()
+		}
+		tokens = append(tokens, text)
+	}
+
+	var normalized []string
+	for i := 0; i < len(tokens); i++ {
+		switch {
+		case i+2 < len(tokens) && tokens[i+1] == "." && token.IsIdentifier(tokens[i]):
+			// A qualifier, e.g. api in api.Item
+			i++
+		case tokens[i] == "interface" && i+2 < len(tokens) && tokens[i+1] == "{" && tokens[i+2] == "}":
+			normalized = append(normalized, "any")
+			i += 2
+		default:
+			normalized = append(normalized, tokens[i])
+		}
+	}
+	return normalized
+}
+
+// Function to match the tokens of two types, where a wildcard on either
+// side stands for one or more tokens of the other, e.g. T for []string
+func matchTypeTokens(a, b []string, wildcards map[string]bool) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b)
+	}
+	if wildcards[a[0]] || wildcards[b[0]] {
+		if wildcards[b[0]] {
+			a, b = b, a
+		}
+		for n := 1; n <= len(b); n++ {
+			if matchTypeTokens(a[1:], b[n:], wildcards) {
+				return true
+			}
+		}
+		return false
+	}
+	return a[0] == b[0] && matchTypeTokens(a[1:], b[1:], wildcards)
+}
+
+// Function to return the text of the first non-empty comment group
+func commentText(groups ...*ast.CommentGroup) string {
+	for _, group := range groups {
+		if group != nil {
+			return strings.TrimSpace(group.Text())
+		}
+	}
+	return ""
+}
+
+// Function to map each type spec of a file to its doc comment. For an
+// unparenthesized "type X ..." the parser attaches the comment to the
+// declaration rather than the spec.
+func typeDocs(file *ast.File) map[*ast.TypeSpec]string {
+	docs := make(map[*ast.TypeSpec]string)
+	ast.Inspect(file, func(n ast.Node) bool {
+		decl, ok := n.(*ast.GenDecl)
+		if !ok || decl.Tok != token.TYPE {
+			return true
+		}
+		for _, spec := range decl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			doc := typeSpec.Doc
+			if doc == nil && !decl.Lparen.IsValid() {
+				doc = decl.Doc
+			}
+			if text := commentText(doc); text != "" {
+				docs[typeSpec] = text
+			}
+		}
+		return true
+	})
+	return docs
+}
+
+// Function to count the entries of a parameter or result list; "a, b int"
+// counts as two
+func fieldCount(fields *ast.FieldList) int {
+	if fields == nil {
+		return 0
+	}
+	count := 0
+	for _, field := range fields.List {
+		if len(field.Names) == 0 {
+			count++
+		} else {
+			count += len(field.Names)
+		}
+	}
+	return count
+}
+
+// Function to render a type parameter list such as "[K comparable, V any]"
+func typeParamsString(fset *token.FileSet, params *ast.FieldList) string {
+	if params == nil || len(params.List) == 0 {
+		return ""
+	}
+	var parts []string
+	for _, field := range params.List {
+		var names []string
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		parts = append(parts, strings.Join(names, ", ")+" "+exprString(fset, field.Type))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// Predeclared types that make an interface usable only as a constraint
+// when embedded in it
+var constraintOnlyTypes = map[string]bool{
+	"comparable": true, "bool": true, "string": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true, "complex64": true, "complex128": true,
+	"byte": true, "rune": true,
+}
+
+// Function to detect type elements (unions, ~T, predeclared types), which
+// only constraint interfaces may contain
+func hasTypeElements(interfaceType *ast.InterfaceType) bool {
+	for _, field := range interfaceType.Methods.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		switch elem := field.Type.(type) {
+		case *ast.BinaryExpr, *ast.UnaryExpr:
+			return true
+		case *ast.Ident:
+			if constraintOnlyTypes[elem.Name] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Function to get methods sorted by their Order, for output that must
+// follow declaration order however the methods were collected
+func methodsInOrder(methods []MethodDetails) []MethodDetails {
+	sorted := append([]MethodDetails(nil), methods...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Order < sorted[j].Order })
+	return sorted
+}
+
+// Function to collect the exported fields of a struct. Embedded fields are
+// named after their type.
+func structFields(fset *token.FileSet, structType *ast.StructType) []FieldDetails {
+	var fields []FieldDetails
+	for _, field := range structType.Fields.List {
+		typeString := exprString(fset, field.Type)
+
+		names := field.Names
+		if len(names) == 0 {
+			if name, _ := receiverTypeName(field.Type); name != "" {
+				names = []*ast.Ident{ast.NewIdent(name)}
+			} else if selector, ok := field.Type.(*ast.SelectorExpr); ok {
+				names = []*ast.Ident{selector.Sel}
+			}
+		}
+
+		for _, name := range names {
+			if !ast.IsExported(name.Name) {
+				continue
+			}
+			details := FieldDetails{Name: name.Name, Type: typeString}
+			if field.Tag != nil {
+				details.Tag = field.Tag.Value
+			}
+			details.Doc = commentText(field.Doc, field.Comment)
+			fields = append(fields, details)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sink names accepted in the sinks config list
+const (
+	SinkLLM       = "llm"
+	SinkOpenAI    = "openai"
+	SinkAnthropic = "anthropic"
+	SinkFile      = "file"
+	SinkNop       = "nop"
+)
+
+// ResultSink receives the results of a scan. The send command hands the
+// results to every configured sink; tests can substitute an in-memory one.
+type ResultSink interface {
+	Send(ctx context.Context, results []InterfaceDetails) error
+}
+
+// LLMSink sends the results to a language model and writes the generated
+// documentation to Output, or stdout when Output is empty
+type LLMSink struct {
+	Config  *Config
+	Tracker *usageTracker
+	Output  string
+}
+
+// Function to send the results to the LLM and write the post-processed
+// documentation. When a request fails, the replies received before it are
+// still written; when post-processing fails, the unprocessed documentation is.
+func (s *LLMSink) Send(ctx context.Context, results []InterfaceDetails) error {
+	content, err := sendData(ctx, s.Config, results, s.Tracker)
+	switch {
+	case err != nil && content == "":
+		return err
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "Writing the documentation received before the failure")
+	default:
+		fmt.Fprintln(os.Stderr, "Data sent successfully!")
+	}
+	content, perr := postProcess(ctx, s.Config, partialMarker(ctx.Err() != nil, docMessages(s.Config))+content)
+	return errors.Join(err, perr, writeContent(s.Output, content))
+}
+
+// FileSink writes the results to Path, as JSON when the extension is .json
+// and as markdown otherwise
+type FileSink struct {
+	Path string
+	// Post-processing of the markdown, see postProcess; nil skips it
+	Config *Config
+}
+
+// Function to write the results to the sink's file
+func (s *FileSink) Send(ctx context.Context, results []InterfaceDetails) error {
+	var data []byte
+	if strings.EqualFold(filepath.Ext(s.Path), ".json") {
+		var err error
+		if data, err = json.MarshalIndent(results, "", "  "); err != nil {
+			return err
+		}
+	} else {
+		msgs := docMessages(s.Config)
+		markdown, err := postProcess(ctx, s.Config, partialMarker(ctx.Err() != nil, msgs)+renderMarkdown(results, msgs))
+		if werr := os.WriteFile(s.Path, []byte(markdown), 0o644); werr != nil {
+			return errors.Join(err, withExitCode(exitOutput, werr))
+		}
+		return err
+	}
+	return withExitCode(exitOutput, os.WriteFile(s.Path, data, 0o644))
+}
+
+// MultiSink sends the results to every sink in turn, even when an earlier
+// one fails, and returns all their errors joined
+type MultiSink []ResultSink
+
+// Function to fan the results out to every sink
+func (m MultiSink) Send(ctx context.Context, results []InterfaceDetails) error {
+	var errs []error
+	for _, sink := range m {
+		if err := sink.Send(ctx, results); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NopSink discards the results
+type NopSink struct{}
+
+// Function to do nothing with the results
+func (NopSink) Send(ctx context.Context, results []InterfaceDetails) error { return nil }
+
+// Function to build the sinks listed in the config; an empty list sends to
+// the configured LLM provider. openai and anthropic override the provider.
+func newSinks(config *Config, tracker *usageTracker, output string) (ResultSink, error) {
+	names := config.Sinks
+	if len(names) == 0 {
+		names = []string{SinkLLM}
+	}
+
+	var sinks MultiSink
+	for _, name := range names {
+		switch name {
+		case SinkLLM:
+			sinks = append(sinks, &LLMSink{Config: config, Tracker: tracker, Output: output})
+		case SinkOpenAI, SinkAnthropic:
+			providerConfig := *config
+			providerConfig.Provider = name
+			sinks = append(sinks, &LLMSink{Config: &providerConfig, Tracker: tracker, Output: output})
+		case SinkFile:
+			sinks = append(sinks, &FileSink{Path: config.SinkFile, Config: config})
+		case SinkNop:
+			sinks = append(sinks, NopSink{})
+		default:
+			return nil, fmt.Errorf("unknown sink %q (use llm, openai, anthropic, file or nop)", name)
+		}
+	}
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return sinks, nil
+}
+
+// Function to report whether any configured sink calls an LLM provider
+func (c *Config) usesLLM() bool {
+	if len(c.Sinks) == 0 {
+		return true
+	}
+	for _, name := range c.Sinks {
+		switch name {
+		case SinkLLM, SinkOpenAI, SinkAnthropic:
+			return true
+		}
+	}
+	return false
+}
+
+// Function to run the scan and hand the results to sink. Nothing is sent
+// when -since is set and no interface changed.
+func sendResults(ctx context.Context, config *Config, sink ResultSink) (*Report, error) {
+	report := analyze(ctx, config)
+	if config.Since != "" && len(report.Interfaces) == 0 {
+		fmt.Fprintf(os.Stderr, "No interfaces changed since %s, nothing to send\n", config.Since)
+		return report, nil
+	}
+	return report, sink.Send(ctx, collapseTestDoubles(report.Interfaces, config))
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// memorySink records what would have been sent
+type memorySink struct {
+	sent [][]InterfaceDetails
+	err  error
+}
+
+func (m *memorySink) Send(ctx context.Context, results []InterfaceDetails) error {
+	m.sent = append(m.sent, results)
+	return m.err
+}
+
+func TestSendResultsPipeline(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(root, "store.go"), `package app
+
+type Store interface {
+	Get(id string) (string, error)
+}
+
+type MemStore struct{}
+
+func (m *MemStore) Get(id string) (string, error) { return "", nil }
+`)
+
+	config := &Config{GoFilePath: filepath.Join(root, "store.go"), GoDirectory: root}
+	sink := &memorySink{}
+	if _, err := sendResults(context.Background(), config, sink); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sink.sent) != 1 || len(sink.sent[0]) != 1 {
+		t.Fatalf("sent = %+v, want one batch with one interface", sink.sent)
+	}
+	store := sink.sent[0][0]
+	if store.InterfaceName != "Store" || len(store.Implementations) != 1 || store.Implementations[0].TypeName != "MemStore" {
+		t.Errorf("sent %+v, want Store implemented by MemStore", store)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	dir := t.TempDir()
+	results := testResults()
+
+	jsonPath := filepath.Join(dir, "docs.json")
+	if err := (&FileSink{Path: jsonPath}).Send(context.Background(), results); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []InterfaceDetails
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded) != len(results) {
+		t.Errorf("JSON sink wrote %s (%v)", data, err)
+	}
+
+	mdPath := filepath.Join(dir, "docs.md")
+	if err := (&FileSink{Path: mdPath}).Send(context.Background(), results); err != nil {
+		t.Fatal(err)
+	}
+	data, err = os.ReadFile(mdPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "# Interfaces") {
+		t.Errorf("markdown sink wrote %q", data)
+	}
+}
+
+func TestMultiSinkSendsToAll(t *testing.T) {
+	failing := &memorySink{err: errors.New("boom")}
+	ok := &memorySink{}
+
+	err := MultiSink{failing, NopSink{}, ok}.Send(context.Background(), testResults())
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("err = %v, want the failing sink's error", err)
+	}
+	if len(ok.sent) != 1 {
+		t.Errorf("sink after the failure got %d batches, want 1", len(ok.sent))
+	}
+}
+
+func TestNewSinks(t *testing.T) {
+	config := &Config{Sinks: []string{SinkFile, SinkNop}, SinkFile: "docs.md"}
+	if config.usesLLM() {
+		t.Error("file and nop sinks should not need an API key")
+	}
+	sink, err := newSinks(config, newUsageTracker(config), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if multi, ok := sink.(MultiSink); !ok || len(multi) != 2 {
+		t.Errorf("sink = %#v, want a MultiSink of two", sink)
+	}
+
+	if err := (&Config{Sinks: []string{SinkFile}}).validate(); err == nil {
+		t.Error("file sink without sink_file should be rejected")
+	}
+	if err := (&Config{Sinks: []string{"slack"}}).validate(); err == nil {
+		t.Error("unknown sink should be rejected")
+	}
+}
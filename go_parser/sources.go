@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RootSummary counts what one interface source or go_directories root
+// contributed to a run
+type RootSummary struct {
+	Path string `json:"path"`
+	// Interfaces declared in an interface source
+	Interfaces int `json:"interfaces,omitempty"`
+	// Implementations declared under a root
+	Implementations int `json:"implementations,omitempty"`
+}
+
+// Function to list the interface sources: go_file_path followed by
+// interface_sources. Entries may be files, package directories or globs
+// matching either.
+func (c *Config) interfaceSources() []string {
+	return expandPatterns(append([]string{c.GoFilePath}, c.InterfaceSources...), "interface_sources", false)
+}
+
+// Function to list the Go files interfaces are read from: each source file,
+// and the selected Go files of each source directory. A file listed twice,
+// e.g. by itself and through its directory, is read once.
+func interfaceFiles(sources []string, config *Config) []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			files = append(files, path)
+		}
+	}
+	for _, source := range sources {
+		if info, err := os.Stat(source); err == nil && info.IsDir() {
+			for _, path := range packageGoFiles(source, config) {
+				add(path)
+			}
+			continue
+		}
+		add(source)
+	}
+	return files
+}
+
+// Function to find the interfaces of every interface source. They are merged
+// by package and name, so interfaces of the same name in other packages are
+// all kept. A name declared twice in a package is a conflict, and the first
+// declaration is kept. A declaration repeated in the same package with the
+// same method set isn't a conflict.
+func findAllInterfaces(ctx context.Context, sources []string, config *Config) (map[interfaceRef]*InterfaceDetails, []InterfaceConflict, error) {
+	interfaces := make(map[interfaceRef]*InterfaceDetails)
+	var conflicts []InterfaceConflict
+	files := interfaceFiles(sources, config)
+	declared := declaredInterfaces(ctx, files, config)
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		found, fileConflicts, err := findInterfacesInSource(file, src, config, declared)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, conflict := range fileConflicts {
+			for _, position := range conflict.Positions[1:] {
+				conflicts = addConflict(conflicts, conflict.InterfaceName, conflict.Positions[0], position, conflict.Differences)
+			}
+		}
+		for _, ref := range sortedInterfaceRefs(found) {
+			iface := found[ref]
+			existing, ok := interfaces[ref]
+			if !ok {
+				interfaces[ref] = iface
+				continue
+			}
+			conflicts = addDuplicate(conflicts, existing, iface)
+		}
+	}
+	return interfaces, conflicts, nil
+}
+
+// Function to break the counts of a run down by interface source and root.
+// Returns nothing for the usual run with one of each.
+func summarizeRoots(results []InterfaceDetails, sources, roots []string) []RootSummary {
+	if len(sources) <= 1 && len(roots) <= 1 {
+		return nil
+	}
+	var summaries []RootSummary
+	index := make(map[string]int)
+	entry := func(path string) *RootSummary {
+		if i, ok := index[path]; ok {
+			return &summaries[i]
+		}
+		index[path] = len(summaries)
+		summaries = append(summaries, RootSummary{Path: filepath.ToSlash(path)})
+		return &summaries[len(summaries)-1]
+	}
+	for _, path := range append(append([]string{}, sources...), roots...) {
+		entry(path)
+	}
+
+	for _, result := range results {
+		for _, source := range sources {
+			if declaredIn(result.pos.Filename, source, false) {
+				entry(source).Interfaces++
+				break
+			}
+		}
+		for _, impl := range result.Implementations {
+			for _, root := range roots {
+				if declaredIn(impl.pos.Filename, root, true) {
+					entry(root).Implementations++
+					break
+				}
+			}
+		}
+	}
+	return summaries
+}
+
+// Function to tell whether a file is path, is directly inside the directory
+// path or, with nested, anywhere below it
+func declaredIn(filename, path string, nested bool) bool {
+	file, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	if file == dir || filepath.Dir(file) == dir {
+		return true
+	}
+	rel, err := filepath.Rel(dir, file)
+	return nested && err == nil && !strings.HasPrefix(rel, "..")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestMultipleInterfaceSources(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "pkg", "ports", "store.go"), "package ports\n\ntype Store interface{ Get() string }\n")
+	writeFile(t, filepath.Join(root, "pkg", "ports", "clock.go"), "package ports\n\ntype Clock interface{ Now() int }\n")
+	writeFile(t, filepath.Join(root, "internal", "contracts", "contracts.go"), "package contracts\n\ntype Mailer interface{ Send() }\n\ntype Store interface{ Put() }\n")
+	writeFile(t, filepath.Join(root, "internal", "adapters", "mem.go"), "package adapters\n\ntype MemStore struct{}\n\nfunc (MemStore) Get() string { return \"\" }\n\ntype SMTP struct{}\n\nfunc (SMTP) Send() {}\n")
+	writeFile(t, filepath.Join(root, "services", "clock.go"), "package services\n\ntype Wall struct{}\n\nfunc (Wall) Now() int { return 0 }\n")
+
+	// store.go is listed by itself and through its directory
+	config := &Config{
+		GoFilePath:       filepath.Join(root, "pkg", "ports", "store.go"),
+		InterfaceSources: []string{filepath.Join(root, "pkg", "ports"), filepath.Join(root, "internal", "contr*")},
+		GoDirectories:    []string{filepath.Join(root, "internal", "adapters"), filepath.Join(root, "services")},
+	}
+	sources := config.interfaceSources()
+	interfaces, conflicts, err := findAllInterfaces(context.Background(), sources, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(sortedInterfaceNames(interfaces), " "); got != "Clock Mailer Store Store" {
+		t.Errorf("interfaces = %s", got)
+	}
+	// Both Stores are kept, as they are declared in different packages
+	var stores []string
+	for _, iface := range interfaces {
+		if iface.InterfaceName == "Store" {
+			stores = append(stores, iface.Package)
+		}
+	}
+	sort.Strings(stores)
+	if strings.Join(stores, " ") != "contracts ports" || len(conflicts) != 0 {
+		t.Errorf("Stores of %v, conflicts = %+v", stores, conflicts)
+	}
+
+	roots := config.scanRoots()
+	results, _, _ := findImplementations(context.Background(), roots, interfaces, config)
+	summaries := summarizeRoots(results, sources, roots)
+	var got []string
+	for _, summary := range summaries {
+		rel, _ := filepath.Rel(root, filepath.FromSlash(summary.Path))
+		got = append(got, fmt.Sprintf("%s %d/%d", filepath.ToSlash(rel), summary.Interfaces, summary.Implementations))
+	}
+	want := "pkg/ports/store.go 1/0, pkg/ports 1/0, internal/contracts 2/0, internal/adapters 0/2, services 0/1"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("roots = %s, want %s", strings.Join(got, ", "), want)
+	}
+
+	var buf bytes.Buffer
+	printSummary(&buf, Summary{Roots: summaries[4:]})
+	if !strings.Contains(buf.String(), "services: 0 interfaces, 1 implementations") {
+		t.Errorf("summary = %q", buf.String())
+	}
+
+	if summarizeRoots(results, sources[:1], roots[:1]) != nil {
+		t.Error("a breakdown for a single source and root")
+	}
+}
+
+func TestDuplicateInterfaces(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "store")
+	writeFile(t, filepath.Join(dir, "store_tagged.go"), "package store\n\ntype Store interface {\n\tGet(id string) string\n\tClose() error\n}\n\ntype Clock interface{ Now() int }\n")
+	writeFile(t, filepath.Join(dir, "store_other.go"), "package store\n\ntype Store interface {\n\tGet(id string) string\n\tClose()\n\tFlush()\n}\n\ntype Clock interface{ Now() int }\n")
+
+	// Another package's Store is a different interface, whatever its methods
+	other := filepath.Join(root, "cache")
+	writeFile(t, filepath.Join(other, "cache.go"), "package cache\n\ntype Store interface{ Put(id string) }\n")
+
+	config := &Config{GoFilePath: filepath.Join(dir, "store_tagged.go"), InterfaceSources: []string{dir, other}, GoDirectory: root}
+	interfaces, conflicts, err := findAllInterfaces(context.Background(), config.interfaceSources(), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The identical Clock is dropped quietly, the drifting Store is a conflict
+	if len(conflicts) != 1 || conflicts[0].InterfaceName != "Store" {
+		t.Fatalf("conflicts = %+v, want Store only", conflicts)
+	}
+	want := []string{filepath.ToSlash(filepath.Join(dir, "store_tagged.go")) + ":3", filepath.ToSlash(filepath.Join(dir, "store_other.go")) + ":3"}
+	if strings.Join(conflicts[0].Positions, " ") != strings.Join(want, " ") {
+		t.Errorf("positions = %v, want %v", conflicts[0].Positions, want)
+	}
+	if got := strings.Join(conflicts[0].Differences, ", "); got != "Close(), Close() error, Flush()" {
+		t.Errorf("differences = %s", got)
+	}
+	if got := strings.Join(sortedInterfaceNames(interfaces), " "); got != "Clock Store Store" {
+		t.Errorf("interfaces = %s, want Clock and the Stores of both packages", got)
+	}
+	for _, iface := range interfaces {
+		if iface.InterfaceName == "Store" && (iface.Package == "store") != (len(iface.Methods) == 2) {
+			t.Errorf("Store of %s has %d methods, want the first of store and the one of cache", iface.Package, len(iface.Methods))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Function to analyze a single Go file read from r, for editor integration:
+// the interfaces it declares, matched against the implementations in dir
+// when dir is set, are written to w as JSON. filename is used for positions
+// and need not exist, and version is the schema version of the JSON. No
+// config file is read. Errors are marked with the exit code of the run:
+// exitParse when the source doesn't parse, exitOutput when the JSON can't
+// be written and exitUsage otherwise.
+func analyzeStdin(ctx context.Context, r io.Reader, w io.Writer, filename, dir string, version int) error {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return withExitCode(exitUsage, fmt.Errorf("reading standard input: %w", err))
+	}
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return withExitCode(exitUsage, fmt.Errorf("checking -dir: %s is not a directory", dir))
+		}
+	}
+
+	config := &Config{GoFilePath: filename, GoDirectory: dir}
+	declared := declaredInterfaces(ctx, []string{filename}, config)
+	interfaces, conflicts, err := findInterfacesInSource(filename, src, config, declared)
+	if err != nil {
+		return withExitCode(exitParse, fmt.Errorf("parsing Go source: %w", err))
+	}
+
+	var results []InterfaceDetails
+	if dir != "" {
+		results, _, _ = findImplementations(ctx, []string{dir}, interfaces, config)
+	} else {
+		for _, ref := range sortedInterfaceRefs(interfaces) {
+			results = append(results, *interfaces[ref])
+		}
+	}
+
+	paths := ReportPaths{InterfaceSources: []string{filename}}
+	if dir != "" {
+		paths.Roots = []string{dir}
+	}
+	report := &Report{
+		SchemaVersion: schemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		ToolVersion:   toolVersion(),
+		Paths:         paths,
+		ReportResults: ReportResults{
+			Interfaces:   results,
+			Summary:      summarize(results, 0),
+			Conflicts:    conflicts,
+			Undocumented: undocumentedMethods(results),
+		},
+	}
+	if err := writeReportJSON(w, report, version); err != nil {
+		return withExitCode(exitOutput, fmt.Errorf("writing JSON: %w", err))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeStdin(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "mem", "mem.go"), "package mem\n\ntype MemStore struct{}\n\nfunc (MemStore) Get(id string) string { return id }\n")
+	// The buffer differs from the copy on disk, which must not be read
+	filename := filepath.Join(root, "api", "api.go")
+	writeFile(t, filename, "package api\n\ntype Old interface{ Old() }\n")
+
+	src := "package api\n\ntype Store interface {\n\tGet(id string) string\n}\n"
+	var out strings.Builder
+	if err := analyzeStdin(context.Background(), strings.NewReader(src), &out, filename, root, schemaVersion); err != nil {
+		t.Fatalf("analyzeStdin: %v", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal([]byte(out.String()), &report); err != nil {
+		t.Fatalf("decoding output: %v\n%s", err, out.String())
+	}
+	if len(report.Interfaces) != 1 || report.Interfaces[0].InterfaceName != "Store" {
+		t.Fatalf("interfaces = %+v, want only Store from the buffer", report.Interfaces)
+	}
+	if impls := report.Interfaces[0].Implementations; len(impls) != 1 || impls[0].TypeName != "MemStore" {
+		t.Errorf("implementations = %+v, want MemStore", impls)
+	}
+}
+
+// A writer that always fails, like a closed pipe
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestAnalyzeStdinExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		dir  string
+		w    io.Writer
+		want int
+	}{
+		{"no directory", "package api\n\ntype Store interface{ Get() }\n", "", io.Discard, exitOK},
+		{"parse error", "package api\n\ntype Store interface{", "", io.Discard, exitParse},
+		{"missing directory", "package api\n", filepath.Join(t.TempDir(), "missing"), io.Discard, exitUsage},
+		{"write error", "package api\n\ntype Store interface{ Get() }\n", "", failingWriter{}, exitOutput},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := analyzeStdin(context.Background(), strings.NewReader(test.src), test.w, "buffer.go", test.dir, schemaVersion)
+			if got := exitCode(err); got != test.want {
+				t.Errorf("exit code = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
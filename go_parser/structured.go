@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// InterfaceDoc is the documentation of one interface in a structured reply,
+// see structured_output
+type InterfaceDoc struct {
+	Interface  string      `json:"interface"`
+	Summary    string      `json:"summary"`
+	MethodDocs []MethodDoc `json:"method_docs"`
+	// Pitfalls for implementers and callers; may be empty
+	Caveats []string `json:"caveats"`
+}
+
+// MethodDoc is the documentation of one method in a structured reply
+type MethodDoc struct {
+	Name string `json:"name"`
+	Doc  string `json:"doc"`
+}
+
+// The reply asked for with structured_output
+type structuredReply struct {
+	Interfaces []InterfaceDoc `json:"interfaces"`
+}
+
+// JSON Schema of structuredReply, in the strict form OpenAI's json_schema
+// response format takes: every property required, no others allowed
+var structuredReplySchema = map[string]interface{}{
+	"type":                 "object",
+	"additionalProperties": false,
+	"required":             []string{"interfaces"},
+	"properties": map[string]interface{}{
+		"interfaces": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": false,
+				"required":             []string{"interface", "summary", "method_docs", "caveats"},
+				"properties": map[string]interface{}{
+					"interface": map[string]interface{}{"type": "string"},
+					"summary":   map[string]interface{}{"type": "string"},
+					"method_docs": map[string]interface{}{
+						"type": "array",
+						"items": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": false,
+							"required":             []string{"name", "doc"},
+							"properties": map[string]interface{}{
+								"name": map[string]interface{}{"type": "string"},
+								"doc":  map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+					"caveats": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				},
+			},
+		},
+	},
+}
+
+// Added to each prompt with structured_output, for models that don't see
+// the schema
+const structuredInstruction = "\nReply with JSON only: an object whose interfaces array has one entry per interface above, " +
+	"with its name without type parameters (interface), a summary of its purpose (summary), " +
+	"a doc comment for each of its methods (method_docs, with name and doc) " +
+	"and pitfalls for implementers and callers (caveats, possibly empty).\n"
+
+// Function to tell whether a provider can be asked for JSON following a
+// schema: OpenAI through response_format, Ollama through format, and the
+// mock provider
+func supportsStructuredOutput(provider string) bool {
+	switch provider {
+	case "", "openai", "ollama", "mock":
+		return true
+	}
+	return false
+}
+
+// Function to decode a structured reply and check it against the schema
+// and against the interfaces the prompt described: each documented once,
+// under its name, with only its own methods. Returns the docs in the order
+// of chunk.
+func parseInterfaceDocs(content string, chunk []InterfaceDetails) ([]InterfaceDoc, error) {
+	// Some models fence the JSON despite being asked not to
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(strings.TrimPrefix(content, "```json"), "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	}
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.DisallowUnknownFields()
+	var reply structuredReply
+	if err := decoder.Decode(&reply); err != nil {
+		return nil, fmt.Errorf("not JSON following the schema: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("not JSON following the schema: text after the object")
+	}
+
+	docs := make([]InterfaceDoc, len(chunk))
+	documented := make([]bool, len(chunk))
+	for _, doc := range reply.Interfaces {
+		if doc.Interface == "" || strings.TrimSpace(doc.Summary) == "" {
+			return nil, fmt.Errorf("an entry of interfaces lacks its interface or summary")
+		}
+		i := -1
+		for j, result := range chunk {
+			if !documented[j] && result.InterfaceName == strings.SplitN(doc.Interface, "[", 2)[0] {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("interface %s is not one of the interfaces asked about, or is documented twice", doc.Interface)
+		}
+		documented[i] = true
+		docs[i] = doc
+		for _, method := range doc.MethodDocs {
+			if !hasMethod(chunk[i], method.Name) {
+				return nil, fmt.Errorf("%s has no method %s", doc.Interface, method.Name)
+			}
+		}
+	}
+	for i, result := range chunk {
+		if !documented[i] {
+			return nil, fmt.Errorf("interface %s is not documented", result.InterfaceName)
+		}
+	}
+	return docs, nil
+}
+
+// Function to attach the docs of each valid structured reply to the
+// interfaces of its prompt, for rendering. A reply that doesn't parse, such
+// as the partial reply of a failed request, is left out.
+func mergeInterfaceDocs(chunks [][]InterfaceDetails, completions []*Completion) []InterfaceDetails {
+	var documented []InterfaceDetails
+	for i, completion := range completions {
+		docs, err := parseInterfaceDocs(completion.Content, chunks[i])
+		if err != nil {
+			continue
+		}
+		for j, result := range chunks[i] {
+			doc := docs[j]
+			result.generatedDoc = &doc
+			documented = append(documented, result)
+		}
+	}
+	return documented
+}
+
+// Function to tell whether an interface has a method of the given name
+func hasMethod(result InterfaceDetails, name string) bool {
+	for _, method := range result.Methods {
+		if method.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Function to ask again after an invalid reply, saying what was wrong
+func correctivePrompt(prompt, reply string, err error) string {
+	return prompt + "\nYour previous reply was rejected: " + err.Error() + ". It was:\n" + reply +
+		"\nReply again, with JSON only, documenting each interface above exactly once."
+}
+
+// structuredClient checks each reply of another client against the schema
+// and the interfaces of its prompt, asking once more with a corrective
+// prompt when it doesn't match
+type structuredClient struct {
+	LLMClient
+	// Interfaces described by each prompt
+	chunks map[string][]InterfaceDetails
+}
+
+func (c *structuredClient) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	completion, err := c.LLMClient.Complete(ctx, prompt)
+	if err != nil {
+		return completion, err
+	}
+	_, invalid := parseInterfaceDocs(completion.Content, c.chunks[prompt])
+	if invalid == nil {
+		return completion, nil
+	}
+	log.Printf("Warning: invalid structured reply (%v), asking again", invalid)
+	retry, err := c.LLMClient.Complete(ctx, correctivePrompt(prompt, completion.Content, invalid))
+	if retry != nil {
+		retry.Usage.PromptTokens += completion.Usage.PromptTokens
+		retry.Usage.CompletionTokens += completion.Usage.CompletionTokens
+	}
+	if err != nil {
+		return retry, err
+	}
+	if _, invalid := parseInterfaceDocs(retry.Content, c.chunks[prompt]); invalid != nil {
+		return nil, fmt.Errorf("structured reply still invalid after a corrective prompt: %w", invalid)
+	}
+	return retry, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const structuredStore = `{"interfaces": [{"interface": "Store", "summary": "Store persists items.", ` +
+	`"method_docs": [{"name": "Get", "doc": "Get returns the item with the given id."}], ` +
+	`"caveats": ["Get returns an error for a missing id."]}]}`
+
+// Function to start a fake chat completions endpoint that answers with the
+// given contents in turn, recording the payloads it got
+func fakeStructuredOpenAI(t *testing.T, contents ...string) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var calls int32
+	payloads := new([]map[string]interface{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decoding request: %v", err)
+		}
+		*payloads = append(*payloads, payload)
+		content, _ := json.Marshal(contents[int(n-1)%len(contents)])
+		fmt.Fprintf(w, `{"choices": [{"message": {"content": %s}}], "usage": {"prompt_tokens": 100, "completion_tokens": 20}}`, content)
+	}))
+	t.Cleanup(server.Close)
+	return server, payloads
+}
+
+func TestSendDataStructured(t *testing.T) {
+	server, payloads := fakeStructuredOpenAI(t, structuredStore)
+	config := &Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true, StructuredOutput: true}
+
+	content, err := sendData(context.Background(), config, testResults(), newUsageTracker(config))
+	if err != nil {
+		t.Fatalf("sendData: %v", err)
+	}
+	for _, want := range []string{
+		"## Store\n\nStore persists items.\n\n### Methods\n\n- `Get(id string) (string, error)`: Get returns the item with the given id.\n",
+		"### Caveats\n\n- Get returns an error for a missing id.\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, content)
+		}
+	}
+
+	format, _ := (*payloads)[0]["response_format"].(map[string]interface{})
+	schema, _ := format["json_schema"].(map[string]interface{})
+	if format["type"] != "json_schema" || schema["strict"] != true || schema["schema"] == nil {
+		t.Errorf("response_format = %v", format)
+	}
+	messages, _ := (*payloads)[0]["messages"].([]interface{})
+	if prompt := messages[0].(map[string]interface{})["content"].(string); !strings.Contains(prompt, structuredInstruction) {
+		t.Errorf("prompt lacks the instruction:\n%s", prompt)
+	}
+}
+
+func TestStructuredRetriesOnce(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	// A first reply missing a field gets a corrective prompt
+	server, payloads := fakeStructuredOpenAI(t, `{"interfaces": [{"interface": "Store"}]}`, structuredStore)
+	config := &Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true, StructuredOutput: true}
+	tracker := newUsageTracker(config)
+	content, err := sendData(context.Background(), config, testResults(), tracker)
+	if err != nil || !strings.Contains(content, "Store persists items.") {
+		t.Fatalf("sendData = %q, %v", content, err)
+	}
+	if len(*payloads) != 2 || tracker.total.PromptTokens != 200 {
+		t.Errorf("requests = %d, prompt tokens = %d, want both attempts counted", len(*payloads), tracker.total.PromptTokens)
+	}
+	messages := (*payloads)[1]["messages"].([]interface{})
+	if prompt := messages[0].(map[string]interface{})["content"].(string); !strings.Contains(prompt, "Your previous reply was rejected: an entry of interfaces lacks its interface or summary") {
+		t.Errorf("corrective prompt:\n%s", prompt)
+	}
+	if !strings.Contains(logs.String(), "Warning: invalid structured reply") {
+		t.Errorf("logs = %q", logs.String())
+	}
+
+	// and a second invalid reply fails the request
+	server, payloads = fakeStructuredOpenAI(t, "Store persists items.")
+	config.BaseURL = server.URL
+	if _, err := sendData(context.Background(), config, testResults(), newUsageTracker(config)); err == nil || !strings.Contains(err.Error(), "still invalid") {
+		t.Errorf("err = %v, want the reply rejected", err)
+	}
+	if len(*payloads) != 2 {
+		t.Errorf("requests = %d, want a single retry", len(*payloads))
+	}
+}
+
+func TestStructuredFallsBackToFreeText(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+		fmt.Fprint(w, `{"content": [{"type": "text", "text": "Store persists items."}], "usage": {"input_tokens": 10, "output_tokens": 5}}`)
+	}))
+	defer server.Close()
+
+	config := &Config{Provider: "anthropic", APIKey: "test-key", BaseURL: server.URL, StructuredOutput: true}
+	content, err := sendData(context.Background(), config, testResults(), newUsageTracker(config))
+	if err != nil || content != "Store persists items." {
+		t.Errorf("sendData = %q, %v, want the free text", content, err)
+	}
+	if !strings.Contains(logs.String(), "Warning: the anthropic provider can't be asked for JSON") {
+		t.Errorf("logs = %q", logs.String())
+	}
+	if prompt := fmt.Sprint(payload["messages"]); strings.Contains(prompt, "Reply with JSON") {
+		t.Errorf("free text prompt asks for JSON: %s", prompt)
+	}
+}
+
+func TestParseInterfaceDocs(t *testing.T) {
+	chunk := append(testResults(), testResults()...)
+	chunk[1].InterfaceName = "Cache"
+	chunk[1].TypeParams = "[K comparable]"
+	valid := `{"interfaces": [
+		{"interface": "Cache[K]", "summary": "Caches.", "method_docs": [], "caveats": []},
+		{"interface": "Store", "summary": "Stores.", "method_docs": [{"name": "Get", "doc": "Gets."}], "caveats": []}
+	]}`
+	docs, err := parseInterfaceDocs("```json\n"+valid+"\n```", chunk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs[0].Summary != "Stores." || docs[1].Summary != "Caches." {
+		t.Errorf("docs = %+v, want them in the order of the interfaces", docs)
+	}
+
+	for reply, want := range map[string]string{
+		`{"interfaces": [{"interface": "Store", "summary": "Stores.", "method_docs": [], "caveats": [], "extra": 1}]}`:     "unknown field",
+		`{"interfaces": [{"interface": "Store", "summary": "Stores.", "method_docs": [{"name": "Put", "doc": "Puts."}]}]}`: "Store has no method Put",
+		`{"interfaces": [{"interface": "Store", "summary": "Stores."}]}`:                                                   "interface Cache is not documented",
+		`{"interfaces": [{"interface": "Queue", "summary": "Queues."}]}`:                                                   "Queue is not one of the interfaces",
+		`{"interfaces": []} trailing`: "text after the object",
+	} {
+		if _, err := parseInterfaceDocs(reply, chunk); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: err = %v, want %q", reply, err, want)
+		}
+	}
+}
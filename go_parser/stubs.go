@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Function to list the names declared by a field list, e.g. type parameters
+func fieldNames(fields *ast.FieldList) []string {
+	if fields == nil {
+		return nil
+	}
+	var names []string
+	for _, field := range fields.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+	return names
+}
+
+// Function to find the import specs of a file that an interface's method
+// signatures and embedded interfaces refer to, e.g. `"context"` or
+// `foo "example.com/foo"`
+func usedImports(file *ast.File, interfaceType *ast.InterfaceType) []string {
+	used := make(map[string]bool)
+	for _, method := range interfaceType.Methods.List {
+		ast.Inspect(method.Type, func(n ast.Node) bool {
+			if selector, ok := n.(*ast.SelectorExpr); ok {
+				if ident, ok := selector.X.(*ast.Ident); ok {
+					used[ident.Name] = true
+				}
+			}
+			return true
+		})
+	}
+
+	var imports []string
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if !used[importName(spec, importPath)] {
+			continue
+		}
+		if spec.Name != nil {
+			imports = append(imports, spec.Name.Name+" "+spec.Path.Value)
+		} else {
+			imports = append(imports, spec.Path.Value)
+		}
+	}
+	return imports
+}
+
+// Function to write a compilable skeleton implementation for every interface
+// without implementations, one gofmt-formatted file per interface. When dir
+// is not the interface's own package, types of that package are qualified
+// and the package is imported.
+func generateStubs(dir string, results []InterfaceDetails) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	dirImportPath := packageImportPath(dir)
+
+	for _, iface := range results {
+		// Anything implements an empty interface, there is nothing to stub,
+		// and an interface literal has no name to stub
+		if len(iface.Implementations) > 0 || iface.IsConstraint || (iface.IsEmpty && len(iface.Embeds) == 0) || iface.Anonymous != "" {
+			continue
+		}
+		target := stubPackage{name: iface.Package}
+		if iface.importPath == "" || iface.importPath != dirImportPath {
+			target = stubPackage{name: outputPackageName(dir), external: true}
+		}
+		src, err := stubSource(iface, target)
+		if err != nil {
+			return fmt.Errorf("generating stub for %s: %w", iface.InterfaceName, err)
+		}
+		file := filepath.Join(dir, strings.ToLower(iface.InterfaceName)+"_stub.go")
+		if err := os.WriteFile(file, src, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The package a stub is written to
+type stubPackage struct {
+	name string
+	// Set when it is not the package declaring the interface
+	external bool
+}
+
+// Function to pick the package name for stubs written to dir: the package of
+// the Go files already there, or else the directory name
+func outputPackageName(dir string) string {
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, entry.Name()), nil, parser.PackageClauseOnly)
+		if err == nil {
+			return file.Name.Name
+		}
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "stubs"
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(abs))
+	if name == "" || !unicode.IsLetter(rune(name[0])) {
+		return "stubs"
+	}
+	return name
+}
+
+// Predeclared types, which are never qualified
+var predeclaredTypes = map[string]bool{
+	"any": true, "bool": true, "byte": true, "comparable": true, "complex64": true, "complex128": true,
+	"error": true, "float32": true, "float64": true, "int": true, "int8": true, "int16": true,
+	"int32": true, "int64": true, "rune": true, "string": true, "uint": true, "uint8": true,
+	"uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+}
+
+// Function to qualify the types of the interface's own package in a
+// signature or type expression, e.g. "*User" becomes "*svc.User". It reports
+// whether anything was qualified.
+func qualifyLocalTypes(expr ast.Node, pkg string, typeParams []string) bool {
+	keep := make(map[string]bool)
+	for _, name := range typeParams {
+		keep[name] = true
+	}
+
+	// Parameter and field names are identifiers too; only types are qualified
+	names := make(map[*ast.Ident]bool)
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok {
+			for _, name := range field.Names {
+				names[name] = true
+			}
+		}
+		return true
+	})
+
+	qualified := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.SelectorExpr:
+			return false // already qualified
+		case *ast.Ident:
+			if !names[node] && !keep[node.Name] && !predeclaredTypes[node.Name] && node.Name != "_" {
+				// The printer writes the name as is, which gives pkg.Name
+				node.Name = pkg + "." + node.Name
+				qualified = true
+			}
+		}
+		return true
+	})
+	return qualified
+}
+
+// Function to render the stub source for one interface
+func stubSource(iface InterfaceDetails, target stubPackage) ([]byte, error) {
+	fset := token.NewFileSet()
+	stubName := iface.InterfaceName + "Stub"
+	receiver := stubName
+	if len(iface.typeParamNames) > 0 {
+		receiver += "[" + strings.Join(iface.typeParamNames, ", ") + "]"
+	}
+
+	// Re-parse the declarations so they can be qualified for another package
+	usesPackage := false
+	qualify := func(src string, parse func(string) (ast.Node, error)) (string, error) {
+		if !target.external {
+			return src, nil
+		}
+		node, err := parse(src)
+		if err != nil {
+			return "", err
+		}
+		if qualifyLocalTypes(node, iface.Package, iface.typeParamNames) {
+			usesPackage = true
+		}
+		return exprString(fset, node), nil
+	}
+	parseExpr := func(src string) (ast.Node, error) { return parser.ParseExpr(src) }
+
+	typeParams := iface.TypeParams
+	if target.external && typeParams != "" {
+		// Type parameter lists only parse as part of a declaration
+		file, err := parser.ParseFile(fset, "", "package p\ntype T"+typeParams+" struct{}", 0)
+		if err != nil {
+			return nil, err
+		}
+		params := file.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).TypeParams
+		if qualifyLocalTypes(params, iface.Package, iface.typeParamNames) {
+			usesPackage = true
+		}
+		typeParams = typeParamsString(fset, params)
+	}
+
+	var embeds []string
+	for _, embed := range iface.Embeds {
+		qualified, err := qualify(embed, parseExpr)
+		if err != nil {
+			return nil, err
+		}
+		embeds = append(embeds, qualified)
+	}
+
+	var methods []string
+	for _, method := range iface.Methods {
+		// Methods of embedded interfaces come with the embedded field
+		if method.embeddedFrom != "" {
+			continue
+		}
+		open := strings.Index(method.Signature, "(")
+		signature, err := qualify(method.Signature[open:], func(src string) (ast.Node, error) {
+			return parser.ParseExpr("func" + src)
+		})
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method.Name+strings.TrimPrefix(signature, "func"))
+	}
+
+	imports := iface.imports
+	if usesPackage {
+		if iface.importPath == "" {
+			return nil, fmt.Errorf("it refers to types of package %s, which is not inside a module and can't be imported", iface.Package)
+		}
+		spec := strconv.Quote(iface.importPath)
+		if importName(&ast.ImportSpec{}, iface.importPath) != iface.Package {
+			spec = iface.Package + " " + spec
+		}
+		imports = append([]string{spec}, imports...)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by go_parser; replace the panics with real code.\n\npackage %s\n\n", target.name)
+	if len(imports) > 0 {
+		fmt.Fprintf(&b, "import (\n\t%s\n)\n\n", strings.Join(imports, "\n\t"))
+	}
+
+	fmt.Fprintf(&b, "// %s is a skeleton implementation of %s.\n", stubName, iface.InterfaceName)
+	if len(embeds) > 0 {
+		// Embedding the embedded interfaces satisfies them; set the fields or
+		// replace them with methods
+		fmt.Fprintf(&b, "type %s%s struct {\n\t%s\n}\n", stubName, typeParams, strings.Join(embeds, "\n\t"))
+	} else {
+		fmt.Fprintf(&b, "type %s%s struct{}\n", stubName, typeParams)
+	}
+	for _, method := range methods {
+		fmt.Fprintf(&b, "\nfunc (s *%s) %s {\n\tpanic(\"not implemented\")\n}\n", receiver, method)
+	}
+
+	return format.Source(b.Bytes())
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStubsCompile(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	ifacePath := filepath.Join(root, "svc", "svc.go")
+	writeFile(t, ifacePath, `package svc
+
+import (
+	"context"
+	"io"
+)
+
+type User struct{ Name string }
+
+type Base interface {
+	ID() string
+}
+
+type Finder interface {
+	io.Closer
+	Base
+	Find(ctx context.Context, id string) (*User, error)
+	All(filter func(u User) bool) []User
+}
+
+type Cache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Owner() *User
+}
+`)
+
+	interfaces, _ := findInterfaces(ifacePath, &Config{})
+	var results []InterfaceDetails
+	for _, ref := range sortedInterfaceRefs(interfaces) {
+		results = append(results, *interfaces[ref])
+	}
+
+	// Stubs in another package qualify svc's types; stubs next to the
+	// interfaces use them as they are
+	for _, dir := range []string{filepath.Join(root, "out"), filepath.Join(root, "svc")} {
+		if err := generateStubs(dir, results); err != nil {
+			t.Fatalf("generateStubs(%s): %v", dir, err)
+		}
+	}
+	src, err := os.ReadFile(filepath.Join(root, "out", "finder_stub.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"package out", "*svc.User", "func(u svc.User) bool", "io.Closer", "svc.Base"} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("finder stub lacks %q:\n%s", want, src)
+		}
+	}
+
+	// The stubs have to build and satisfy their interfaces
+	writeFile(t, filepath.Join(root, "check", "check.go"), `package check
+
+import (
+	"example.com/app/out"
+	"example.com/app/svc"
+)
+
+var (
+	_ svc.Finder               = (*out.FinderStub)(nil)
+	_ svc.Cache[string, int]   = (*out.CacheStub[string, int])(nil)
+	_ svc.Finder               = (*svc.FinderStub)(nil)
+	_ svc.Cache[string, int]   = (*svc.CacheStub[string, int])(nil)
+)
+`)
+	cmd := exec.Command(goTool, "build", "./...")
+	cmd.Dir = root
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,60 @@
+// Package fluent has builder-style interfaces whose methods return the
+// interface itself, directly, in composite types or through an embed.
+package fluent
+
+// Builder builds queries fluently.
+type Builder interface {
+	Node
+	// WithLimit caps the rows.
+	WithLimit(n int) Builder
+	Where(cond string, args ...any) Builder
+	Clone() (Builder, error)
+	Children() []Builder
+	Each(fn func(Builder) Builder) Builder
+	Build() string
+}
+
+// Node is a builder in a tree of builders.
+type Node interface {
+	Parent() Builder
+}
+
+// Chain is a generic fluent interface.
+type Chain[T any] interface {
+	Then(fn func(T) T) Chain[T]
+	Value() T
+}
+
+// Query implements Builder.
+type Query struct {
+	limit  int
+	parent Builder
+}
+
+func (q *Query) Parent() Builder { return q.parent }
+
+// WithLimit caps the rows.
+func (q *Query) WithLimit(n int) Builder {
+	q.limit = n
+	return q
+}
+
+func (q *Query) Where(cond string, args ...any) Builder { return q }
+
+func (q *Query) Clone() (Builder, error) {
+	clone := *q
+	return &clone, nil
+}
+
+func (q *Query) Children() []Builder { return nil }
+
+func (q *Query) Each(fn func(Builder) Builder) Builder { return fn(q) }
+
+func (q *Query) Build() string { return "" }
+
+// Box implements Chain.
+type Box[T any] struct{ value T }
+
+func (b Box[T]) Then(fn func(T) T) Chain[T] { return Box[T]{value: fn(b.value)} }
+
+func (b Box[T]) Value() T { return b.value }
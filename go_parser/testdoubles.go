@@ -0,0 +1,94 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of implementations
+const (
+	KindProduction = "production"
+	KindTestDouble = "test-double"
+)
+
+// Names of test doubles unless test_double_patterns is set, e.g. MockUserRepo,
+// fakeClock or userRepoStub
+var defaultTestDoublePatterns = []string{"^[Mm]ock", "^[Ff]ake", "[Ss]tub$"}
+
+// Function to compile test_double_patterns, or the defaults when it is empty
+func (c *Config) testDoublePatterns() ([]*regexp.Regexp, error) {
+	patterns := c.TestDoublePatterns
+	if len(patterns) == 0 {
+		patterns = defaultTestDoublePatterns
+	}
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Function to check whether a type is a test double: its name matches one
+// of the patterns, or it is declared in a _test.go file or under a mocks
+// directory
+func isTestDouble(impl Implementation, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(impl.TypeName) {
+			return true
+		}
+	}
+	path := filepath.ToSlash(impl.pos.Filename)
+	return strings.HasSuffix(strings.ToLower(path), "_test.go") ||
+		strings.HasPrefix(path, "mocks/") || strings.Contains(path, "/mocks/")
+}
+
+// Function to set the Kind of every implementation and list the production
+// ones first, keeping the order within each kind
+func classifyImplementations(results []InterfaceDetails, patterns []*regexp.Regexp) {
+	for i := range results {
+		impls := results[i].Implementations
+		for j := range impls {
+			impls[j].Kind = KindProduction
+			if isTestDouble(impls[j], patterns) {
+				impls[j].Kind = KindTestDouble
+			}
+		}
+		sort.SliceStable(impls, func(a, b int) bool {
+			return impls[a].Kind == KindProduction && impls[b].Kind != KindProduction
+		})
+	}
+}
+
+// Function to drop the test doubles from copies of the results, keeping
+// their count for the markdown and the LLM, unless show_test_doubles is set
+func collapseTestDoubles(results []InterfaceDetails, config *Config) []InterfaceDetails {
+	if config.ShowTestDoubles {
+		return results
+	}
+	collapsed := make([]InterfaceDetails, len(results))
+	for i, result := range results {
+		var production []Implementation
+		for _, impl := range result.Implementations {
+			if impl.Kind == KindTestDouble {
+				result.hiddenTestDoubles++
+				continue
+			}
+			production = append(production, impl)
+		}
+		result.Implementations = production
+		collapsed[i] = result
+	}
+	return collapsed
+}
+
+// Function to count the implementations of an interface, including hidden
+// test doubles
+func implementationCount(result InterfaceDetails) int {
+	return len(result.Implementations) + result.hiddenTestDoubles
+}
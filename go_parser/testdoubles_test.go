@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifyTestDoubles(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "repo", "repo.go")
+	writeFile(t, ifacePath, "package repo\n\ntype UserRepo interface{ Find(id string) string }\n")
+	method := func(name string) string {
+		return "\n\ntype " + name + " struct{}\n\nfunc (" + name + ") Find(id string) string { return \"\" }\n"
+	}
+	writeFile(t, filepath.Join(root, "repo", "mock.go"), "package repo"+method("MockUserRepo")+method("userRepoStub"))
+	writeFile(t, filepath.Join(root, "repo", "sql.go"), "package repo"+method("SQLRepo"))
+	writeFile(t, filepath.Join(root, "repo", "mocks", "repo.go"), "package mocks"+method("UserRepo"))
+	writeFile(t, filepath.Join(root, "repo", "repo_test.go"), "package repo"+method("memRepo"))
+	writeFile(t, filepath.Join(root, "repo", "zcache.go"), "package repo"+method("CachedRepo"))
+
+	config := &Config{IncludeTests: true}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{filepath.Join(root, "repo")}, interfaces, config)
+
+	var got []string
+	for _, impl := range results[0].Implementations {
+		got = append(got, impl.TypeName+":"+impl.Kind)
+	}
+	// Production implementations come first, each kind in scan order
+	want := "SQLRepo:production CachedRepo:production MockUserRepo:test-double userRepoStub:test-double UserRepo:test-double memRepo:test-double"
+	if strings.Join(got, " ") != want {
+		t.Errorf("implementations = %s\nwant %s", strings.Join(got, " "), want)
+	}
+
+	markdown := renderMarkdown(collapseTestDoubles(results, config), nil)
+	for _, want := range []string{"`repo.SQLRepo`", "- _and 4 test doubles (mocks, fakes, stubs)_", "Implemented by 6 types"} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+	if strings.Contains(markdown, "MockUserRepo") {
+		t.Errorf("markdown lists a test double:\n%s", markdown)
+	}
+	if message := formatResultsForMessage(collapseTestDoubles(results, config)); !strings.Contains(message, "Test doubles (mocks, fakes, stubs): 4, not listed") {
+		t.Errorf("message lacks the test double count:\n%s", message)
+	}
+
+	config.ShowTestDoubles = true
+	if markdown := renderMarkdown(collapseTestDoubles(results, config), nil); !strings.Contains(markdown, "`repo.MockUserRepo` (satisfied by both), test double") {
+		t.Errorf("markdown with test doubles:\n%s", markdown)
+	}
+	if len(results[0].Implementations) != 6 {
+		t.Error("collapseTestDoubles changed the results")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Transcript is the record of one LLM request written to transcript_dir,
+// for auditing what was sent and received
+type Transcript struct {
+	Timestamp time.Time `json:"timestamp"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	// The conversation as sent, after redaction
+	Messages  []PromptMessage `json:"messages"`
+	Response  string          `json:"response"`
+	Usage     TokenUsage      `json:"usage"`
+	LatencyMS int64           `json:"latency_ms"`
+	// Set when the request failed; Response then holds whatever part of the
+	// reply arrived
+	Error string `json:"error,omitempty"`
+}
+
+// transcriptClient writes a Transcript for every request of another client.
+// It only ever sees prompts that went through redactPrompt, and redacts
+// again before writing as a safety net, so text from before the redaction
+// pass can't reach the disk.
+type transcriptClient struct {
+	LLMClient
+	dir    string
+	config *Config
+	now    func() time.Time
+}
+
+// Function to wrap a client so its requests are recorded in dir, creating
+// dir if needed
+func newTranscriptClient(client LLMClient, dir string, config *Config) (*transcriptClient, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("transcript_dir: %w", err)
+	}
+	return &transcriptClient{LLMClient: client, dir: dir, config: config, now: time.Now}, nil
+}
+
+func (c *transcriptClient) Complete(ctx context.Context, prompt string) (*Completion, error) {
+	start := c.now()
+	completion, err := c.LLMClient.Complete(ctx, prompt)
+
+	transcript := Transcript{
+		Timestamp: start.UTC(),
+		Provider:  c.config.Provider,
+		Model:     modelName(c.config),
+		Messages:  buildMessages(c.config, prompt),
+		LatencyMS: c.now().Sub(start).Milliseconds(),
+	}
+	if transcript.Provider == "" {
+		transcript.Provider = "openai"
+	}
+	if completion != nil {
+		transcript.Response = completion.Content
+		transcript.Usage = completion.Usage
+	}
+	if err != nil {
+		transcript.Error = err.Error()
+	}
+	if path, writeErr := c.write(transcript); writeErr != nil {
+		log.Printf("Error writing transcript %s: %v", path, writeErr)
+	}
+	return completion, err
+}
+
+// Function to write a transcript to a file of its own, named after its
+// timestamp, so names sort in request order, and a hash of its content, so
+// requests started at the same moment don't collide. Returns the path.
+func (c *transcriptClient) write(transcript Transcript) (string, error) {
+	for i := range transcript.Messages {
+		content, _, err := redactPrompt(transcript.Messages[i].Content, c.config)
+		if err != nil {
+			return "", err
+		}
+		transcript.Messages[i].Content = content
+	}
+	response, _, err := redactPrompt(transcript.Response, c.config)
+	if err != nil {
+		return "", err
+	}
+	transcript.Response = response
+
+	data, err := json.MarshalIndent(transcript, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	base := transcript.Timestamp.Format("20060102T150405.000000000Z") + "-" + hex.EncodeToString(sum[:])[:12]
+	path := filepath.Join(c.dir, base+".json")
+	// Never overwrite a transcript, even an identical one
+	for n := 2; ; n++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			path = filepath.Join(c.dir, fmt.Sprintf("%s-%d.json", base, n))
+			continue
+		}
+		if err != nil {
+			return path, err
+		}
+		if _, err := file.Write(append(data, '\n')); err != nil {
+			file.Close()
+			return path, err
+		}
+		return path, file.Close()
+	}
+}
+
+// Function to keep send -check-auth working through the recorder
+func (c *transcriptClient) checkAuth(ctx context.Context) error {
+	return checkAuthOf(ctx, c.LLMClient, c.config.Provider)
+}
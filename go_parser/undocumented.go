@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// UndocumentedMethod is an exported interface method, or an exported method
+// of an exported implementing type, without a doc comment
+type UndocumentedMethod struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Symbol string `json:"symbol"` // e.g. "Store.Get" or "MemStore.Get"
+	// "interface_method" or "method"
+	Kind string `json:"kind"`
+}
+
+// Kinds of undocumented methods
+const (
+	UndocumentedInterfaceMethod = "interface_method"
+	UndocumentedMethodKind      = "method"
+)
+
+// Function to format a finding as "path:line: exported method Foo.Bar is
+// undocumented"
+func (u UndocumentedMethod) String() string {
+	return fmt.Sprintf("%s:%d: %s", u.File, u.Line, u.message())
+}
+
+func (u UndocumentedMethod) message() string {
+	return fmt.Sprintf("exported method %s is undocumented", u.Symbol)
+}
+
+// Function to list the undocumented exported methods of the interfaces and
+// their implementations, ordered by position. A method shared by several
+// interfaces (through embedding) or implementations is listed once.
+func undocumentedMethods(results []InterfaceDetails) []UndocumentedMethod {
+	var findings []UndocumentedMethod
+	seen := make(map[string]bool)
+	add := func(pos token.Position, symbol, kind string) {
+		if pos.Filename == "" {
+			return // a known interface such as io.Reader
+		}
+		finding := UndocumentedMethod{File: filepath.ToSlash(pos.Filename), Line: pos.Line, Symbol: symbol, Kind: kind}
+		if key := finding.String(); !seen[key] {
+			seen[key] = true
+			findings = append(findings, finding)
+		}
+	}
+
+	for _, result := range results {
+		if !ast.IsExported(result.InterfaceName) {
+			continue
+		}
+		for _, method := range methodsInOrder(result.Methods) {
+			// Methods of embeds are reported under their own interface
+			if method.embeddedFrom == "" && ast.IsExported(method.Name) && method.Doc == "" {
+				add(method.pos, result.InterfaceName+"."+method.Name, UndocumentedInterfaceMethod)
+			}
+		}
+		for _, impl := range result.Implementations {
+			if !ast.IsExported(impl.TypeName) {
+				continue
+			}
+			for _, method := range impl.Methods {
+				if ast.IsExported(method.Name) && !method.Documented && method.DelegatedTo == "" {
+					add(method.pos, impl.TypeName+"."+method.Name, UndocumentedMethodKind)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings
+}
+
+// Function to print one line per finding, as compilers and linters do
+func writeUndocumented(w io.Writer, findings []UndocumentedMethod) error {
+	for _, finding := range findings {
+		if _, err := fmt.Fprintln(w, finding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Function to print the findings as GitHub Actions workflow commands, which
+// show up as warnings on the lines of a pull request
+func writeGitHubAnnotations(w io.Writer, findings []UndocumentedMethod) error {
+	for _, finding := range findings {
+		_, err := fmt.Fprintf(w, "::warning file=%s,line=%d::%s\n",
+			escapeGitHubProperty(finding.File), finding.Line, escapeGitHubData(finding.message()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Function to escape the message of a workflow command
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// Function to escape a property of a workflow command, which also can't
+// contain the separators
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
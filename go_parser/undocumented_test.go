@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUndocumentedMethods(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "svc", "svc.go")
+	writeFile(t, ifacePath, `package svc
+
+type Reader interface {
+	Read(id string) string
+}
+
+type Store interface {
+	Reader
+	// Put stores an item.
+	Put(id, v string)
+	Delete(id string)
+	flush()
+}
+
+type cache interface {
+	Purge()
+}
+`)
+	writeFile(t, filepath.Join(root, "svc", "mem.go"), `package svc
+
+type MemStore struct{}
+
+// Read returns an item.
+func (m *MemStore) Read(id string) string { return "" }
+func (m *MemStore) Put(id, v string)      {}
+func (m *MemStore) Delete(id string)      {}
+func (m *MemStore) flush()                {}
+func (m *MemStore) Purge()                {}
+
+type lru struct{}
+
+func (l lru) Purge() {}
+`)
+
+	config := &Config{}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	results, _, _ := findImplementations(context.Background(), []string{filepath.Join(root, "svc")}, interfaces, config)
+	findings := undocumentedMethods(results)
+
+	// Read is reported once, under Reader; nothing of the unexported cache
+	// or lru is reported
+	var buf bytes.Buffer
+	if err := writeUndocumented(&buf, findings); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.ToSlash(filepath.Join(root, "svc"))
+	want := strings.Join([]string{
+		dir + "/mem.go:7: exported method MemStore.Put is undocumented",
+		dir + "/mem.go:8: exported method MemStore.Delete is undocumented",
+		dir + "/svc.go:4: exported method Reader.Read is undocumented",
+		dir + "/svc.go:11: exported method Store.Delete is undocumented",
+	}, "\n") + "\n"
+	if buf.String() != want {
+		t.Errorf("findings:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	if findings[0].Kind != UndocumentedMethodKind || findings[2].Kind != UndocumentedInterfaceMethod {
+		t.Errorf("kinds = %s, %s", findings[0].Kind, findings[2].Kind)
+	}
+}
+
+func TestGitHubAnnotations(t *testing.T) {
+	var buf bytes.Buffer
+	findings := []UndocumentedMethod{{File: "svc/a,b:c.go", Line: 3, Symbol: "Store.Get", Kind: UndocumentedInterfaceMethod}}
+	if err := writeGitHubAnnotations(&buf, findings); err != nil {
+		t.Fatal(err)
+	}
+	want := "::warning file=svc/a%2Cb%3Ac.go,line=3::exported method Store.Get is undocumented\n"
+	if buf.String() != want {
+		t.Errorf("annotations = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// TokenUsage is the token count reported by the provider for a request
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Prompt     float64 `yaml:"prompt" json:"prompt" toml:"prompt"`
+	Completion float64 `yaml:"completion" json:"completion" toml:"completion"`
+}
+
+// Built-in prices; the pricing config adds models or overrides these
+var defaultPricing = map[string]ModelPrice{
+	"gpt-4":                    {Prompt: 30, Completion: 60},
+	"gpt-4-turbo":              {Prompt: 10, Completion: 30},
+	"gpt-4o":                   {Prompt: 2.5, Completion: 10},
+	"gpt-4o-mini":              {Prompt: 0.15, Completion: 0.6},
+	"claude-3-5-sonnet-latest": {Prompt: 3, Completion: 15},
+	"claude-3-5-haiku-latest":  {Prompt: 0.8, Completion: 4},
+}
+
+// usageTracker aggregates token usage across all requests of a run
+type usageTracker struct {
+	mu       sync.Mutex
+	config   *Config
+	model    string
+	requests int
+	total    TokenUsage
+}
+
+// UsageLogEntry is one line of the usage_log file
+type UsageLogEntry struct {
+	Time             time.Time `json:"time"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	Requests         int       `json:"requests"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+	Priced           bool      `json:"priced"`
+}
+
+func newUsageTracker(config *Config) *usageTracker {
+	return &usageTracker{config: config, model: modelName(config)}
+}
+
+// Function to add the usage of one request to the totals
+func (t *usageTracker) record(usage TokenUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+	t.config.Metrics.tokensUsed(usage)
+	t.total.PromptTokens += usage.PromptTokens
+	t.total.CompletionTokens += usage.CompletionTokens
+}
+
+// Function to estimate the cost of the run; false when the model has no
+// price. Models run locally by Ollama, and the mock provider, are free
+// unless priced in the config.
+func (t *usageTracker) cost() (float64, bool) {
+	price, ok := t.config.Pricing[t.model]
+	if !ok {
+		price, ok = defaultPricing[t.model]
+	}
+	if !ok && (t.config.Provider == "ollama" || t.config.Provider == "mock") {
+		return 0, true
+	}
+	if !ok {
+		return 0, false
+	}
+	return (float64(t.total.PromptTokens)*price.Prompt + float64(t.total.CompletionTokens)*price.Completion) / 1e6, true
+}
+
+// Function to print e.g. "14 requests, 182k prompt / 36k completion tokens, ~$2.71"
+func (t *usageTracker) printSummary(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	line := fmt.Sprintf("%d requests, %s prompt / %s completion tokens", t.requests,
+		formatTokens(t.total.PromptTokens), formatTokens(t.total.CompletionTokens))
+	if cost, ok := t.cost(); ok {
+		line += fmt.Sprintf(", ~$%.2f", cost)
+	} else {
+		line += fmt.Sprintf(" (no price configured for %s)", t.model)
+	}
+	fmt.Fprintln(w, line)
+}
+
+// Function to append the run's totals to the usage_log file, if configured
+func (t *usageTracker) appendLog() error {
+	if t.config.UsageLog == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	cost, priced := t.cost()
+	entry := UsageLogEntry{
+		Time:             time.Now().UTC(),
+		Provider:         t.config.Provider,
+		Model:            t.model,
+		Requests:         t.requests,
+		PromptTokens:     t.total.PromptTokens,
+		CompletionTokens: t.total.CompletionTokens,
+		CostUSD:          cost,
+		Priced:           priced,
+	}
+	t.mu.Unlock()
+
+	if entry.Provider == "" {
+		entry.Provider = "openai"
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(t.config.UsageLog, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Function to abbreviate token counts, e.g. 182000 -> "182k"
+func formatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1_000:
+		return fmt.Sprintf("%dk", (n+500)/1000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
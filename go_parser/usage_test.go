@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUsageAccumulatesAcrossChunks(t *testing.T) {
+	server, calls := fakeOpenAI(t)
+	usageLog := filepath.Join(t.TempDir(), "usage.jsonl")
+	config := &Config{APIKey: "test-key", BaseURL: server.URL, NoStream: true, Model: "gpt-4o", MaxPromptTokens: 1, UsageLog: usageLog}
+	results := append(testResults(), testResults()...)
+	results[1].InterfaceName = "Cache"
+	tracker := newUsageTracker(config)
+
+	if _, err := sendData(context.Background(), config, results, tracker); err != nil {
+		t.Fatalf("sendData: %v", err)
+	}
+	if *calls != 2 {
+		t.Fatalf("requests = %d, want one per chunk", *calls)
+	}
+	// 240 prompt tokens at $2.50 and 60 completion tokens at $10 a million
+	if cost, ok := tracker.cost(); !ok || cost != 0.0012 {
+		t.Errorf("cost = %v, %v, want 0.0012", cost, ok)
+	}
+	var summary bytes.Buffer
+	tracker.printSummary(&summary)
+	if summary.String() != "2 requests, 240 prompt / 60 completion tokens, ~$0.00\n" {
+		t.Errorf("summary = %q", summary.String())
+	}
+
+	if err := tracker.appendLog(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(usageLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry UsageLogEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Provider != "openai" || entry.Requests != 2 || entry.PromptTokens != 240 || entry.CostUSD != 0.0012 || !entry.Priced {
+		t.Errorf("usage log entry = %+v", entry)
+	}
+}
+
+func TestUsageCost(t *testing.T) {
+	usage := TokenUsage{PromptTokens: 1_000_000, CompletionTokens: 500_000}
+	tests := []struct {
+		config Config
+		cost   float64
+		priced bool
+	}{
+		{Config{Model: "gpt-4o-mini"}, 0.45, true},
+		{Config{Model: "gpt-4o-mini", Pricing: map[string]ModelPrice{"gpt-4o-mini": {Prompt: 1, Completion: 2}}}, 2, true},
+		{Config{Model: "custom"}, 0, false},
+		{Config{Provider: "ollama"}, 0, true},
+		{Config{Provider: "ollama", Pricing: map[string]ModelPrice{ollamaDefaultModel: {Prompt: 0.1}}}, 0.1, true},
+	}
+	for _, test := range tests {
+		tracker := newUsageTracker(&test.config)
+		tracker.record(usage)
+		if cost, priced := tracker.cost(); cost != test.cost || priced != test.priced {
+			t.Errorf("%s %s: cost = %v, %v, want %v, %v", test.config.Provider, tracker.model, cost, priced, test.cost, test.priced)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"path/filepath"
+)
+
+// Usage is a place where an interface type is consumed
+type Usage struct {
+	Kind     string `json:"kind"`   // "param", "result", "field" or "var"
+	Symbol   string `json:"symbol"` // function, struct or variable using the interface
+	Package  string `json:"package"`
+	Position string `json:"position"` // file:line
+	// Deprecation notice of the function using the interface, if any
+	Deprecated string `json:"deprecated,omitempty"`
+
+	pos token.Position
+}
+
+// Function to record every parameter, result, struct field and variable of a
+// file of package pkgKey (see packageKey) whose type refers to one of the
+// interfaces
+func collectUsages(fset *token.FileSet, file *ast.File, pkgKey string, interfaces map[interfaceRef]*InterfaceDetails) {
+	pkg := file.Name.Name
+	imports := fileImports(file)
+
+	record := func(kind, symbol, deprecated string, field *ast.Field) {
+		for _, ref := range referencedInterfaces(field.Type, interfaces, pkgKey, imports) {
+			iface := interfaces[ref]
+			iface.Usages = append(iface.Usages, Usage{
+				Kind:       kind,
+				Symbol:     symbol,
+				Package:    pkg,
+				Position:   positionString(fset, field.Pos()),
+				Deprecated: deprecated,
+				pos:        fset.Position(field.Pos()),
+			})
+		}
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch decl := n.(type) {
+		case *ast.FuncDecl:
+			symbol := decl.Name.Name
+			if decl.Recv != nil && len(decl.Recv.List) > 0 {
+				if recv, _ := receiverTypeName(decl.Recv.List[0].Type); recv != "" {
+					symbol = recv + "." + symbol
+				}
+			}
+			deprecated := deprecationNotice(commentText(decl.Doc))
+			for _, field := range decl.Type.Params.List {
+				record("param", symbol, deprecated, field)
+			}
+			if decl.Type.Results != nil {
+				for _, field := range decl.Type.Results.List {
+					record("result", symbol, deprecated, field)
+				}
+			}
+		case *ast.TypeSpec:
+			if structType, ok := decl.Type.(*ast.StructType); ok {
+				for _, field := range structType.Fields.List {
+					record("field", decl.Name.Name, "", field)
+				}
+			}
+		case *ast.GenDecl:
+			if decl.Tok != token.VAR {
+				return true
+			}
+			for _, spec := range decl.Specs {
+				valueSpec := spec.(*ast.ValueSpec)
+				if valueSpec.Type == nil {
+					continue
+				}
+				for _, name := range valueSpec.Names {
+					record("var", name.Name, "", &ast.Field{Type: valueSpec.Type, Names: []*ast.Ident{name}})
+				}
+			}
+		}
+		return true
+	})
+}
+
+// Function to find the interfaces a type expression of a file of package
+// pkgKey refers to, such as Store, []Store, map[string]pkg.Store or Store[T].
+// A name refers to the interface of the file's own package, and a qualified
+// name to that of the package it is imported from, so http.Handler is not
+// taken for a local Handler.
+func referencedInterfaces(expr ast.Expr, interfaces map[interfaceRef]*InterfaceDetails, pkgKey string, imports map[string]string) []interfaceRef {
+	var refs []interfaceRef
+	seen := make(map[interfaceRef]bool)
+	add := func(expr ast.Expr) {
+		ref, ok := embeddedRef(expr, pkgKey, imports)
+		if _, found := interfaces[ref]; ok && found && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+
+	ast.Inspect(expr, func(n ast.Node) bool {
+		switch t := n.(type) {
+		case *ast.SelectorExpr:
+			add(t)
+			return false
+		case *ast.Ident:
+			add(t)
+		}
+		return true
+	})
+	return refs
+}
+
+// Function to format a position as file:line
+func positionString(fset *token.FileSet, pos token.Pos) string {
+	return formatPosition(fset.Position(pos))
+}
+
+// Function to format a position as file:line with forward slashes, so the
+// output is the same on every OS
+func formatPosition(position token.Position) string {
+	return fmt.Sprintf("%s:%d", filepath.ToSlash(position.Filename), position.Line)
+}
+
+// Function to summarize usages, e.g. "consumed by 11 functions across 4 packages"
+func usageSummary(usages []Usage) string {
+	functions, packages := usageCounts(usages)
+	return fmt.Sprintf("consumed by %d functions across %d packages (%d usage sites)", functions, packages, len(usages))
+}
+
+// Function to count the distinct functions taking or returning the
+// interface, and the packages using it
+func usageCounts(usages []Usage) (functions, packages int) {
+	functionSet := make(map[string]bool)
+	packageSet := make(map[string]bool)
+	for _, usage := range usages {
+		if usage.Kind == "param" || usage.Kind == "result" {
+			functionSet[usage.Package+"."+usage.Symbol] = true
+		}
+		packageSet[usage.Package] = true
+	}
+	return len(functionSet), len(packageSet)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectUsagesResolvesQualifiedNames(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n\ngo 1.22\n")
+	writeFile(t, filepath.Join(root, "svc", "svc.go"), "package svc\n\ntype Handler interface{ Handle() }\n")
+
+	interfaces, _ := findInterfaces(filepath.Join(root, "svc", "svc.go"), &Config{})
+
+	src := `package api
+
+import (
+	"net/http"
+
+	"example.com/app/svc"
+)
+
+func Serve(h http.Handler) {}
+
+func Run(h svc.Handler) {}
+`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "api.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectUsages(fset, file, packageKey(filepath.Join(root, "api")), interfaces)
+
+	usages := interfaceNamed(interfaces, "Handler").Usages
+	if len(usages) != 1 || usages[0].Symbol != "Run" {
+		t.Errorf("usages = %+v, want only the svc.Handler parameter of Run", usages)
+	}
+}
+
+func TestPackageImportPath(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/app\n")
+	if err := os.MkdirAll(filepath.Join(root, "internal", "store"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := packageImportPath(root); got != "example.com/app" {
+		t.Errorf("module root = %q, want example.com/app", got)
+	}
+	if got := packageImportPath(filepath.Join(root, "internal", "store")); got != "example.com/app/internal/store" {
+		t.Errorf("subpackage = %q, want example.com/app/internal/store", got)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+// Kinds of value declarations
+const (
+	ValueConst = "const"
+	ValueVar   = "var"
+)
+
+// ValueDeclaration is an exported package-level constant or variable
+type ValueDeclaration struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Kind    string `json:"kind"` // "const" or "var"
+	// Declared type, if any; constants repeating the previous line of their
+	// group (as with iota) have its type
+	Type string `json:"type,omitempty"`
+	// Value expression, if any; only the first line of a longer one
+	Value    string `json:"value,omitempty"`
+	Doc      string `json:"doc,omitempty"`
+	Position string `json:"position"`
+
+	pos token.Position
+}
+
+// Function to collect the exported constants and variables of a const or
+// var declaration
+func valueDeclarations(fset *token.FileSet, pkg string, decl *ast.GenDecl) []ValueDeclaration {
+	kind := ValueVar
+	if decl.Tok == token.CONST {
+		kind = ValueConst
+	}
+
+	var values []ValueDeclaration
+	var previousType ast.Expr
+	for _, spec := range decl.Specs {
+		valueSpec := spec.(*ast.ValueSpec)
+		typ := valueSpec.Type
+		// A constant without type and values repeats the line before it
+		if kind == ValueConst && typ == nil && len(valueSpec.Values) == 0 {
+			typ = previousType
+		}
+		previousType = typ
+
+		doc := valueSpec.Doc
+		if doc == nil {
+			doc = valueSpec.Comment
+		}
+		if doc == nil && !decl.Lparen.IsValid() {
+			doc = decl.Doc
+		}
+
+		for i, name := range valueSpec.Names {
+			if !ast.IsExported(name.Name) {
+				continue
+			}
+			value := ValueDeclaration{
+				Name:    name.Name,
+				Package: pkg,
+				Kind:    kind,
+				Doc:     commentText(doc),
+				pos:     fset.Position(name.Pos()),
+			}
+			value.Position = formatPosition(value.pos)
+			if typ != nil {
+				value.Type = exprString(fset, typ)
+			}
+			// With a, b = f() the values can't be told apart
+			if len(valueSpec.Values) == len(valueSpec.Names) {
+				value.Value = firstLine(exprString(fset, valueSpec.Values[i]))
+			}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Function to shorten a multi-line expression, e.g. a composite literal, to
+// its first line
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return strings.TrimRight(s[:i], " ") + " ..."
+	}
+	return s
+}
+
+// Function to render one value as a markdown list item, e.g.
+// "- `MaxRetries int = 3`: MaxRetries bounds the retries."
+func renderValue(b *strings.Builder, value ValueDeclaration, qualified bool) {
+	name := value.Name
+	if qualified {
+		name = value.Package + "." + name
+	}
+	if value.Type != "" {
+		name += " " + value.Type
+	}
+	if value.Value != "" {
+		name += " = " + value.Value
+	}
+	fmt.Fprintf(b, "- `%s`", name)
+	if summary := docSummary(value.Doc); summary != "" {
+		fmt.Fprintf(b, ": %s", summary)
+	}
+	b.WriteString("\n")
+}
+
+// Catalog keys of the headings of the markdown sections of values,
+// constants first
+var valueSections = []struct{ kind, heading string }{{ValueConst, "constants"}, {ValueVar, "variables"}}
+
+// Function to pick the values of one kind
+func valuesOfKind(values []ValueDeclaration, kind string) []ValueDeclaration {
+	var items []ValueDeclaration
+	for _, value := range values {
+		if value.Kind == kind {
+			items = append(items, value)
+		}
+	}
+	return items
+}
+
+// Function to render the constants and variables of the scanned files as a
+// markdown section, with the headings from msgs; empty when there are none
+func renderValues(values []ValueDeclaration, msgs messages) string {
+	var b strings.Builder
+	for _, section := range valueSections {
+		items := valuesOfKind(values, section.kind)
+		if len(items) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", msgs.text(section.heading))
+		for _, value := range items {
+			renderValue(&b, value, true)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\n# " + msgs.text("constants_and_variables") + "\n" + b.String()
+}
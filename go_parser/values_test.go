@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValueDeclarations(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "svc", "svc.go")
+	writeFile(t, ifacePath, `package svc
+
+import "time"
+
+type Store interface{ Get() }
+
+// MaxRetries bounds the retries. It is never zero.
+const MaxRetries = 3
+
+// Levels of logging
+const (
+	Debug Level = iota // Debug logs everything.
+	Info
+	internal
+)
+
+type Level int
+
+var (
+	// Timeout of every request.
+	Timeout time.Duration = 30 * time.Second
+	Names                 = map[string]int{
+		"a": 1,
+	}
+	A, B = split()
+	_    = 1
+)
+
+func split() (int, int) { return 1, 2 }
+`)
+
+	config := &Config{}
+	interfaces, _ := findInterfaces(ifacePath, config)
+	_, _, values := findImplementations(context.Background(), []string{filepath.Join(root, "svc")}, interfaces, config)
+
+	var got []string
+	for _, value := range values {
+		got = append(got, strings.Join([]string{value.Kind, value.Name, value.Type, value.Value, value.Doc}, "|"))
+	}
+	want := []string{
+		"const|MaxRetries||3|MaxRetries bounds the retries. It is never zero.",
+		"const|Debug|Level|iota|Debug logs everything.",
+		"const|Info|Level||",
+		"var|Timeout|time.Duration|30 * time.Second|Timeout of every request.",
+		"var|Names||map[string]int{ ...|",
+		"var|A|||",
+		"var|B|||",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("values:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if values[0].Position != filepath.ToSlash(ifacePath)+":8" {
+		t.Errorf("position = %s", values[0].Position)
+	}
+
+	markdown := renderValues(values, nil)
+	for _, want := range []string{
+		"\n## Constants\n\n- `svc.MaxRetries = 3`: MaxRetries bounds the retries.\n",
+		"- `svc.Info Level`\n",
+		"\n## Variables\n\n- `svc.Timeout time.Duration = 30 * time.Second`: Timeout of every request.\n",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("markdown lacks %q:\n%s", want, markdown)
+		}
+	}
+	if renderValues(nil, nil) != "" {
+		t.Error("a section without values")
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// How often render -watch looks for changed files, and how long they must
+// stay unchanged before it renders again, so that a burst of saves, e.g. a
+// formatter rewriting files or a branch switch, renders once
+const (
+	watchInterval = 500 * time.Millisecond
+	watchSettle   = 300 * time.Millisecond
+)
+
+// fileStamp tells whether a file changed between two looks at it
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Function to call run, then again each time the Go files the config reads
+// (interface sources and the scanned roots, with their ignore rules) are
+// created, changed or removed, until ctx is done. Files are polled every
+// interval, and a change is acted on once nothing changed for settle. Files
+// run writes itself, such as stubs, don't count as changes.
+func watch(ctx context.Context, config *Config, interval, settle time.Duration, run func()) {
+	run()
+	last := goFileStamps(ctx, config)
+	fmt.Fprintln(os.Stderr, "Watching for changes, press Ctrl-C to stop")
+	for {
+		if sleepContext(ctx, interval) != nil {
+			return
+		}
+		current := goFileStamps(ctx, config)
+		if sameStamps(last, current) {
+			continue
+		}
+		for {
+			if sleepContext(ctx, settle) != nil {
+				return
+			}
+			next := goFileStamps(ctx, config)
+			if sameStamps(current, next) {
+				break
+			}
+			current = next
+		}
+		fmt.Fprintf(os.Stderr, "%d Go files changed, running again\n", changedFiles(last, current))
+		run()
+		last = goFileStamps(ctx, config)
+	}
+}
+
+// Function to stamp the Go files the config reads. Files that can't be
+// listed or stat'ed are left out, so they count as removed.
+func goFileStamps(ctx context.Context, config *Config) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	add := func(path string) {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	for _, path := range interfaceFiles(config.interfaceSources(), config) {
+		add(path)
+	}
+	for _, root := range config.scanRoots() {
+		_ = walkGoFiles(ctx, root, config, add)
+	}
+	return stamps
+}
+
+func sameStamps(a, b map[string]fileStamp) bool {
+	return changedFiles(a, b) == 0
+}
+
+// Function to count the files created, changed or removed between two sets
+// of stamps
+func changedFiles(before, after map[string]fileStamp) int {
+	changed := 0
+	for path, stamp := range after {
+		if previous, ok := before[path]; !ok || previous != stamp {
+			changed++
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed++
+		}
+	}
+	return changed
+}
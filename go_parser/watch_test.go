@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	root := t.TempDir()
+	ifacePath := filepath.Join(root, "api.go")
+	writeFile(t, ifacePath, "package api\n\ntype Store interface{ Get(id string) string }\n")
+	implPath := filepath.Join(root, "mem", "mem.go")
+	writeFile(t, implPath, "package mem\n")
+	config := &Config{GoFilePath: ifacePath, GoDirectory: root}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan int, 10)
+	done := make(chan struct{})
+	count := 0
+	go func() {
+		defer close(done)
+		watch(ctx, config, 10*time.Millisecond, 100*time.Millisecond, func() {
+			count++
+			runs <- count
+		})
+	}()
+	waitRun := func(want int) {
+		t.Helper()
+		select {
+		case got := <-runs:
+			if got != want {
+				t.Fatalf("run %d, want %d", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no run %d", want)
+		}
+	}
+	waitRun(1)
+
+	// A burst of saves runs once, after it settles
+	for i := 0; i < 3; i++ {
+		writeFile(t, implPath, "package mem\n"+strings.Repeat("\n", i+1))
+		time.Sleep(5 * time.Millisecond)
+	}
+	waitRun(2)
+
+	// A new Go file counts, other files don't
+	writeFile(t, filepath.Join(root, "notes.txt"), "todo\n")
+	writeFile(t, filepath.Join(root, "mem", "extra.go"), "package mem\n")
+	waitRun(3)
+	select {
+	case got := <-runs:
+		t.Errorf("unexpected run %d", got)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch didn't stop with its context")
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	now := time.Now()
+	before := map[string]fileStamp{"a.go": {now, 1}, "b.go": {now, 2}, "c.go": {now, 3}}
+	after := map[string]fileStamp{"a.go": {now, 1}, "b.go": {now.Add(time.Second), 2}, "d.go": {now, 4}}
+	if got := changedFiles(before, after); got != 3 {
+		t.Errorf("changed files = %d, want b.go changed, c.go removed and d.go created", got)
+	}
+	if !sameStamps(before, before) {
+		t.Error("stamps differ from themselves")
+	}
+}